@@ -16,6 +16,7 @@ import (
 	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 
+	updoconfig "github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/utils"
 )
 
@@ -32,21 +33,7 @@ const (
 	_functionCheckTimeout = 5 * time.Second
 )
 
-var _defaultRegions = []string{
-	"us-east-1",      // N. Virginia
-	"us-west-1",      // N. California
-	"us-west-2",      // Oregon
-	"eu-west-1",      // Ireland
-	"eu-central-1",   // Frankfurt
-	"ap-southeast-1", // Singapore
-	"ap-southeast-2", // Sydney
-	"ap-northeast-1", // Tokyo
-	"ap-northeast-2", // Seoul
-	"ap-south-1",     // Mumbai
-	"sa-east-1",      // São Paulo
-	"ca-central-1",   // Canada
-	"eu-west-2",      // London
-}
+var _defaultRegions = updoconfig.SupportedRegions
 
 type Deployer struct {
 	lambdaClient *lambda.Client
@@ -21,6 +21,7 @@ type LambdaRequest struct {
 	Headers         []string `json:"headers"`
 	Body            string   `json:"body"`
 	Timeout         int      `json:"timeout"`
+	TimeoutMs       int64    `json:"timeout_ms,omitempty"`
 	FollowRedirects bool     `json:"follow_redirects"`
 	AcceptRedirects bool     `json:"accept_redirects"`
 	SkipSSL         bool     `json:"skip_ssl"`
@@ -49,6 +50,7 @@ type HttpTraceInfoSimple struct {
 	WaitMs             float64 `json:"wait_ms"`
 	DNSLookupMs        float64 `json:"dns_lookup_ms"`
 	TCPConnectionMs    float64 `json:"tcp_connection_ms"`
+	TLSHandshakeMs     float64 `json:"tls_handshake_ms,omitempty"`
 	TimeToFirstByteMs  float64 `json:"time_to_first_byte_ms"`
 	DownloadDurationMs float64 `json:"download_duration_ms"`
 }
@@ -106,7 +108,8 @@ func invokeLambdaInRegion(url string, config net.NetworkConfig, region string, p
 		Method:          config.Method,
 		Headers:         config.Headers,
 		Body:            config.Body,
-		Timeout:         int(config.Timeout / time.Second),
+		Timeout:         timeoutSeconds(config.Timeout),
+		TimeoutMs:       config.Timeout.Milliseconds(),
 		FollowRedirects: config.FollowRedirects,
 		AcceptRedirects: config.AcceptRedirects,
 		SkipSSL:         config.SkipSSL,
@@ -185,6 +188,7 @@ func invokeLambdaInRegion(url string, config net.NetworkConfig, region string, p
 			Wait:             time.Duration(lambdaResp.TraceInfo.WaitMs) * time.Millisecond,
 			DNSLookup:        time.Duration(lambdaResp.TraceInfo.DNSLookupMs) * time.Millisecond,
 			TCPConnection:    time.Duration(lambdaResp.TraceInfo.TCPConnectionMs) * time.Millisecond,
+			TLSHandshake:     time.Duration(lambdaResp.TraceInfo.TLSHandshakeMs) * time.Millisecond,
 			TimeToFirstByte:  time.Duration(lambdaResp.TraceInfo.TimeToFirstByteMs) * time.Millisecond,
 			DownloadDuration: time.Duration(lambdaResp.TraceInfo.DownloadDurationMs) * time.Millisecond,
 		}
@@ -209,3 +213,12 @@ func loadAWSConfig(ctx context.Context, region string, profile string) (aws.Conf
 
 	return config.LoadDefaultConfig(ctx, opts...)
 }
+
+// timeoutSeconds rounds timeout up to whole seconds for functions deployed
+// before timeout_ms was added, which only read timeout.
+func timeoutSeconds(timeout time.Duration) int {
+	if timeout <= 0 {
+		return 0
+	}
+	return int((timeout + time.Second - 1) / time.Second)
+}
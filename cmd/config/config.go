@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Owloops/updo/cmd/config/convert"
+	"github.com/Owloops/updo/cmd/config/show"
+	"github.com/Owloops/updo/cmd/config/validate"
+	"github.com/Owloops/updo/cmd/root"
+)
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with updo configuration files",
+	Long: `Inspect, check and convert updo configuration files.
+
+This command group provides operations on the TOML, YAML and JSON
+configuration files passed to updo monitor with --config.`,
+	Example: `  updo config validate updo.toml
+  updo config validate --config updo.yaml
+  updo config convert updo.toml updo.yaml
+  updo config show --resolved updo.toml`,
+}
+
+func init() {
+	ConfigCmd.AddCommand(validate.ValidateCmd)
+	ConfigCmd.AddCommand(convert.ConvertCmd)
+	ConfigCmd.AddCommand(show.ShowCmd)
+
+	root.HideMonitoringFlags(ConfigCmd)
+}
@@ -0,0 +1,77 @@
+package convert
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Owloops/updo/cmd/root"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+	"github.com/spf13/cobra"
+)
+
+var ConvertCmd = &cobra.Command{
+	Use:   "convert <input> [output]",
+	Short: "Convert a configuration file between TOML, YAML and JSON",
+	Long: `Convert a configuration file between TOML, YAML and JSON.
+
+Formats are picked by file extension (.toml, .yaml or .yml, .json). Without
+an output file the result is printed, in the format given by --to. The
+input's syntax and keys are checked first, while ${...} references and
+included files are copied as they are. Keys keep their order; comments
+are dropped.`,
+	Example: `  updo config convert updo.toml updo.yaml
+  updo config convert updo.yaml --to json
+  updo config convert updo.json updo.toml`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		input := args[0]
+		toFlag, _ := cmd.Flags().GetString("to")
+
+		var to string
+		switch {
+		case toFlag != "":
+			format, err := config.ParseFormat(toFlag)
+			if err != nil {
+				utils.Log.Error(err.Error())
+				os.Exit(1)
+			}
+			to = format
+		case len(args) == 2:
+			to = config.DetectFormat(args[1])
+		default:
+			utils.Log.Error("--to is required when no output file is given")
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(input)
+		if err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+		if err := config.CheckSchema(data, config.DetectFormat(input)); err != nil {
+			utils.Log.Error(fmt.Sprintf("%s is not valid:\n%v", input, err))
+			os.Exit(1)
+		}
+		converted, err := config.Convert(data, config.DetectFormat(input), to)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("Failed to convert %s: %v", input, err))
+			os.Exit(1)
+		}
+
+		if len(args) == 1 {
+			fmt.Print(string(converted))
+			return
+		}
+		if err := os.WriteFile(args[1], converted, 0o600); err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+		utils.Log.Success(fmt.Sprintf("Converted %s to %s", input, args[1]))
+	},
+}
+
+func init() {
+	ConvertCmd.Flags().String("to", "", "Output format: toml, yaml or json (default: from the output file extension)")
+	root.HideMonitoringFlags(ConvertCmd)
+}
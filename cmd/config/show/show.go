@@ -0,0 +1,102 @@
+package show
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Owloops/updo/cmd/root"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/exporter"
+	"github.com/Owloops/updo/redact"
+	"github.com/Owloops/updo/utils"
+	"github.com/spf13/cobra"
+)
+
+// resolvedTargets is what --resolved prints.
+type resolvedTargets struct {
+	Targets []config.Target `mapstructure:"targets"`
+}
+
+var ShowCmd = &cobra.Command{
+	Use:   "show [file]",
+	Short: "Print a configuration file",
+	Long: `Print a configuration file after validating it.
+
+With --resolved, prints the effective settings of every target instead,
+including those of included files, once templates, [defaults] and [global]
+have been applied and environment variables and secret files have been
+substituted. Secrets are masked following the [redact] settings unless
+--show-secrets is given.`,
+	Example: `  updo config show updo.toml
+  updo config show --resolved updo.toml
+  updo config show --resolved --format json --config updo.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile := root.AppConfig.ConfigFile
+		if len(args) > 0 {
+			configFile = args[0]
+		}
+		if configFile == "" {
+			utils.Log.Error("A config file is required")
+			utils.Log.Plain("Use updo config show --help for usage information")
+			os.Exit(1)
+		}
+
+		resolved, _ := cmd.Flags().GetBool("resolved")
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		format := config.DetectFormat(configFile)
+		if formatFlag, _ := cmd.Flags().GetString("format"); formatFlag != "" {
+			parsed, err := config.ParseFormat(formatFlag)
+			if err != nil {
+				utils.Log.Error(err.Error())
+				os.Exit(1)
+			}
+			format = parsed
+		}
+
+		cfg, err := config.LoadConfig(configFile)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("%s is not valid:\n%v", configFile, err))
+			os.Exit(1)
+		}
+
+		var output []byte
+		if resolved {
+			targets := make([]config.Target, len(cfg.Targets))
+			var redactor *redact.Redactor
+			if !showSecrets {
+				redactor, err = redact.New(cfg.Redact.Headers, cfg.Redact.QueryParams, cfg.Redact.BodyPatterns, cfg.Redact.DisableDefaults)
+				if err != nil {
+					utils.Log.Error(fmt.Sprintf("Error in redact config: %v", err))
+					os.Exit(1)
+				}
+			}
+			for i, target := range cfg.Targets {
+				target.Extends = nil
+				if redactor != nil {
+					target = exporter.RedactTarget(redactor, target)
+				}
+				targets[i] = target
+			}
+			output, err = config.Marshal(resolvedTargets{Targets: targets}, format)
+		} else {
+			var data []byte
+			data, err = os.ReadFile(configFile)
+			if err == nil {
+				output, err = config.Convert(data, config.DetectFormat(configFile), format)
+			}
+		}
+		if err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+		fmt.Print(string(output))
+	},
+}
+
+func init() {
+	ShowCmd.Flags().Bool("resolved", false, "Print the effective settings of every target")
+	ShowCmd.Flags().String("format", "", "Output format: toml, yaml or json (default: the format of the file)")
+	ShowCmd.Flags().Bool("show-secrets", false, "Do not mask secrets in --resolved output")
+	root.HideMonitoringFlags(ShowCmd)
+}
@@ -0,0 +1,67 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Owloops/updo/cmd/root"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+	"github.com/spf13/cobra"
+)
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a configuration file for errors",
+	Long: `Check a configuration file without starting to monitor.
+
+Reports unknown keys, values of the wrong type, invalid URLs, methods and
+headers, unknown regions and duplicate target names, each with the file and
+line it is on. Files listed in include are checked too, while problems in
+discovery files are reported as warnings, since monitoring skips those
+files. The same checks run when updo monitor loads a configuration file.
+
+Exits with status 1 if the file has errors.`,
+	Example: `  updo config validate updo.toml
+  updo config validate --config updo.toml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile := root.AppConfig.ConfigFile
+		if len(args) > 0 {
+			configFile = args[0]
+		}
+		if configFile == "" {
+			utils.Log.Error("A config file is required")
+			utils.Log.Plain("Use updo config validate --help for usage information")
+			os.Exit(1)
+		}
+
+		cfg, err := config.LoadConfig(configFile)
+		if err != nil {
+			var validationErrs config.ValidationErrors
+			if !errors.As(err, &validationErrs) {
+				utils.Log.Error(fmt.Sprintf("%s: %v", configFile, err))
+				os.Exit(1)
+			}
+			for _, validationErr := range validationErrs {
+				if validationErr.File != "" {
+					utils.Log.Error(validationErr.Error())
+					continue
+				}
+				utils.Log.Error(fmt.Sprintf("%s: %v", configFile, validationErr))
+			}
+			utils.Log.Plain(fmt.Sprintf("\n%d error(s) found in %s", len(validationErrs), configFile))
+			os.Exit(1)
+		}
+
+		for _, warning := range cfg.Warnings {
+			utils.Log.Warn(warning.Error())
+		}
+		utils.Log.Success(fmt.Sprintf("%s is valid (%d targets)", configFile, len(cfg.Targets)))
+	},
+}
+
+func init() {
+	root.HideMonitoringFlags(ValidateCmd)
+}
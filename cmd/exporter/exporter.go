@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Owloops/updo/cmd/root"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/exporter"
+	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
+	"github.com/Owloops/updo/utils"
+	"github.com/spf13/cobra"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export [target]",
+	Short: "Print a target as a curl command or HAR entry",
+	Long: `Print a target as a curl command, or check it once and print the
+request and response as a HAR 1.2 file, to reproduce a check by hand.
+
+With --config, the target is picked by name or URL among those of the file,
+after templates and [global] settings apply. Without it, the target is the
+URL given, with the request flags of updo monitor (-X, -H, -d, -k, -l, -t).
+
+Secrets are masked following the [redact] settings unless --show-secrets
+is given.`,
+	Example: `  updo export --config updo.toml API
+  updo export --config updo.toml API --format har --output api.har
+  updo export -X POST -H "Content-Type: application/json" -d '{"ping":true}' https://api.example.com/ping
+  updo export --show-secrets --config updo.toml https://api.example.com/health`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		format = strings.ToLower(format)
+		if format != "curl" && format != "har" {
+			utils.Log.Error(fmt.Sprintf("unknown export format %q, expected curl or har", format))
+			os.Exit(1)
+		}
+
+		target, redactConfig, err := resolveTarget(args)
+		if err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+
+		var redactor *redact.Redactor
+		if !showSecrets {
+			redactor, err = redact.New(redactConfig.Headers, redactConfig.QueryParams, redactConfig.BodyPatterns, redactConfig.DisableDefaults)
+			if err != nil {
+				utils.Log.Error(fmt.Sprintf("Error in redact config: %v", err))
+				os.Exit(1)
+			}
+		}
+
+		var data []byte
+		if format == "curl" {
+			data = []byte(exporter.Curl(target, redactor))
+		} else {
+			result := net.CheckWebsite(target.URL, net.NetworkConfig{
+				Timeout:         target.GetTimeout(),
+				ShouldFail:      target.ShouldFail,
+				FollowRedirects: config.BoolVal(target.FollowRedirects, false),
+				AcceptRedirects: config.BoolVal(target.AcceptRedirects, false),
+				SkipSSL:         config.BoolVal(target.SkipSSL, false),
+				AssertText:      target.AssertText,
+				Headers:         target.Headers,
+				Method:          target.Method,
+				Body:            target.Body,
+				BodySizeLimit:   config.Int64Val(target.BodySizeLimit, net.DefaultBodySizeLimit),
+			})
+			data, err = exporter.HAR(exporter.HAREntry(result, redactor))
+			if err != nil {
+				utils.Log.Error(fmt.Sprintf("Failed to write HAR: %v", err))
+				os.Exit(1)
+			}
+		}
+
+		if output == "" {
+			fmt.Print(string(data))
+			return
+		}
+		if err := os.WriteFile(output, data, 0o600); err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+		utils.Log.Success(fmt.Sprintf("Exported %s to %s", target.Name, output))
+	},
+}
+
+// resolveTarget returns the target to export, picked from the config file
+// or built from the monitoring flags, and the redaction settings in use.
+func resolveTarget(args []string) (config.Target, config.Redact, error) {
+	appConfig := root.AppConfig
+	if appConfig.ConfigFile == "" {
+		url := appConfig.URL
+		if len(args) > 0 {
+			url = args[0]
+		}
+		if url == "" {
+			return config.Target{}, config.Redact{}, fmt.Errorf("a URL, or a target of the --config file, is required")
+		}
+		if !strings.Contains(url, "://") {
+			url = "https://" + url
+		}
+		return config.Target{
+			URL:             url,
+			Name:            url,
+			Timeout:         appConfig.Timeout,
+			ShouldFail:      appConfig.ShouldFail,
+			FollowRedirects: &appConfig.FollowRedirects,
+			AcceptRedirects: &appConfig.AcceptRedirects,
+			SkipSSL:         &appConfig.SkipSSL,
+			AssertText:      appConfig.AssertText,
+			Headers:         appConfig.Headers,
+			Method:          appConfig.Method,
+			Body:            appConfig.Body,
+		}, config.Redact{}, nil
+	}
+
+	cfg, err := config.LoadConfig(appConfig.ConfigFile)
+	if err != nil {
+		return config.Target{}, config.Redact{}, fmt.Errorf("error loading config file: %w", err)
+	}
+	if len(args) == 0 {
+		if len(cfg.Targets) == 1 {
+			return cfg.Targets[0], cfg.Redact, nil
+		}
+		return config.Target{}, config.Redact{}, fmt.Errorf("%s has %d targets, name the one to export: %s", appConfig.ConfigFile, len(cfg.Targets), targetNames(cfg.Targets))
+	}
+	for _, target := range cfg.Targets {
+		if target.Name == args[0] || target.URL == args[0] {
+			return target, cfg.Redact, nil
+		}
+	}
+	return config.Target{}, config.Redact{}, fmt.Errorf("no target named %q in %s, expected one of: %s", args[0], appConfig.ConfigFile, targetNames(cfg.Targets))
+}
+
+func targetNames(targets []config.Target) string {
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = target.Name
+		if names[i] == "" {
+			names[i] = target.URL
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	ExportCmd.Flags().String("format", "curl", "Export format: curl, or har to check the target once and record it")
+	ExportCmd.Flags().StringP("output", "o", "", "File to write instead of printing")
+	ExportCmd.Flags().Bool("show-secrets", false, "Do not mask secrets")
+}
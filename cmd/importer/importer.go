@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/Owloops/updo/cmd/root"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/importer"
+	"github.com/Owloops/updo/utils"
+	"github.com/spf13/cobra"
+)
+
+// importedTargets is the config file import writes.
+type importedTargets struct {
+	Targets []config.Target `mapstructure:"targets"`
+}
+
+var ImportCmd = &cobra.Command{
+	Use:   "import [file | -- curl ...]",
+	Short: "Create targets from curl commands, HAR files or OpenAPI specs",
+	Long: `Create targets from curl commands, HAR files or OpenAPI 3 documents.
+
+The input is a file, standard input when the file is - or missing, or a
+curl command given after --. Its format is detected from the content
+unless --from is given:
+
+  curl     One or more curl command lines, such as those copied from a
+           browser or API documentation
+  har      A HAR file exported from a browser's network panel; the
+           recorded response status is what each check expects
+  openapi  An OpenAPI 3 document in JSON or YAML; every GET operation
+           becomes a target, with parameters filled from their examples
+
+The targets are printed as a config file, or written to --output in the
+format given by its extension.`,
+	Example: `  updo import requests.har --output updo.toml
+  updo import openapi.yaml --base-url https://staging.example.com
+  pbpaste | updo import --from curl
+  updo import -- curl -X POST -H "Content-Type: application/json" -d '{"ping":true}' https://api.example.com/ping`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		output, _ := cmd.Flags().GetString("output")
+
+		format := config.FormatTOML
+		if output != "" {
+			format = config.DetectFormat(output)
+		}
+		if formatFlag, _ := cmd.Flags().GetString("format"); formatFlag != "" {
+			parsed, err := config.ParseFormat(formatFlag)
+			if err != nil {
+				utils.Log.Error(err.Error())
+				os.Exit(1)
+			}
+			format = parsed
+		}
+
+		var result importer.Result
+		var err error
+		if len(args) > 0 && (path.Base(args[0]) == "curl" || path.Base(args[0]) == "curl.exe") {
+			result.Targets, err = importer.CurlArgs(args[1:])
+		} else {
+			if from != "" {
+				if from, err = importer.ParseFormat(from); err != nil {
+					utils.Log.Error(err.Error())
+					os.Exit(1)
+				}
+			}
+			var data []byte
+			data, err = readInput(args)
+			if err != nil {
+				utils.Log.Error(err.Error())
+				os.Exit(1)
+			}
+			result, err = importer.Import(data, from, importer.Options{BaseURL: baseURL})
+		}
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("Failed to import: %v", err))
+			os.Exit(1)
+		}
+
+		for _, skipped := range result.Skipped {
+			fmt.Fprintf(os.Stderr, "! Skipped %s\n", skipped)
+		}
+		if len(result.Targets) == 0 {
+			utils.Log.Error("No targets to import")
+			os.Exit(1)
+		}
+
+		data, err := config.Marshal(importedTargets{Targets: result.Targets}, format)
+		if err != nil {
+			utils.Log.Error(fmt.Sprintf("Failed to write targets: %v", err))
+			os.Exit(1)
+		}
+		if output == "" {
+			fmt.Print(string(data))
+			return
+		}
+
+		if _, err := os.Stat(output); err == nil {
+			if force, _ := cmd.Flags().GetBool("force"); !force {
+				utils.Log.Error(fmt.Sprintf("%s already exists, use --force to overwrite it", output))
+				os.Exit(1)
+			}
+		}
+		if err := os.WriteFile(output, data, 0o600); err != nil {
+			utils.Log.Error(err.Error())
+			os.Exit(1)
+		}
+		if _, err := config.LoadConfig(output); err != nil {
+			utils.Log.Error(fmt.Sprintf("%s was written but is not valid:\n%v", output, err))
+			os.Exit(1)
+		}
+		utils.Log.Success(fmt.Sprintf("Imported %d target(s) to %s", len(result.Targets), output))
+	},
+}
+
+func readInput(args []string) ([]byte, error) {
+	if len(args) == 0 || args[0] == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	if len(args) > 1 {
+		return nil, fmt.Errorf("expected one input file, got %d arguments", len(args))
+	}
+	return os.ReadFile(args[0])
+}
+
+func init() {
+	ImportCmd.Flags().String("from", "", "Input format: curl, har or openapi (default: detected from the content)")
+	ImportCmd.Flags().String("base-url", "", "Server URL to use instead of those of an OpenAPI document")
+	ImportCmd.Flags().StringP("output", "o", "", "Config file to write (TOML, YAML or JSON, by extension)")
+	ImportCmd.Flags().String("format", "", "Format to print in when no output file is given: toml, yaml or json (default: toml)")
+	ImportCmd.Flags().Bool("force", false, "Overwrite the output file if it exists")
+	root.HideMonitoringFlags(ImportCmd)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Owloops/updo/cmd/root"
 	"github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 	"github.com/Owloops/updo/simple"
 	"github.com/Owloops/updo/tui"
 	"github.com/spf13/cobra"
@@ -22,7 +23,7 @@ with a visual interface or in simple mode with text output.
 
 You can monitor multiple targets by:
 - Providing multiple URLs as arguments
-- Using --config flag with a TOML configuration file`,
+- Using --config flag with a TOML, YAML or JSON configuration file`,
 	Example: `  updo monitor https://example.com
   updo monitor https://example.com https://google.com
   updo monitor --config updo.toml
@@ -36,6 +37,9 @@ You can monitor multiple targets by:
 		profile, _ := cmd.Flags().GetString("profile")
 
 		var targets []config.Target
+		var metricsConfig config.Metrics
+		var logsConfig config.Logs
+		var discoveryFiles, includeFiles []string
 
 		if appConfig.ConfigFile != "" {
 			cfg, err := config.LoadConfig(appConfig.ConfigFile)
@@ -43,7 +47,21 @@ You can monitor multiple targets by:
 				fmt.Printf("Error loading config file: %v\n", err)
 				os.Exit(1)
 			}
+			for _, warning := range cfg.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+			}
 			targets = cfg.FilterTargets(appConfig.Only, appConfig.Skip)
+			metricsConfig = cfg.Metrics
+			logsConfig = cfg.Logs
+			discoveryFiles = cfg.FileSDPatterns(appConfig.ConfigFile)
+			includeFiles = cfg.IncludePatterns
+
+			redactor, err := redact.New(cfg.Redact.Headers, cfg.Redact.QueryParams, cfg.Redact.BodyPatterns, cfg.Redact.DisableDefaults)
+			if err != nil {
+				fmt.Printf("Error in redact config: %v\n", err)
+				os.Exit(1)
+			}
+			redact.Set(redactor)
 			if appConfig.Count == 0 && cfg.Global.Count > 0 {
 				appConfig.Count = cfg.Global.Count
 			}
@@ -68,8 +86,8 @@ You can monitor multiple targets by:
 				target := config.Target{
 					URL:             net.AutoDetectProtocol(url),
 					Name:            fmt.Sprintf("Target-%d", i+1),
-					RefreshInterval: int(appConfig.RefreshInterval.Seconds()),
-					Timeout:         int(appConfig.Timeout.Seconds()),
+					RefreshInterval: appConfig.RefreshInterval,
+					Timeout:         appConfig.Timeout,
 					ShouldFail:      appConfig.ShouldFail,
 					FollowRedirects: &appConfig.FollowRedirects,
 					AcceptRedirects: &appConfig.AcceptRedirects,
@@ -86,7 +104,9 @@ You can monitor multiple targets by:
 			}
 		}
 
-		if len(targets) == 0 {
+		// With file_sd, targets may be discovered once monitoring runs.
+		waitForDiscovery := len(discoveryFiles) > 0 && appConfig.Count == 0
+		if len(targets) == 0 && !waitForDiscovery {
 			fmt.Println("Error: No targets to monitor after filtering")
 			fmt.Println("Check your --only/--skip flags or config file settings")
 			os.Exit(1)
@@ -96,20 +116,44 @@ You can monitor multiple targets by:
 
 		if useSimpleMode {
 			options := simple.MonitoringOptions{
-				Count:         appConfig.Count,
-				Log:           appConfig.Log,
-				Regions:       regions,
-				Profile:       profile,
-				PrometheusURL: appConfig.PrometheusURL,
+				Count:          appConfig.Count,
+				Log:            appConfig.Log,
+				Regions:        regions,
+				Profile:        profile,
+				PrometheusURL:  appConfig.PrometheusURL,
+				StateFile:      appConfig.StateFile,
+				MetricsListen:  appConfig.MetricsListen,
+				OTLPEndpoint:   appConfig.OTLPEndpoint,
+				OTLPProtocol:   appConfig.OTLPProtocol,
+				Metrics:        metricsConfig,
+				LogOutput:      appConfig.LogOutput,
+				LogFormat:      appConfig.LogFormat,
+				LogFields:      appConfig.LogFields,
+				Logs:           logsConfig,
+				ConfigFile:     appConfig.ConfigFile,
+				Only:           appConfig.Only,
+				Skip:           appConfig.Skip,
+				DiscoveryFiles: discoveryFiles,
+				IncludeFiles:   includeFiles,
 			}
 			simple.StartMultiTargetMonitoring(targets, options)
 		} else {
 			options := tui.Options{
-				Count:         appConfig.Count,
-				Log:           appConfig.Log,
-				Regions:       regions,
-				Profile:       profile,
-				PrometheusURL: appConfig.PrometheusURL,
+				Count:          appConfig.Count,
+				Log:            appConfig.Log,
+				Regions:        regions,
+				Profile:        profile,
+				PrometheusURL:  appConfig.PrometheusURL,
+				StateFile:      appConfig.StateFile,
+				MetricsListen:  appConfig.MetricsListen,
+				OTLPEndpoint:   appConfig.OTLPEndpoint,
+				OTLPProtocol:   appConfig.OTLPProtocol,
+				Metrics:        metricsConfig,
+				ConfigFile:     appConfig.ConfigFile,
+				Only:           appConfig.Only,
+				Skip:           appConfig.Skip,
+				DiscoveryFiles: discoveryFiles,
+				IncludeFiles:   includeFiles,
 			}
 			tui.StartMonitoring(targets, options)
 		}
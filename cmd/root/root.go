@@ -1,15 +1,17 @@
 package root
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/Owloops/updo/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 const (
-	_defaultRefresh = 5
-	_defaultTimeout = 10
+	_defaultRefresh = 5 * time.Second
+	_defaultTimeout = 10 * time.Second
 	_defaultMethod  = "GET"
 )
 
@@ -30,15 +32,53 @@ type Config struct {
 	Method          string
 	Body            string
 	Log             string
+	LogOutput       string
+	LogFormat       string
+	LogFields       []string
 	Only            []string
 	Skip            []string
 	WebhookURL      string
 	WebhookHeaders  []string
 	PrometheusURL   string
+	StateFile       string
+	MetricsListen   string
+	OTLPEndpoint    string
+	OTLPProtocol    string
 }
 
 var AppConfig Config
 
+// durationValue is a flag taking a duration such as "500ms" or "1m30s", or
+// a plain number of seconds. Durations below min are rejected.
+type durationValue struct {
+	p   *time.Duration
+	min time.Duration
+}
+
+func newDurationValue(value time.Duration, p *time.Duration, min time.Duration) *durationValue {
+	*p = value
+	return &durationValue{p: p, min: min}
+}
+
+func (d *durationValue) Set(s string) error {
+	parsed, err := config.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if parsed <= 0 {
+		return fmt.Errorf("duration must be positive, got %q", s)
+	}
+	if parsed < d.min {
+		return fmt.Errorf("must be at least %s", d.min)
+	}
+	*d.p = parsed
+	return nil
+}
+
+func (d *durationValue) Type() string { return "duration" }
+
+func (d *durationValue) String() string { return config.FormatDuration(*d.p) }
+
 var RootCmd = &cobra.Command{
 	Use:   "updo",
 	Short: "A simple website monitoring tool",
@@ -48,6 +88,7 @@ and a simple text-based output mode.`,
 	Example: `  updo monitor https://example.com
   updo --url https://example.com
   updo monitor -r 10 -t 5 https://example.com
+  updo monitor -r 500ms -t 2s https://example.com
   updo monitor --simple -c 10 https://example.com
   updo monitor --simple https://example.com
   updo monitor -a "Welcome" https://example.com
@@ -72,9 +113,9 @@ func HideMonitoringFlags(cmd *cobra.Command) {
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&AppConfig.URL, "url", "u", "", "URL or IP address to monitor")
-	RootCmd.PersistentFlags().StringVarP(&AppConfig.ConfigFile, "config", "C", "", "Config file (TOML format)")
-	RootCmd.PersistentFlags().IntP("refresh", "r", _defaultRefresh, "Refresh interval in seconds")
-	RootCmd.PersistentFlags().IntP("timeout", "t", _defaultTimeout, "HTTP request timeout in seconds")
+	RootCmd.PersistentFlags().StringVarP(&AppConfig.ConfigFile, "config", "C", "", "Config file (TOML, YAML or JSON)")
+	RootCmd.PersistentFlags().VarP(newDurationValue(_defaultRefresh, &AppConfig.RefreshInterval, config.MinRefreshInterval), "refresh", "r", "Refresh interval, in seconds or as a duration (e.g., 500ms, 1m30s)")
+	RootCmd.PersistentFlags().VarP(newDurationValue(_defaultTimeout, &AppConfig.Timeout, 0), "timeout", "t", "HTTP request timeout, in seconds or as a duration (e.g., 500ms, 1m30s)")
 	RootCmd.PersistentFlags().BoolVarP(&AppConfig.ShouldFail, "should-fail", "f", false, "Invert success code range")
 	RootCmd.PersistentFlags().BoolVarP(&AppConfig.FollowRedirects, "follow-redirects", "l", true, "Follow redirects")
 	RootCmd.PersistentFlags().BoolVar(&AppConfig.AcceptRedirects, "accept-redirects", false, "Accept redirects (3xx) as successful responses")
@@ -91,16 +132,17 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&AppConfig.WebhookURL, "webhook-url", "", "Webhook URL for notifications")
 	RootCmd.PersistentFlags().StringArrayVar(&AppConfig.WebhookHeaders, "webhook-header", nil, "Webhook headers (can be used multiple times, format: 'Header-Name: value')")
 	RootCmd.PersistentFlags().StringVar(&AppConfig.PrometheusURL, "prometheus-url", "", "Prometheus remote write endpoint URL (e.g., http://localhost:9090/api/v1/write)")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.MetricsListen, "metrics-listen", "", "Serve Prometheus metrics for scraping at this address (e.g., :9102)")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.OTLPEndpoint, "otlp-endpoint", "", "OpenTelemetry collector endpoint for metrics and traces (e.g., http://localhost:4318)")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.OTLPProtocol, "otlp-protocol", "", "OTLP transport: http/protobuf (default) or grpc")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.StateFile, "state-file", "", "Restore monitor state from this file on start and save it on exit (.gob for binary, JSON otherwise)")
 
 	RootCmd.PersistentFlags().Bool("log", false, "Output structured logs in JSON format (includes requests, responses, and metrics)")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.LogFormat, "log-format", "", "Structured log format: json (default) or logfmt")
+	RootCmd.PersistentFlags().StringSliceVar(&AppConfig.LogFields, "log-fields", nil, "Structured log fields to include, or to exclude when prefixed with - (e.g., -request_body,-response_body)")
+	RootCmd.PersistentFlags().StringVar(&AppConfig.LogOutput, "log-output", "", "Where simple mode output goes: stdout (default), syslog, journald or a syslog address (e.g., udp://localhost:514)")
 
 	RootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
-		refresh, _ := cmd.Flags().GetInt("refresh")
-		timeout, _ := cmd.Flags().GetInt("timeout")
-
-		AppConfig.RefreshInterval = time.Duration(refresh) * time.Second
-		AppConfig.Timeout = time.Duration(timeout) * time.Second
-
 		logEnabled, _ := cmd.Flags().GetBool("log")
 		if logEnabled {
 			AppConfig.Log = "all"
@@ -1,36 +1,56 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/Owloops/updo/net"
 	"github.com/spf13/viper"
 )
 
+var _labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 const (
-	_defaultRefreshInterval = 5
-	_defaultTimeout         = 10
+	_defaultRefreshInterval = 5 * time.Second
+	_defaultTimeout         = 10 * time.Second
 	_defaultMethod          = "GET"
 )
 
 type Target struct {
-	URL             string   `mapstructure:"url"`
-	Name            string   `mapstructure:"name"`
-	RefreshInterval int      `mapstructure:"refresh_interval"`
-	Timeout         int      `mapstructure:"timeout"`
-	ShouldFail      bool     `mapstructure:"should_fail"`
-	FollowRedirects *bool    `mapstructure:"follow_redirects"`
-	AcceptRedirects *bool    `mapstructure:"accept_redirects"`
-	SkipSSL         *bool    `mapstructure:"skip_ssl"`
-	AssertText      string   `mapstructure:"assert_text"`
-	ReceiveAlert    *bool    `mapstructure:"receive_alert"`
-	Headers         []string `mapstructure:"headers"`
-	Method          string   `mapstructure:"method"`
-	Body            string   `mapstructure:"body"`
-	WebhookURL      string   `mapstructure:"webhook_url"`
-	WebhookHeaders  []string `mapstructure:"webhook_headers"`
-	Regions         []string `mapstructure:"regions"`
-	BodySizeLimit   *int64   `mapstructure:"body_size_limit"`
+	URL             string        `mapstructure:"url"`
+	Name            string        `mapstructure:"name"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	ShouldFail      bool          `mapstructure:"should_fail"`
+	FollowRedirects *bool         `mapstructure:"follow_redirects"`
+	AcceptRedirects *bool         `mapstructure:"accept_redirects"`
+	SkipSSL         *bool         `mapstructure:"skip_ssl"`
+	AssertText      string        `mapstructure:"assert_text"`
+	ReceiveAlert    *bool         `mapstructure:"receive_alert"`
+	Headers         []string      `mapstructure:"headers"`
+	Method          string        `mapstructure:"method"`
+	Body            string        `mapstructure:"body"`
+	WebhookURL      string        `mapstructure:"webhook_url"`
+	WebhookHeaders  []string      `mapstructure:"webhook_headers"`
+	Regions         []string      `mapstructure:"regions"`
+	BodySizeLimit   *int64        `mapstructure:"body_size_limit"`
+	ApdexThreshold  float64       `mapstructure:"apdex_threshold"`
+	AnomalyAlert    *bool         `mapstructure:"anomaly_alert"`
+	// Labels are attached to every exported series and JSON log record of
+	// the target, on top of those set in [global].
+	Labels map[string]string `mapstructure:"labels"`
+	// Extends names the [templates] the target is based on, in order of
+	// increasing precedence.
+	Extends []string `mapstructure:"extends"`
+	// Source is the config file the target was loaded from.
+	Source string `mapstructure:"-"`
 }
 
 // BoolVal returns the value of a *bool, or the fallback if nil.
@@ -50,32 +70,54 @@ func Int64Val(p *int64, fallback int64) int64 {
 }
 
 type Global struct {
-	RefreshInterval int      `mapstructure:"refresh_interval"`
-	Timeout         int      `mapstructure:"timeout"`
-	ShouldFail      bool     `mapstructure:"should_fail"`
-	FollowRedirects bool     `mapstructure:"follow_redirects"`
-	AcceptRedirects bool     `mapstructure:"accept_redirects"`
-	SkipSSL         bool     `mapstructure:"skip_ssl"`
-	ReceiveAlert    bool     `mapstructure:"receive_alert"`
-	Count           int      `mapstructure:"count"`
-	Simple          bool     `mapstructure:"simple"`
-	Log             bool     `mapstructure:"log"`
-	Only            []string `mapstructure:"only"`
-	Skip            []string `mapstructure:"skip"`
-	WebhookURL      string   `mapstructure:"webhook_url"`
-	WebhookHeaders  []string `mapstructure:"webhook_headers"`
-	Regions         []string `mapstructure:"regions"`
-	BodySizeLimit   int64    `mapstructure:"body_size_limit"`
+	RefreshInterval time.Duration     `mapstructure:"refresh_interval"`
+	Timeout         time.Duration     `mapstructure:"timeout"`
+	ShouldFail      bool              `mapstructure:"should_fail"`
+	FollowRedirects bool              `mapstructure:"follow_redirects"`
+	AcceptRedirects bool              `mapstructure:"accept_redirects"`
+	SkipSSL         bool              `mapstructure:"skip_ssl"`
+	ReceiveAlert    bool              `mapstructure:"receive_alert"`
+	Count           int               `mapstructure:"count"`
+	Simple          bool              `mapstructure:"simple"`
+	Log             bool              `mapstructure:"log"`
+	Only            []string          `mapstructure:"only"`
+	Skip            []string          `mapstructure:"skip"`
+	WebhookURL      string            `mapstructure:"webhook_url"`
+	WebhookHeaders  []string          `mapstructure:"webhook_headers"`
+	Regions         []string          `mapstructure:"regions"`
+	BodySizeLimit   int64             `mapstructure:"body_size_limit"`
+	ApdexThreshold  float64           `mapstructure:"apdex_threshold"`
+	AnomalyAlert    bool              `mapstructure:"anomaly_alert"`
+	Labels          map[string]string `mapstructure:"labels"`
 }
 
 type Config struct {
 	Global  Global   `mapstructure:"global"`
 	Targets []Target `mapstructure:"targets"`
+	// Templates hold settings targets pull in with extends.
+	Templates map[string]Target `mapstructure:"templates"`
+	// Include lists glob patterns of files holding more targets, relative
+	// to the config file.
+	Include []string `mapstructure:"include"`
+	// IncludePatterns are the Include patterns resolved against the
+	// config file, to watch the included files with.
+	IncludePatterns []string `mapstructure:"-"`
+	// FileSD discovers more targets from files written by other tools.
+	FileSD  []FileSD `mapstructure:"file_sd"`
+	Metrics Metrics  `mapstructure:"metrics"`
+	Logs    Logs     `mapstructure:"logs"`
+	Redact  Redact   `mapstructure:"redact"`
+	// Warnings lists problems that did not stop the config from loading,
+	// such as discovery files that were skipped.
+	Warnings ValidationErrors `mapstructure:"-"`
 }
 
+// LoadConfig reads a TOML, YAML or JSON config file, picked by its
+// extension, and validates it.
 func LoadConfig(configFile string) (*Config, error) {
+	format := DetectFormat(configFile)
 	viper.SetConfigFile(configFile)
-	viper.SetConfigType("toml")
+	viper.SetConfigType(format)
 
 	viper.SetDefault("global.refresh_interval", _defaultRefreshInterval)
 	viper.SetDefault("global.timeout", _defaultTimeout)
@@ -85,15 +127,79 @@ func LoadConfig(configFile string) (*Config, error) {
 	viper.SetDefault("global.method", _defaultMethod)
 	viper.SetDefault("global.body_size_limit", net.DefaultBodySizeLimit)
 
-	if err := viper.ReadInConfig(); err != nil {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+	var errs ValidationErrors
+	checkSchema(doc, reflect.TypeOf(Config{}), "", &errs)
+	if len(errs) > 0 {
+		return nil, errs.sorted()
+	}
+
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
 		return nil, err
 	}
 
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&config, _decodeHook); err != nil {
 		return nil, err
 	}
 
+	dir := filepath.Dir(configFile)
+	interpolateFields(reflect.ValueOf(&config.Global), dir, doc, &errs, "global")
+	for i := range config.Targets {
+		interpolateFields(reflect.ValueOf(&config.Targets[i]), dir, doc, &errs, "targets", i)
+	}
+	for i := range config.FileSD {
+		interpolateFields(reflect.ValueOf(&config.FileSD[i]), dir, doc, &errs, "file_sd", i)
+	}
+	for name, template := range config.Templates {
+		interpolateFields(reflect.ValueOf(&template), dir, doc, &errs, "templates", name)
+		config.Templates[name] = template
+	}
+	if len(errs) > 0 {
+		return nil, errs.sorted()
+	}
+
+	checkGlobal(config.Global, doc, &errs)
+	for _, name := range sortedKeys(config.Templates) {
+		checkTarget(config.Templates[name], false, doc, &errs, "templates", name)
+	}
+	templates := newTemplateResolver(config.Templates, doc, &errs)
+
+	names := make(map[string]targetRef, len(config.Targets))
+	for i := range config.Targets {
+		target := &config.Targets[i]
+		checkTarget(*target, true, doc, &errs, "targets", i)
+		*target, _ = templates.extend(*target, doc, &errs, "targets", i)
+		target.Source = configFile
+		checkName(target.Name, targetRef{file: configFile, index: i}, names, doc, &errs)
+	}
+
+	for _, pattern := range config.Include {
+		config.IncludePatterns = append(config.IncludePatterns, resolvePattern(pattern, configFile))
+	}
+	included, includeErrs := loadIncludes(config.Include, configFile, doc, templates, names)
+	errs = append(errs, includeErrs...)
+	config.Targets = append(config.Targets, included...)
+
+	discovered, discoveryErrs, discoveryWarnings := loadFileSD(config.FileSD, configFile, doc, templates, names)
+	errs = append(errs, discoveryErrs...)
+	config.Targets = append(config.Targets, discovered...)
+	config.Warnings = discoveryWarnings
+
+	if len(config.Targets) == 0 && len(config.FileSD) == 0 && len(errs) == 0 {
+		errs.add(doc.line, "", "no targets defined")
+	}
+	if len(errs) > 0 {
+		return nil, errs.sorted()
+	}
+
 	for i := range config.Targets {
 		target := &config.Targets[i]
 		if target.RefreshInterval == 0 {
@@ -105,6 +211,7 @@ func LoadConfig(configFile string) (*Config, error) {
 		if target.Method == "" {
 			target.Method = _defaultMethod
 		}
+		target.Method = strings.ToUpper(target.Method)
 		// *bool fields: if nil (not set in target), inherit from global
 		if target.FollowRedirects == nil {
 			v := config.Global.FollowRedirects
@@ -122,6 +229,10 @@ func LoadConfig(configFile string) (*Config, error) {
 			v := config.Global.SkipSSL
 			target.SkipSSL = &v
 		}
+		if target.AnomalyAlert == nil {
+			v := config.Global.AnomalyAlert
+			target.AnomalyAlert = &v
+		}
 		if target.BodySizeLimit == nil {
 			v := config.Global.BodySizeLimit
 			target.BodySizeLimit = &v
@@ -135,25 +246,53 @@ func LoadConfig(configFile string) (*Config, error) {
 		if len(target.Regions) == 0 && len(config.Global.Regions) > 0 {
 			target.Regions = config.Global.Regions
 		}
+		if target.ApdexThreshold == 0 {
+			target.ApdexThreshold = config.Global.ApdexThreshold
+		}
+		if len(config.Global.Labels) > 0 {
+			labels := maps.Clone(config.Global.Labels)
+			maps.Copy(labels, target.Labels)
+			target.Labels = labels
+		}
 	}
 
 	return &config, nil
 }
 
+// ValidateLabels checks that every label name is a valid Prometheus label
+// name and not one reserved for internal use.
+func ValidateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !_labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
 func (t *Target) GetRefreshInterval() time.Duration {
-	return time.Duration(t.RefreshInterval) * time.Second
+	return t.RefreshInterval
 }
 
 func (t *Target) GetTimeout() time.Duration {
-	return time.Duration(t.Timeout) * time.Second
+	return t.Timeout
+}
+
+// GetApdexThreshold returns the Apdex target time T, or zero when Apdex
+// scoring is disabled for the target.
+func (t *Target) GetApdexThreshold() time.Duration {
+	if t.ApdexThreshold <= 0 {
+		return 0
+	}
+	return time.Duration(t.ApdexThreshold * float64(time.Second))
 }
 
 func (g *Global) GetRefreshInterval() time.Duration {
-	return time.Duration(g.RefreshInterval) * time.Second
+	return g.RefreshInterval
 }
 
 func (g *Global) GetTimeout() time.Duration {
-	return time.Duration(g.Timeout) * time.Second
+	return g.Timeout
 }
 
 func (c *Config) FilterTargets(onlyFlags, skipFlags []string) []Target {
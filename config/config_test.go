@@ -1,7 +1,9 @@
 package config
 
 import (
+	"maps"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -46,11 +48,11 @@ method = "POST"
 		t.Fatalf("LoadConfig failed: %v", err)
 	}
 
-	if config.Global.RefreshInterval != 30 {
-		t.Errorf("Expected RefreshInterval=30, got %d", config.Global.RefreshInterval)
+	if config.Global.RefreshInterval != 30*time.Second {
+		t.Errorf("Expected RefreshInterval=30s, got %v", config.Global.RefreshInterval)
 	}
-	if config.Global.Timeout != 15 {
-		t.Errorf("Expected Timeout=15, got %d", config.Global.Timeout)
+	if config.Global.Timeout != 15*time.Second {
+		t.Errorf("Expected Timeout=15s, got %v", config.Global.Timeout)
 	}
 
 	if len(config.Targets) != 1 {
@@ -64,8 +66,8 @@ method = "POST"
 	if target.Name != "Example" {
 		t.Errorf("Expected Name=Example, got %s", target.Name)
 	}
-	if target.RefreshInterval != 60 {
-		t.Errorf("Expected RefreshInterval=60, got %d", target.RefreshInterval)
+	if target.RefreshInterval != 60*time.Second {
+		t.Errorf("Expected RefreshInterval=60s, got %v", target.RefreshInterval)
 	}
 	if target.Method != "POST" {
 		t.Errorf("Expected Method=POST, got %s", target.Method)
@@ -281,20 +283,36 @@ url = "https://example.com"
 
 	target := config.Targets[0]
 	if target.RefreshInterval != _defaultRefreshInterval {
-		t.Errorf("Expected default RefreshInterval=%d, got %d", _defaultRefreshInterval, target.RefreshInterval)
+		t.Errorf("Expected default RefreshInterval=%v, got %v", _defaultRefreshInterval, target.RefreshInterval)
 	}
 	if target.Timeout != _defaultTimeout {
-		t.Errorf("Expected default Timeout=%d, got %d", _defaultTimeout, target.Timeout)
+		t.Errorf("Expected default Timeout=%v, got %v", _defaultTimeout, target.Timeout)
 	}
 	if target.Method != _defaultMethod {
 		t.Errorf("Expected default Method=%s, got %s", _defaultMethod, target.Method)
 	}
 }
 
+func TestLoadConfigLowercaseMethod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "[[targets]]\nurl = \"https://example.com\"\nmethod = \"post\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := config.Targets[0].Method; got != "POST" {
+		t.Errorf("Method = %q, want %q", got, "POST")
+	}
+}
+
 func TestTargetGetMethods(t *testing.T) {
 	target := Target{
-		RefreshInterval: 30,
-		Timeout:         15,
+		RefreshInterval: 30 * time.Second,
+		Timeout:         15 * time.Second,
 	}
 
 	expectedRefresh := 30 * time.Second
@@ -310,8 +328,8 @@ func TestTargetGetMethods(t *testing.T) {
 
 func TestGlobalGetMethods(t *testing.T) {
 	global := Global{
-		RefreshInterval: 45,
-		Timeout:         25,
+		RefreshInterval: 45 * time.Second,
+		Timeout:         25 * time.Second,
 	}
 
 	expectedRefresh := 45 * time.Second
@@ -458,3 +476,187 @@ func TestContainsTarget(t *testing.T) {
 		})
 	}
 }
+
+func TestApdexThresholdInheritance(t *testing.T) {
+	configContent := `
+[global]
+apdex_threshold = 0.5
+
+[[targets]]
+url = "https://inherit.example.com"
+name = "Inherit"
+
+[[targets]]
+url = "https://override.example.com"
+name = "Override"
+apdex_threshold = 1.2
+`
+
+	tmpFile, err := os.CreateTemp("", "test-config-apdex-*.toml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer func() {
+		if err := os.Remove(tmpFile.Name()); err != nil {
+			t.Logf("Failed to remove temp file: %v", err)
+		}
+	}()
+
+	if _, err := tmpFile.WriteString(configContent); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if got := cfg.Targets[0].GetApdexThreshold(); got != 500*time.Millisecond {
+		t.Errorf("Inherit target: GetApdexThreshold() = %v, want 500ms", got)
+	}
+	if got := cfg.Targets[1].GetApdexThreshold(); got != 1200*time.Millisecond {
+		t.Errorf("Override target: GetApdexThreshold() = %v, want 1.2s", got)
+	}
+
+	var unset Target
+	if got := unset.GetApdexThreshold(); got != 0 {
+		t.Errorf("Unset target: GetApdexThreshold() = %v, want 0", got)
+	}
+}
+
+func TestLoadConfigMetrics(t *testing.T) {
+	configContent := `
+[metrics]
+sinks = ["influxdb", "graphite"]
+
+[metrics.influxdb]
+url = "http://localhost:8086"
+org = "ops"
+bucket = "updo"
+flush_interval = "15s"
+
+[metrics.statsd]
+address = "localhost:8125"
+
+[metrics.graphite]
+address = "localhost:2003"
+prefix = "monitoring.updo"
+
+[[targets]]
+url = "https://example.com"
+name = "Example"
+`
+
+	tmpFile, err := os.CreateTemp("", "test-config-metrics-*.toml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer func() {
+		if err := os.Remove(tmpFile.Name()); err != nil {
+			t.Logf("Failed to remove temp file: %v", err)
+		}
+	}()
+
+	if _, err := tmpFile.WriteString(configContent); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		t.Fatalf("Failed to close temp file: %v", err)
+	}
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.Metrics.InfluxDB.Bucket != "updo" || cfg.Metrics.InfluxDB.FlushInterval != 15*time.Second {
+		t.Errorf("Unexpected InfluxDB config %+v", cfg.Metrics.InfluxDB)
+	}
+	if cfg.Metrics.Graphite.Prefix != "monitoring.updo" {
+		t.Errorf("Graphite prefix = %q, want monitoring.updo", cfg.Metrics.Graphite.Prefix)
+	}
+
+	tests := []struct {
+		sink       string
+		configured bool
+		want       bool
+	}{
+		{SinkInfluxDB, true, true},
+		{SinkGraphite, true, true},
+		{SinkStatsD, true, false},
+		{SinkPrometheus, false, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.Metrics.SinkEnabled(tt.sink, tt.configured); got != tt.want {
+			t.Errorf("SinkEnabled(%q) = %v, want %v", tt.sink, got, tt.want)
+		}
+	}
+
+	var unselected Metrics
+	if !unselected.SinkEnabled(SinkStatsD, true) || unselected.SinkEnabled(SinkStatsD, false) {
+		t.Error("Without sinks, every configured output should be enabled")
+	}
+}
+
+func TestLoadConfigLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []map[string]string
+		wantErr bool
+	}{
+		{
+			name: "global and target labels are merged",
+			content: `
+[global]
+labels = { team = "payments", env = "prod" }
+
+[[targets]]
+url = "https://example.com"
+labels = { env = "staging", service = "checkout" }
+
+[[targets]]
+url = "https://example.org"
+`,
+			want: []map[string]string{
+				{"team": "payments", "env": "staging", "service": "checkout"},
+				{"team": "payments", "env": "prod"},
+			},
+		},
+		{
+			name: "invalid label name",
+			content: `
+[[targets]]
+url = "https://example.com"
+labels = { "cost-center" = "42" }
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, want := range tt.want {
+				if !maps.Equal(cfg.Targets[i].Labels, want) {
+					t.Errorf("Target %d labels = %v, want %v", i, cfg.Targets[i].Labels, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var _bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CheckSchema parses data as a config file in format and checks its keys
+// and value types. Unlike LoadConfig it leaves ${...} references, includes
+// and discovery files unresolved, so a file can be checked without the
+// environment it runs in.
+func CheckSchema(data []byte, format string) error {
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		return err
+	}
+	var errs ValidationErrors
+	checkSchema(doc, reflect.TypeOf(Config{}), "", &errs)
+	if len(errs) > 0 {
+		return errs.sorted()
+	}
+	return nil
+}
+
+// Convert rewrites a config file from one format to another, keeping the
+// order of its keys. Comments are not carried over.
+func Convert(data []byte, from, to string) ([]byte, error) {
+	doc, err := parseDocument(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return encodeDocument(doc, to)
+}
+
+// Marshal writes v, a config struct such as Config, in format. Fields are
+// written in declaration order and those left at their zero value are
+// omitted.
+func Marshal(v any, format string) ([]byte, error) {
+	doc := structDocument(reflect.ValueOf(v))
+	if doc == nil {
+		doc = newTable(0)
+	}
+	return encodeDocument(doc, format)
+}
+
+func encodeDocument(doc *docNode, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatYAML:
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(yamlNode(doc)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if err := writeJSON(&buf, doc, ""); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	default:
+		if err := writeTOMLTable(&buf, doc, nil); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// structDocument builds the docNode tree of v, or nil when v is a zero
+// value.
+func structDocument(v reflect.Value) *docNode {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		// A set pointer is written even when it points to a zero value,
+		// such as skip_ssl = false.
+		if node := structDocument(v.Elem()); node != nil {
+			return node
+		}
+		switch v.Elem().Kind() {
+		case reflect.Bool:
+			return &docNode{kind: docBool, text: "false"}
+		case reflect.Int, reflect.Int64:
+			return &docNode{kind: docInteger, text: "0"}
+		case reflect.Float64:
+			return &docNode{kind: docFloat, text: "0"}
+		}
+		return nil
+	}
+	if v.Type() == _durationType {
+		if v.Int() == 0 {
+			return nil
+		}
+		return &docNode{kind: docString, text: FormatDuration(time.Duration(v.Int()))}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		table := newTable(0)
+		for i := range v.NumField() {
+			key, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("mapstructure"), ",")
+			if key == "" || key == "-" {
+				continue
+			}
+			if child := structDocument(v.Field(i)); child != nil {
+				table.set(key, child)
+			}
+		}
+		if len(table.keys) == 0 {
+			return nil
+		}
+		return table
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		table := newTable(0)
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if child := structDocument(v.MapIndex(reflect.ValueOf(key))); child != nil {
+				table.set(key, child)
+			}
+		}
+		return table
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+		array := &docNode{kind: docArray}
+		for i := range v.Len() {
+			item := structDocument(v.Index(i))
+			if item == nil {
+				item = newTable(0)
+			}
+			array.items = append(array.items, item)
+		}
+		return array
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		return &docNode{kind: docString, text: v.String()}
+	case reflect.Bool:
+		if !v.Bool() {
+			return nil
+		}
+		return &docNode{kind: docBool, text: "true"}
+	case reflect.Int, reflect.Int64:
+		if v.Int() == 0 {
+			return nil
+		}
+		return &docNode{kind: docInteger, text: strconv.FormatInt(v.Int(), 10)}
+	case reflect.Float64:
+		if v.Float() == 0 {
+			return nil
+		}
+		return &docNode{kind: docFloat, text: strconv.FormatFloat(v.Float(), 'g', -1, 64)}
+	}
+	return nil
+}
+
+// scalar returns the value of a scalar node as a string, int64, float64,
+// bool or nil. Dates are returned as strings.
+func (n *docNode) scalar() (any, error) {
+	switch n.kind {
+	case docInteger:
+		value, err := strconv.ParseInt(strings.ReplaceAll(n.text, "_", ""), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid integer %q", n.line, n.text)
+		}
+		return value, nil
+	case docFloat:
+		text := strings.ToLower(strings.ReplaceAll(n.text, "_", ""))
+		text = strings.TrimPrefix(text, ".") // YAML spells infinity .inf
+		text = strings.Replace(text, "-.", "-", 1)
+		text = strings.Replace(text, "+.", "+", 1)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %q", n.line, n.text)
+		}
+		return value, nil
+	case docBool:
+		return strings.EqualFold(n.text, "true"), nil
+	case docNull:
+		return nil, nil
+	default:
+		return n.text, nil
+	}
+}
+
+func yamlNode(n *docNode) *yaml.Node {
+	switch n.kind {
+	case docTable:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range n.keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				yamlNode(n.fields[key]))
+		}
+		return node
+	case docArray:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range n.items {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	}
+
+	value, err := n.scalar()
+	if err != nil {
+		value = n.text
+	}
+	switch v := value.(type) {
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: formatYAMLFloat(v)}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.text}
+	}
+}
+
+func formatYAMLFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return ".inf"
+	case math.IsInf(v, -1):
+		return "-.inf"
+	case math.IsNaN(v):
+		return ".nan"
+	default:
+		return formatFloat(v)
+	}
+}
+
+// formatFloat formats v so that it still reads as a float rather than an
+// integer.
+func formatFloat(v float64) string {
+	text := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".eE") {
+		text += ".0"
+	}
+	return text
+}
+
+func writeJSON(buf *bytes.Buffer, n *docNode, indent string) error {
+	switch n.kind {
+	case docTable, docArray:
+		open, closing, count := byte('{'), byte('}'), len(n.keys)
+		if n.kind == docArray {
+			open, closing, count = '[', ']', len(n.items)
+		}
+		buf.WriteByte(open)
+		if count == 0 {
+			buf.WriteByte(closing)
+			return nil
+		}
+		inner := indent + "  "
+		for i := range count {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString("\n" + inner)
+			var child *docNode
+			if n.kind == docTable {
+				writeJSONString(buf, n.keys[i])
+				buf.WriteString(": ")
+				child = n.fields[n.keys[i]]
+			} else {
+				child = n.items[i]
+			}
+			if err := writeJSON(buf, child, inner); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + indent)
+		buf.WriteByte(closing)
+		return nil
+	}
+
+	value, err := n.scalar()
+	if err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Errorf("line %d: %s cannot be written as JSON", n.line, n.text)
+		}
+		buf.WriteString(formatFloat(v))
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		writeJSONString(buf, n.text)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	buf.Truncate(buf.Len() - 1) // Encode ends every value with a newline
+}
+
+// isTableArray reports whether n is written as [[array]] tables rather
+// than an inline array.
+func isTableArray(n *docNode) bool {
+	if n.kind != docArray || len(n.items) == 0 {
+		return false
+	}
+	for _, item := range n.items {
+		if item.kind != docTable {
+			return false
+		}
+	}
+	return true
+}
+
+// writeTOMLTable writes the values of table n, then its tables and arrays
+// of tables under headers, as TOML requires values to come first.
+func writeTOMLTable(buf *bytes.Buffer, n *docNode, path []string) error {
+	for _, key := range n.keys {
+		child := n.fields[key]
+		if child.kind == docNull || child.kind == docTable || isTableArray(child) {
+			continue
+		}
+		buf.WriteString(tomlKeyString(key) + " = ")
+		if err := writeTOMLValue(buf, child); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+
+	for _, key := range n.keys {
+		child := n.fields[key]
+		childPath := append(append([]string(nil), path...), key)
+		switch {
+		case child.kind == docTable:
+			// Tables holding only tables need no header of their own.
+			if len(child.keys) == 0 || slices.ContainsFunc(child.keys, func(key string) bool {
+				value := child.fields[key]
+				return value.kind != docTable && !isTableArray(value)
+			}) {
+				writeTOMLHeader(buf, "[", childPath, "]")
+			}
+			if err := writeTOMLTable(buf, child, childPath); err != nil {
+				return err
+			}
+		case isTableArray(child):
+			for _, item := range child.items {
+				writeTOMLHeader(buf, "[[", childPath, "]]")
+				if err := writeTOMLTable(buf, item, childPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func writeTOMLHeader(buf *bytes.Buffer, open string, path []string, closing string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKeyString(key)
+	}
+	buf.WriteString(open + strings.Join(keys, ".") + closing + "\n")
+}
+
+func writeTOMLValue(buf *bytes.Buffer, n *docNode) error {
+	switch n.kind {
+	case docTable:
+		buf.WriteString("{")
+		first := true
+		for _, key := range n.keys {
+			child := n.fields[key]
+			if child.kind == docNull {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.WriteString(" " + tomlKeyString(key) + " = ")
+			if err := writeTOMLValue(buf, child); err != nil {
+				return err
+			}
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString("}")
+		return nil
+	case docArray:
+		buf.WriteString("[")
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeTOMLValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+		return nil
+	}
+
+	value, err := n.scalar()
+	if err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			buf.WriteString("inf")
+		case math.IsInf(v, -1):
+			buf.WriteString("-inf")
+		case math.IsNaN(v):
+			buf.WriteString("nan")
+		default:
+			buf.WriteString(formatFloat(v))
+		}
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		return fmt.Errorf("line %d: null cannot be written as TOML", n.line)
+	default:
+		buf.WriteString(tomlString(n.text))
+	}
+	return nil
+}
+
+func tomlKeyString(key string) string {
+	if _bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const _defaultFileSDScheme = "http"
+
+// _discoveredKeys are the settings a file_sd entry sets on its target.
+var _discoveredKeys = map[string]bool{"url": true, "name": true, "labels": true}
+
+// Labels of file_sd target groups that set the URL of their targets, as
+// Prometheus does, rather than being attached to them. Other labels
+// starting with __ are dropped.
+const (
+	_schemeLabel      = "__scheme__"
+	_metricsPathLabel = "__metrics_path__"
+	_paramLabelPrefix = "__param_"
+)
+
+// _lastValidFileSD holds the contents of every discovery file as of its
+// last successful load, keyed by absolute path, so that a file caught
+// half written keeps the targets it had.
+var _lastValidFileSD = struct {
+	sync.Mutex
+	data map[string][]byte
+}{data: make(map[string][]byte)}
+
+// FileSD discovers targets from JSON or YAML files in the Prometheus
+// file_sd format, lists of target groups such as
+//
+//	[{"targets": ["api-1:8080", "api-2:8080"], "labels": {"env": "prod"}}]
+//
+// Each target of a group becomes a target named after it, built from the
+// templates the block extends and carrying the labels of the group.
+// Addresses are checked at Scheme://address/Path, URLs as they are. As in
+// Prometheus, a file that cannot be read or has errors is skipped with a
+// warning, keeping the targets of its last valid contents.
+type FileSD struct {
+	// Files lists glob patterns of the files, relative to the config
+	// file. Files that do not exist yet are picked up once written.
+	Files   []string `mapstructure:"files"`
+	Extends []string `mapstructure:"extends"`
+	// Scheme defaults to http, as in Prometheus.
+	Scheme string `mapstructure:"scheme"`
+	Path   string `mapstructure:"path"`
+}
+
+// fileSDGroup is the layout of the target groups of a file_sd file.
+type fileSDGroup struct {
+	Targets []string          `mapstructure:"targets"`
+	Labels  map[string]string `mapstructure:"labels"`
+}
+
+// FileSDPatterns returns the glob patterns of the files the config file at
+// configFile discovers targets from.
+func (c *Config) FileSDPatterns(configFile string) []string {
+	var patterns []string
+	for _, sd := range c.FileSD {
+		for _, pattern := range sd.Files {
+			patterns = append(patterns, resolvePattern(pattern, configFile))
+		}
+	}
+	return patterns
+}
+
+// loadFileSD reads the targets of every file matched by the file_sd blocks
+// of configFile, in block, pattern then file name order. Errors in the
+// blocks themselves are returned as errors, those in the files they match
+// as warnings.
+func loadFileSD(blocks []FileSD, configFile string, doc *docNode, templates *templateResolver, names map[string]targetRef) (targets []Target, errs, warnings ValidationErrors) {
+	for i, sd := range blocks {
+		checkFileSD(sd, doc, &errs, "file_sd", i)
+		base, _ := templates.extend(Target{Extends: sd.Extends}, doc, &errs, "file_sd", i)
+
+		seen := make(map[string]bool)
+		for _, pattern := range sd.Files {
+			matches, err := filepath.Glob(resolvePattern(pattern, configFile))
+			if err != nil {
+				continue
+			}
+			for _, file := range matches {
+				absPath, err := filepath.Abs(file)
+				if err != nil || seen[absPath] {
+					continue
+				}
+				seen[absPath] = true
+
+				fileTargets, fileWarnings := loadFileSDFile(file, absPath, sd, base, names)
+				targets = append(targets, fileTargets...)
+				warnings = append(warnings, fileWarnings...)
+			}
+		}
+	}
+
+	return targets, errs, warnings
+}
+
+// loadFileSDFile reads the targets of a discovery file. If the file cannot
+// be read or has errors, they are returned as warnings along with the
+// targets of the file's last valid contents, if any.
+func loadFileSDFile(file, absPath string, sd FileSD, base Target, names map[string]targetRef) ([]Target, ValidationErrors) {
+	_lastValidFileSD.Lock()
+	defer _lastValidFileSD.Unlock()
+
+	data, err := os.ReadFile(file) // #nosec G304 -- path comes from the user's own config file
+	last, hasLast := _lastValidFileSD.data[absPath]
+	var errs ValidationErrors
+	switch {
+	case err != nil:
+		errs = ValidationErrors{{Message: err.Error()}}
+	case len(bytes.TrimSpace(data)) == 0 && len(bytes.TrimSpace(last)) > 0:
+		// Most likely truncated by a writer that has yet to fill it in.
+		errs = ValidationErrors{{Message: "file is empty"}}
+	default:
+		fileNames := maps.Clone(names)
+		var targets []Target
+		targets, errs = parseFileSD(file, data, sd, base, fileNames)
+		if len(errs) == 0 {
+			_lastValidFileSD.data[absPath] = data
+			maps.Copy(names, fileNames)
+			return targets, nil
+		}
+	}
+
+	warnings := errs.sorted().inFile(file)
+	if hasLast && !bytes.Equal(last, data) {
+		fileNames := maps.Clone(names)
+		if targets, lastErrs := parseFileSD(file, last, sd, base, fileNames); len(lastErrs) == 0 {
+			maps.Copy(names, fileNames)
+			warnings = append(warnings, ValidationError{File: file, Message: "skipped, keeping the targets of its last valid contents"})
+			return targets, warnings
+		}
+	}
+	return nil, append(warnings, ValidationError{File: file, Message: "skipped"})
+}
+
+// parseFileSD builds the targets of the contents of a discovery file.
+func parseFileSD(file string, data []byte, sd FileSD, base Target, names map[string]targetRef) ([]Target, ValidationErrors) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var doc *docNode
+	var err error
+	switch DetectFormat(file) {
+	case FormatJSON:
+		doc, err = parseJSONValue(data)
+	case FormatYAML:
+		doc, err = parseYAMLValue(data)
+	default:
+		return nil, ValidationErrors{{Line: 1, Message: "file_sd files must be JSON or YAML"}}
+	}
+	if err != nil {
+		var errs ValidationErrors
+		if errors.As(err, &errs) {
+			return nil, errs
+		}
+		return nil, ValidationErrors{{Line: 1, Message: err.Error()}}
+	}
+	if doc == nil {
+		return nil, nil
+	}
+
+	var errs ValidationErrors
+	checkSchema(doc, reflect.TypeOf([]fileSDGroup{}), "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var targets []Target
+	for i, group := range doc.items {
+		scheme, path := sd.Scheme, sd.Path
+		if scheme == "" {
+			scheme = _defaultFileSDScheme
+		}
+		query := url.Values{}
+		labels := make(map[string]string)
+		if labelsNode := group.lookup("labels"); labelsNode != nil {
+			for _, name := range labelsNode.keys {
+				value := labelsNode.fields[name].text
+				switch {
+				case name == _schemeLabel:
+					scheme = value
+				case name == _metricsPathLabel:
+					path = value
+				case strings.HasPrefix(name, _paramLabelPrefix):
+					query.Set(strings.TrimPrefix(name, _paramLabelPrefix), value)
+				case !strings.HasPrefix(name, "__"):
+					labels[name] = value
+				}
+			}
+		}
+		checkLabels(labels, doc, &errs, i, "labels")
+		if path != "" && !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		targetsNode := group.lookup("targets")
+		if targetsNode == nil {
+			continue
+		}
+		for j, item := range targetsNode.items {
+			address := strings.TrimSpace(item.text)
+			rawURL := address
+			if !strings.Contains(address, "://") {
+				rawURL = scheme + "://" + address + path
+				if len(query) > 0 {
+					rawURL += "?" + query.Encode()
+				}
+			}
+
+			discovered := Target{URL: rawURL, Name: address}
+			if len(labels) > 0 {
+				discovered.Labels = maps.Clone(labels)
+			}
+			target := mergeTarget(base, discovered, _discoveredKeys)
+			target.Source = file
+			checkURL(target.URL, true, doc, &errs, i, "targets", j)
+			checkDiscoveredName(address, file, names, doc, &errs, i, "targets", j)
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, errs
+}
+
+func checkFileSD(sd FileSD, doc *docNode, errs *ValidationErrors, path ...any) {
+	sub := func(key string) []any { return append(path[:len(path):len(path)], key) }
+
+	if len(sd.Files) == 0 {
+		errs.add(doc.lineOf(sub("files")...), formatPath(sub("files")), "is required")
+	}
+	for j, pattern := range sd.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			filePath := append(sub("files"), j)
+			errs.add(doc.lineOf(filePath...), formatPath(filePath), "invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if sd.Scheme != "" && sd.Scheme != "http" && sd.Scheme != "https" {
+		errs.add(doc.lineOf(sub("scheme")...), formatPath(sub("scheme")), "unknown scheme %q, expected http or https", sd.Scheme)
+	}
+}
+
+// checkDiscoveredName reports a discovered target whose name is already
+// used by another target. Discovered targets are recorded in names with a
+// negative index.
+func checkDiscoveredName(name, file string, names map[string]targetRef, doc *docNode, errs *ValidationErrors, path ...any) {
+	first, exists := names[name]
+	if !exists {
+		names[name] = targetRef{file: file, index: -1}
+		return
+	}
+	switch {
+	case first.index >= 0:
+		errs.add(doc.lineOf(path...), formatPath(path), "duplicate name %q, also used by targets[%d] in %s", name, first.index, first.file)
+	case first.file == file:
+		errs.add(doc.lineOf(path...), formatPath(path), "duplicate target %q", name)
+	default:
+		errs.add(doc.lineOf(path...), formatPath(path), "duplicate target %q, also discovered in %s", name, first.file)
+	}
+}
+
+// resolvePattern makes a glob pattern of a config file relative to the
+// working directory rather than to the config file.
+func resolvePattern(pattern, configFile string) string {
+	if filepath.IsAbs(pattern) {
+		return pattern
+	}
+	return filepath.Join(filepath.Dir(configFile), pattern)
+}
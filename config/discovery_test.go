@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFileSD(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml": `[global]
+labels = { env = "prod" }
+
+[templates.service]
+refresh_interval = "30s"
+assert_text = "ok"
+labels = { team = "platform" }
+
+[[targets]]
+url = "https://example.com"
+name = "Main"
+
+[[file_sd]]
+files = ["sd/*.json", "sd/*.yaml", "sd/missing.json"]
+extends = ["service"]
+path = "/health"
+`,
+		"sd/api.json": `[
+  {"targets": ["api-1:8080", "api-2:8080"], "labels": {"tier": "1", "__meta_rack": "a"}},
+  {"targets": ["https://status.example.com/ping"]}
+]`,
+		"sd/db.yaml": `- targets: [db-1:9000]
+  labels:
+    __scheme__: https
+    __metrics_path__: ready
+    __param_verbose: "true"
+`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"Main", "https://example.com"},
+		{"api-1:8080", "http://api-1:8080/health"},
+		{"api-2:8080", "http://api-2:8080/health"},
+		{"https://status.example.com/ping", "https://status.example.com/ping"},
+		{"db-1:9000", "https://db-1:9000/ready?verbose=true"},
+	}
+	if len(cfg.Targets) != len(tests) {
+		t.Fatalf("Expected %d targets, got %d: %+v", len(tests), len(cfg.Targets), cfg.Targets)
+	}
+	for i, tt := range tests {
+		if target := cfg.Targets[i]; target.Name != tt.name || target.URL != tt.url {
+			t.Errorf("Target %d: expected %s at %s, got %s at %s", i, tt.name, tt.url, target.Name, target.URL)
+		}
+	}
+
+	api := cfg.Targets[1]
+	if api.RefreshInterval != 30*time.Second || api.AssertText != "ok" {
+		t.Errorf("Expected discovered targets to extend the template, got %+v", api)
+	}
+	if len(api.Labels) != 3 || api.Labels["tier"] != "1" || api.Labels["team"] != "platform" || api.Labels["env"] != "prod" {
+		t.Errorf("Expected group, template and global labels without meta labels, got %v", api.Labels)
+	}
+	if api.Source != filepath.Join(dir, "sd", "api.json") {
+		t.Errorf("Expected the discovery file as source, got %s", api.Source)
+	}
+
+	patterns := cfg.FileSDPatterns(filepath.Join(dir, "updo.toml"))
+	if len(patterns) != 3 || patterns[0] != filepath.Join(dir, "sd", "*.json") {
+		t.Errorf("Unexpected patterns %v", patterns)
+	}
+}
+
+func TestLoadConfigFileSDWithoutTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml":     "[[file_sd]]\nfiles = [\"sd/*.json\"]\n",
+		"sd/empty.json": "",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	if err != nil {
+		t.Fatalf("Expected discovery files to be allowed to be empty, got %v", err)
+	}
+	if len(cfg.Targets) != 0 {
+		t.Errorf("Expected no targets, got %+v", cfg.Targets)
+	}
+}
+
+func TestLoadConfigFileSDErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml": `[[file_sd]]
+files = ["sd/*"]
+extends = ["servce"]
+
+[[file_sd]]
+scheme = "ftp"
+`,
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	want := []string{
+		`line 3: file_sd[0].extends[0]: unknown template "servce"`,
+		"line 5: file_sd[1].files: is required",
+		`line 6: file_sd[1].scheme: unknown scheme "ftp"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d:\n%v", len(want), len(errs), errs)
+	}
+	for i := range want {
+		if got := errs[i].Error(); !strings.HasPrefix(got, want[i]) {
+			t.Errorf("Error %d: expected prefix %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestLoadConfigFileSDSkipsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml": `[[targets]]
+url = "https://example.com"
+name = "api:80"
+
+[[file_sd]]
+files = ["sd/*"]
+`,
+		"sd/a.json": `[
+  {"targets": ["api:80", "web:80", "web:80"]},
+  {"targets": ["bad host"], "labels": {"bad-label": "x"}}
+]`,
+		"sd/b.yaml": "targets: [x]\n",
+		"sd/c.toml": "x = 1\n",
+		"sd/d.json": `[{"targets": ["db:5432"]}]`,
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	if err != nil {
+		t.Fatalf("Expected invalid discovery files to be skipped, got %v", err)
+	}
+	if len(cfg.Targets) != 2 || cfg.Targets[1].Name != "db:5432" {
+		t.Errorf("Expected the static target and db:5432, got %+v", cfg.Targets)
+	}
+
+	configFile, sdA := filepath.Join(dir, "updo.toml"), filepath.Join(dir, "sd", "a.json")
+	want := []string{
+		sdA + `: line 2: [0].targets[0]: duplicate name "api:80", also used by targets[0] in ` + configFile,
+		sdA + `: line 2: [0].targets[2]: duplicate target "web:80"`,
+		sdA + `: line 3: [1].labels: invalid label name "bad-label"`,
+		sdA + `: line 3: [1].targets[0]: invalid URL "http://bad host"`,
+		sdA + ": skipped",
+		filepath.Join(dir, "sd", "b.yaml") + ": line 1: expected an array, got a table",
+		filepath.Join(dir, "sd", "b.yaml") + ": skipped",
+		filepath.Join(dir, "sd", "c.toml") + ": line 1: file_sd files must be JSON or YAML",
+		filepath.Join(dir, "sd", "c.toml") + ": skipped",
+	}
+	if len(cfg.Warnings) != len(want) {
+		t.Fatalf("Expected %d warnings, got %d:\n%v", len(want), len(cfg.Warnings), cfg.Warnings)
+	}
+	for i := range want {
+		if got := cfg.Warnings[i].Error(); !strings.HasPrefix(got, want[i]) {
+			t.Errorf("Warning %d: expected prefix %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestLoadConfigFileSDKeepsLastValidContents(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml":   "[[file_sd]]\nfiles = [\"sd/*.json\"]\n",
+		"sd/api.json": `[{"targets": ["api-1:8080", "api-2:8080"]}]`,
+	})
+	configFile := filepath.Join(dir, "updo.toml")
+
+	if _, err := LoadConfig(configFile); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	for _, partial := range []string{`[{"targets": ["api-1:8080", "api-`, ""} {
+		writeFiles(t, dir, map[string]string{"sd/api.json": partial})
+
+		cfg, err := LoadConfig(configFile)
+		if err != nil {
+			t.Fatalf("Expected a half written file to be skipped, got %v", err)
+		}
+		if len(cfg.Targets) != 2 || cfg.Targets[1].Name != "api-2:8080" {
+			t.Errorf("Expected the targets of the last valid contents, got %+v", cfg.Targets)
+		}
+		if len(cfg.Warnings) == 0 || !strings.HasSuffix(cfg.Warnings[len(cfg.Warnings)-1].Error(), "keeping the targets of its last valid contents") {
+			t.Errorf("Expected a warning about the skipped file, got %v", cfg.Warnings)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"sd/api.json": "[]"})
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(cfg.Targets) != 0 || len(cfg.Warnings) != 0 {
+		t.Errorf("Expected an emptied target list to apply, got %+v with warnings %v", cfg.Targets, cfg.Warnings)
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+type docKind int
+
+const (
+	docTable docKind = iota
+	docArray
+	docString
+	docInteger
+	docFloat
+	docBool
+	docDateTime
+	docNull
+)
+
+// docNode is a value of a parsed config file along with the line it starts
+// on, so that validation can point at the offending line.
+type docNode struct {
+	kind   docKind
+	line   int
+	fields map[string]*docNode
+	// keys holds the keys of fields in the order the file sets them.
+	keys  []string
+	items []*docNode
+	text  string
+}
+
+func newTable(line int) *docNode {
+	return &docNode{kind: docTable, line: line, fields: make(map[string]*docNode)}
+}
+
+// set adds or replaces the field key of a table.
+func (n *docNode) set(key string, value *docNode) {
+	if _, exists := n.fields[key]; !exists {
+		n.keys = append(n.keys, key)
+	}
+	n.fields[key] = value
+}
+
+// lookup returns the node at path, made of field names and array indexes,
+// or nil if the file does not set it.
+func (n *docNode) lookup(path ...any) *docNode {
+	for _, step := range path {
+		if n == nil {
+			return nil
+		}
+		switch s := step.(type) {
+		case string:
+			n = n.fields[s]
+		case int:
+			if n.kind != docArray || s >= len(n.items) {
+				return nil
+			}
+			n = n.items[s]
+		}
+	}
+	return n
+}
+
+// lineOf returns the line of the node at path, or of its closest parent
+// the file sets.
+func (n *docNode) lineOf(path ...any) int {
+	line := n.line
+	for i := range path {
+		node := n.lookup(path[:i+1]...)
+		if node == nil {
+			break
+		}
+		line = node.line
+	}
+	return line
+}
+
+// parseTOMLDocument parses data into a docNode tree. Syntax errors are
+// returned as ValidationErrors.
+func parseTOMLDocument(data []byte) (*docNode, error) {
+	parser := unstable.Parser{}
+	parser.Reset(data)
+
+	root := newTable(1)
+	current := root
+
+	for parser.NextExpression() {
+		expr := parser.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			keys, line := tomlKey(&parser, expr)
+			parent, err := tomlTable(root, keys[:len(keys)-1], line)
+			if err != nil {
+				return nil, err
+			}
+			last := keys[len(keys)-1]
+
+			if expr.Kind == unstable.Table {
+				table, exists := parent.fields[last]
+				if !exists {
+					table = newTable(line)
+					parent.set(last, table)
+				} else if table.kind != docTable {
+					return nil, ValidationErrors{{Line: line, Message: fmt.Sprintf("%q is already defined as a value", last)}}
+				}
+				table.line = line
+				current = table
+				continue
+			}
+
+			array, exists := parent.fields[last]
+			if !exists {
+				array = &docNode{kind: docArray, line: line}
+				parent.set(last, array)
+			} else if array.kind != docArray {
+				return nil, ValidationErrors{{Line: line, Message: fmt.Sprintf("%q is already defined as a table", last)}}
+			}
+			current = newTable(line)
+			array.items = append(array.items, current)
+
+		case unstable.KeyValue:
+			keys, line := tomlKey(&parser, expr)
+			table, err := tomlTable(current, keys[:len(keys)-1], line)
+			if err != nil {
+				return nil, err
+			}
+			table.set(keys[len(keys)-1], tomlValue(&parser, expr.Value(), line))
+		}
+	}
+
+	if err := parser.Error(); err != nil {
+		line := bytes.Count(data, []byte{'\n'}) + 1
+		var parserErr *unstable.ParserError
+		if errors.As(err, &parserErr) {
+			if len(parserErr.Highlight) > 0 {
+				line = parser.Shape(parser.Range(parserErr.Highlight)).Start.Line
+			}
+			return nil, ValidationErrors{{Line: line, Message: parserErr.Message}}
+		}
+		return nil, ValidationErrors{{Line: line, Message: err.Error()}}
+	}
+
+	return root, nil
+}
+
+func tomlKey(parser *unstable.Parser, expr *unstable.Node) ([]string, int) {
+	var keys []string
+	line := 0
+	it := expr.Key()
+	for it.Next() {
+		key := it.Node()
+		if line == 0 {
+			line = nodeLine(parser, key, 1)
+		}
+		keys = append(keys, string(key.Data))
+	}
+	return keys, line
+}
+
+// tomlTable walks keys down from table, creating the tables that do not
+// exist yet. A key naming an array of tables refers to its last table.
+func tomlTable(table *docNode, keys []string, line int) (*docNode, error) {
+	for _, key := range keys {
+		next, exists := table.fields[key]
+		switch {
+		case !exists:
+			next = newTable(line)
+			table.set(key, next)
+		case next.kind == docArray && len(next.items) > 0 && next.items[len(next.items)-1].kind == docTable:
+			next = next.items[len(next.items)-1]
+		case next.kind != docTable:
+			return nil, ValidationErrors{{Line: line, Message: fmt.Sprintf("%q is already defined as a value", key)}}
+		}
+		table = next
+	}
+	return table, nil
+}
+
+func tomlValue(parser *unstable.Parser, value *unstable.Node, line int) *docNode {
+	line = nodeLine(parser, value, line)
+	switch value.Kind {
+	case unstable.Array:
+		node := &docNode{kind: docArray, line: line}
+		it := value.Children()
+		for it.Next() {
+			node.items = append(node.items, tomlValue(parser, it.Node(), line))
+		}
+		return node
+	case unstable.InlineTable:
+		node := newTable(line)
+		it := value.Children()
+		for it.Next() {
+			child := it.Node()
+			keys, keyLine := tomlKey(parser, child)
+			table, err := tomlTable(node, keys[:len(keys)-1], keyLine)
+			if err != nil {
+				continue
+			}
+			table.set(keys[len(keys)-1], tomlValue(parser, child.Value(), keyLine))
+		}
+		return node
+	case unstable.String:
+		return &docNode{kind: docString, line: line, text: string(value.Data)}
+	case unstable.Integer:
+		return &docNode{kind: docInteger, line: line, text: string(value.Data)}
+	case unstable.Float:
+		return &docNode{kind: docFloat, line: line, text: string(value.Data)}
+	case unstable.Bool:
+		return &docNode{kind: docBool, line: line, text: string(value.Data)}
+	default:
+		return &docNode{kind: docDateTime, line: line, text: string(value.Data)}
+	}
+}
+
+func nodeLine(parser *unstable.Parser, node *unstable.Node, fallback int) int {
+	if node.Raw.Length == 0 {
+		return fallback
+	}
+	return parser.Shape(node.Raw).Start.Line
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// MinRefreshInterval is the shortest refresh interval a target may use.
+const MinRefreshInterval = 100 * time.Millisecond
+
+// _decodeHook decodes durations from Go duration strings ("1m30s") or from
+// a number of seconds, which is how they were written before strings were
+// accepted.
+var _decodeHook = viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+	secondsToDurationHook,
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+))
+
+func secondsToDurationHook(from, to reflect.Type, data any) (any, error) {
+	if to != _durationType || from == _durationType {
+		return data, nil
+	}
+	switch value := data.(type) {
+	case string:
+		return ParseDuration(value)
+	case float32:
+		return secondsToDuration(float64(value))
+	case float64:
+		return secondsToDuration(value)
+	}
+	switch from.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return secondsToDuration(float64(reflect.ValueOf(data).Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return secondsToDuration(float64(reflect.ValueOf(data).Uint()))
+	}
+	return data, nil
+}
+
+// ParseDuration parses a Go duration string such as "500ms" or "1m30s", or
+// a plain number of seconds such as "5" or "0.5".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return secondsToDuration(seconds)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func secondsToDuration(seconds float64) (time.Duration, error) {
+	d := seconds * float64(time.Second)
+	if math.IsNaN(d) || math.IsInf(d, 0) || d > math.MaxInt64 || d < math.MinInt64 {
+		return 0, fmt.Errorf("duration of %v seconds is out of range", seconds)
+	}
+	return time.Duration(math.Round(d)), nil
+}
+
+// FormatDuration formats d the way durations are written in config files,
+// dropping the zero units time.Duration.String leaves in ("1m0s" is "1m").
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
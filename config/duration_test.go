@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "5", want: 5 * time.Second},
+		{input: "0.5", want: 500 * time.Millisecond},
+		{input: "500ms", want: 500 * time.Millisecond},
+		{input: "1m30s", want: 90 * time.Second},
+		{input: " 2s ", want: 2 * time.Second},
+		{input: "soon", wantErr: true},
+		{input: "1e300", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		500 * time.Millisecond:  "500ms",
+		5 * time.Second:         "5s",
+		90 * time.Second:        "1m30s",
+		time.Minute:             "1m",
+		2 * time.Hour:           "2h",
+		time.Hour + time.Minute: "1h1m",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestLoadConfigDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name:    "toml",
+			file:    "updo.toml",
+			content: "[global]\nrefresh_interval = \"1m30s\"\ntimeout = 2\n\n[[targets]]\nurl = \"https://example.com\"\nrefresh_interval = \"500ms\"\ntimeout = 1.5\n\n[[targets]]\nurl = \"https://example.org\"\n",
+		},
+		{
+			name:    "yaml",
+			file:    "updo.yaml",
+			content: "global:\n  refresh_interval: 1m30s\n  timeout: 2\ntargets:\n  - url: https://example.com\n    refresh_interval: 500ms\n    timeout: 1.5\n  - url: https://example.org\n",
+		},
+		{
+			name:    "json",
+			file:    "updo.json",
+			content: `{"global": {"refresh_interval": "1m30s", "timeout": 2}, "targets": [{"url": "https://example.com", "refresh_interval": "500ms", "timeout": 1.5}, {"url": "https://example.org"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, tt.file, []byte(tt.content)))
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			fast, inherited := cfg.Targets[0], cfg.Targets[1]
+			if fast.RefreshInterval != 500*time.Millisecond || fast.Timeout != 1500*time.Millisecond {
+				t.Errorf("Expected 500ms and 1.5s, got %v and %v", fast.RefreshInterval, fast.Timeout)
+			}
+			if inherited.RefreshInterval != 90*time.Second || inherited.Timeout != 2*time.Second {
+				t.Errorf("Expected the global 1m30s and 2s, got %v and %v", inherited.RefreshInterval, inherited.Timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats, named after the extensions they are detected by.
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+var _yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// DetectFormat returns the format of a config file from its extension.
+// Files without a known extension are read as TOML.
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	default:
+		return FormatTOML
+	}
+}
+
+// ParseFormat checks a format name given by the user, accepting "yml" for
+// YAML.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case FormatTOML:
+		return FormatTOML, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown config format %q, expected toml, yaml or json", name)
+	}
+}
+
+func parseDocument(data []byte, format string) (*docNode, error) {
+	switch format {
+	case FormatYAML:
+		return parseYAMLDocument(data)
+	case FormatJSON:
+		return parseJSONDocument(data)
+	default:
+		return parseTOMLDocument(data)
+	}
+}
+
+func parseYAMLDocument(data []byte) (*docNode, error) {
+	doc, err := parseYAMLValue(data)
+	if err != nil || doc == nil {
+		return newTable(1), err
+	}
+	if doc.kind != docTable {
+		return nil, ValidationErrors{{Line: doc.line, Message: "the document must be a mapping"}}
+	}
+	return doc, nil
+}
+
+// parseYAMLValue parses a YAML document of any kind, returning nil if it
+// is empty.
+func parseYAMLValue(data []byte) (*docNode, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		line, message := 1, strings.TrimPrefix(err.Error(), "yaml: ")
+		if match := _yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = err.Error()[len(match[0]):]
+		}
+		return nil, ValidationErrors{{Line: line, Message: message}}
+	}
+	if root.Kind == 0 || len(root.Content) == 0 {
+		return nil, nil
+	}
+	return yamlValue(root.Content[0])
+}
+
+func yamlValue(node *yaml.Node) (*docNode, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		table := newTable(node.Line)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child, err := yamlValue(value)
+			if err != nil {
+				return nil, err
+			}
+			// Merge keys copy the fields of the anchored mapping that are
+			// not set explicitly.
+			if key.Value == "<<" && key.Tag == "!!merge" {
+				if child.kind != docTable {
+					return nil, ValidationErrors{{Line: key.Line, Message: "<< must refer to a mapping"}}
+				}
+				for _, k := range child.keys {
+					if _, exists := table.fields[k]; !exists {
+						table.set(k, child.fields[k])
+					}
+				}
+				continue
+			}
+			if _, exists := table.fields[key.Value]; exists {
+				return nil, ValidationErrors{{Line: key.Line, Message: fmt.Sprintf("%q is already defined", key.Value)}}
+			}
+			child.line = key.Line
+			table.set(key.Value, child)
+		}
+		return table, nil
+	case yaml.SequenceNode:
+		array := &docNode{kind: docArray, line: node.Line}
+		for _, item := range node.Content {
+			child, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, child)
+		}
+		return array, nil
+	}
+
+	scalar := &docNode{line: node.Line, text: node.Value}
+	switch node.ShortTag() {
+	case "!!int":
+		scalar.kind = docInteger
+	case "!!float":
+		scalar.kind = docFloat
+	case "!!bool":
+		scalar.kind = docBool
+	case "!!null":
+		scalar.kind = docNull
+	case "!!timestamp":
+		scalar.kind = docDateTime
+	default:
+		scalar.kind = docString
+	}
+	return scalar, nil
+}
+
+func parseJSONDocument(data []byte) (*docNode, error) {
+	doc, err := parseJSONValue(data)
+	if err != nil {
+		return nil, err
+	}
+	if doc.kind != docTable {
+		return nil, ValidationErrors{{Line: doc.line, Message: "the document must be an object"}}
+	}
+	return doc, nil
+}
+
+// parseJSONValue parses a JSON document of any kind.
+func parseJSONValue(data []byte) (*docNode, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	parser := &jsonParser{decoder: decoder, data: data}
+
+	doc, err := parser.value()
+	if err == nil {
+		if _, err = decoder.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the top-level value")
+		}
+	}
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return nil, errs
+	}
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		offset := decoder.InputOffset()
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+		return nil, ValidationErrors{{Line: parser.lineAt(offset), Message: err.Error()}}
+	}
+	return doc, nil
+}
+
+// jsonParser builds a docNode tree from the tokens of a JSON document,
+// keeping track of the line each value starts on.
+type jsonParser struct {
+	decoder *json.Decoder
+	data    []byte
+}
+
+// nextLine returns the line of the token the decoder reads next.
+func (p *jsonParser) nextLine() int {
+	offset := p.decoder.InputOffset()
+	for offset < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	return p.lineAt(offset)
+}
+
+func (p *jsonParser) lineAt(offset int64) int {
+	offset = min(max(offset, 0), int64(len(p.data)))
+	return bytes.Count(p.data[:offset], []byte{'\n'}) + 1
+}
+
+func (p *jsonParser) value() (*docNode, error) {
+	line := p.nextLine()
+	token, err := p.decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			table := newTable(line)
+			for p.decoder.More() {
+				keyLine := p.nextLine()
+				keyToken, err := p.decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyToken.(string)
+				if _, exists := table.fields[key]; exists {
+					return nil, ValidationErrors{{Line: keyLine, Message: fmt.Sprintf("%q is already defined", key)}}
+				}
+				child, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				child.line = keyLine
+				table.set(key, child)
+			}
+			_, err := p.decoder.Token()
+			return table, err
+		}
+		array := &docNode{kind: docArray, line: line}
+		for p.decoder.More() {
+			child, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, child)
+		}
+		_, err := p.decoder.Token()
+		return array, err
+	case string:
+		return &docNode{kind: docString, line: line, text: t}, nil
+	case json.Number:
+		if strings.ContainsAny(string(t), ".eE") {
+			return &docNode{kind: docFloat, line: line, text: string(t)}, nil
+		}
+		return &docNode{kind: docInteger, line: line, text: string(t)}, nil
+	case bool:
+		return &docNode{kind: docBool, line: line, text: strconv.FormatBool(t)}, nil
+	default:
+		return &docNode{kind: docNull, line: line}, nil
+	}
+}
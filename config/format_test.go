@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const _formatTestTOML = `[global]
+refresh_interval = 10
+skip_ssl = true
+apdex_threshold = 0.5
+labels = { env = "prod" }
+
+[[targets]]
+url = "https://example.com"
+name = "Example"
+skip_ssl = false
+headers = ["Accept: */*"]
+
+[[targets]]
+url = "https://api.example.com"
+method = "POST"
+
+[targets.labels]
+team = "api"
+
+[logs.loki]
+url = "http://localhost:3100"
+flush_interval = "2s"
+`
+
+func writeConfig(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	want, err := LoadConfig(writeConfig(t, "updo.toml", []byte(_formatTestTOML)))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	for _, format := range []string{FormatYAML, FormatJSON, FormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			converted, err := Convert([]byte(_formatTestTOML), FormatTOML, format)
+			if err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+			got, err := LoadConfig(writeConfig(t, "updo."+format, converted))
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v\n%s", err, converted)
+			}
+			for i := range got.Targets {
+				got.Targets[i].Source = want.Targets[i].Source
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Config differs from TOML:\ngot  %+v\nwant %+v\n%s", got, want, converted)
+			}
+			if got.Targets[1].SkipSSL == nil || !*got.Targets[1].SkipSSL {
+				t.Error("Second target should inherit skip_ssl from global")
+			}
+		})
+	}
+}
+
+func TestLoadConfigFormatErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{
+			name:    "yaml unknown key",
+			file:    "updo.yml",
+			content: "global:\n  timeout: 5\ntargets:\n  - url: https://example.com\n    nmae: Example\n",
+			want:    `line 5: targets[0]: unknown key "nmae", did you mean "name"?`,
+		},
+		{
+			name:    "yaml wrong type",
+			file:    "updo.yaml",
+			content: "targets:\n  - url: https://example.com\n    skip_ssl: \"yes\"\n",
+			want:    "line 3: targets[0].skip_ssl: expected a boolean, got a string",
+		},
+		{
+			name:    "yaml syntax error",
+			file:    "updo.yaml",
+			content: "targets:\n  - url: https://example.com\n    name: Example\n      timeout: 5\n",
+			want:    "line 4: mapping values are not allowed in this context",
+		},
+		{
+			name:    "json invalid url",
+			file:    "updo.json",
+			content: "{\n  \"targets\": [\n    {\n      \"url\": \"example.com\"\n    }\n  ]\n}\n",
+			want:    `line 4: targets[0].url: invalid URL "example.com"`,
+		},
+		{
+			name:    "json duplicate key",
+			file:    "updo.json",
+			content: "{\n  \"targets\": [{\"url\": \"https://example.com\"}],\n  \"targets\": []\n}\n",
+			want:    `line 3: "targets" is already defined`,
+		},
+		{
+			name:    "json syntax error",
+			file:    "updo.json",
+			content: "{\n  \"targets\": [\n    {\"url\": \"https://example.com\",}\n  ]\n}\n",
+			want:    "line 3: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.file, []byte(tt.content)))
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), tt.want) {
+				t.Errorf("Expected one error starting with %q, got:\n%v", tt.want, errs)
+			}
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	unresolved := "include = [\"teams/*.toml\"]\n\n[[targets]]\nurl = \"https://example.com\"\nheaders = [\"Authorization: Bearer ${UPDO_TEST_UNSET_TOKEN}\"]\n"
+	if err := CheckSchema([]byte(unresolved), FormatTOML); err != nil {
+		t.Errorf("CheckSchema should not resolve references or includes, got %v", err)
+	}
+
+	err := CheckSchema([]byte("[[targets]]\nurl = \"https://example.com\"\nasert_text = \"ok\"\n"), FormatTOML)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !strings.Contains(errs[0].Error(), `unknown key "asert_text"`) {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	skipSSL := false
+	cfg := Config{
+		Global: Global{Timeout: 5 * time.Second, Labels: map[string]string{"team": "api", "env": "prod"}},
+		Targets: []Target{
+			{URL: "https://example.com", SkipSSL: &skipSSL, Headers: []string{"Accept: */*"}},
+		},
+		Logs: Logs{Loki: LokiOutput{URL: "http://localhost:3100", FlushInterval: 2 * time.Second}},
+	}
+
+	got, err := Marshal(cfg, FormatTOML)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `[global]
+timeout = "5s"
+
+[global.labels]
+env = "prod"
+team = "api"
+
+[[targets]]
+url = "https://example.com"
+skip_ssl = false
+headers = ["Accept: */*"]
+
+[logs.loki]
+url = "http://localhost:3100"
+flush_interval = "2s"
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
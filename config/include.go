@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// includeFile is the layout of the files listed in include. Defaults
+// fill in the settings its targets and their templates leave unset, before
+// [global] applies.
+type includeFile struct {
+	Defaults Target   `mapstructure:"defaults"`
+	Targets  []Target `mapstructure:"targets"`
+}
+
+// loadIncludes reads the targets of every file matched by the include
+// patterns of configFile, in pattern then file name order. Files matched
+// twice, and configFile itself, are read once.
+func loadIncludes(patterns []string, configFile string, doc *docNode, templates *templateResolver, names map[string]targetRef) ([]Target, ValidationErrors) {
+	var targets []Target
+	var errs ValidationErrors
+
+	seen := make(map[string]bool)
+	if absPath, err := filepath.Abs(configFile); err == nil {
+		seen[absPath] = true
+	}
+
+	for i, pattern := range patterns {
+		pattern = resolvePattern(pattern, configFile)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			errs.add(doc.lineOf("include", i), fmt.Sprintf("include[%d]", i), "invalid pattern %q: %v", patterns[i], err)
+			continue
+		}
+		if len(matches) == 0 && !hasMeta(pattern) {
+			errs.add(doc.lineOf("include", i), fmt.Sprintf("include[%d]", i), "%s does not exist", pattern)
+			continue
+		}
+
+		for _, file := range matches {
+			absPath, err := filepath.Abs(file)
+			if err != nil || seen[absPath] {
+				continue
+			}
+			seen[absPath] = true
+
+			fileTargets, fileErrs := loadIncludeFile(file, templates, names)
+			targets = append(targets, fileTargets...)
+			errs = append(errs, fileErrs.inFile(file)...)
+		}
+	}
+
+	return targets, errs
+}
+
+func loadIncludeFile(file string, templates *templateResolver, names map[string]targetRef) ([]Target, ValidationErrors) {
+	data, err := os.ReadFile(file) // #nosec G304 -- path comes from the user's own config file
+	if err != nil {
+		return nil, ValidationErrors{{Line: 1, Message: err.Error()}}
+	}
+	format := DetectFormat(file)
+	doc, err := parseDocument(data, format)
+	if err != nil {
+		var errs ValidationErrors
+		if errors.As(err, &errs) {
+			return nil, errs
+		}
+		return nil, ValidationErrors{{Line: 1, Message: err.Error()}}
+	}
+
+	var errs ValidationErrors
+	checkSchema(doc, reflect.TypeOf(includeFile{}), "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, ValidationErrors{{Line: 1, Message: err.Error()}}
+	}
+	var included includeFile
+	if err := v.Unmarshal(&included, _decodeHook); err != nil {
+		return nil, ValidationErrors{{Line: 1, Message: err.Error()}}
+	}
+
+	dir := filepath.Dir(file)
+	interpolateFields(reflect.ValueOf(&included.Defaults), dir, doc, &errs, "defaults")
+	for i := range included.Targets {
+		interpolateFields(reflect.ValueOf(&included.Targets[i]), dir, doc, &errs, "targets", i)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	checkTarget(included.Defaults, false, doc, &errs, "defaults")
+	defaults, _ := templates.extend(included.Defaults, doc, &errs, "defaults")
+	for i := range included.Targets {
+		target := &included.Targets[i]
+		checkTarget(*target, true, doc, &errs, "targets", i)
+		extended, keys := templates.extend(*target, doc, &errs, "targets", i)
+		*target = mergeTarget(defaults, extended, keys)
+		target.Source = file
+		checkName(target.Name, targetRef{file: file, index: i}, names, doc, &errs)
+	}
+
+	return included.Targets, errs
+}
+
+// hasMeta reports whether pattern uses any glob syntax.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
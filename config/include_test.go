@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml": `include = ["targets.d/*.toml", "targets.d/*.yaml", "updo.toml"]
+
+[global]
+timeout = 7
+labels = { env = "prod" }
+
+[[targets]]
+url = "https://example.com"
+name = "Main"
+`,
+		"targets.d/payments.toml": `[defaults]
+refresh_interval = 30
+skip_ssl = true
+labels = { team = "payments" }
+
+[[targets]]
+url = "https://pay.example.com"
+name = "Payments"
+
+[[targets]]
+url = "https://refunds.example.com"
+name = "Refunds"
+refresh_interval = 60
+labels = { tier = "2" }
+`,
+		"targets.d/search.yaml": "targets:\n  - url: https://search.example.com\n    name: Search\n",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	var names []string
+	for _, target := range cfg.Targets {
+		names = append(names, target.Name)
+	}
+	if got := strings.Join(names, ","); got != "Main,Payments,Refunds,Search" {
+		t.Fatalf("Expected targets Main,Payments,Refunds,Search, got %s", got)
+	}
+
+	payments, refunds, search := cfg.Targets[1], cfg.Targets[2], cfg.Targets[3]
+	if payments.RefreshInterval != 30*time.Second || refunds.RefreshInterval != time.Minute || search.RefreshInterval != _defaultRefreshInterval {
+		t.Errorf("Unexpected refresh intervals %v, %v, %v", payments.RefreshInterval, refunds.RefreshInterval, search.RefreshInterval)
+	}
+	if !BoolVal(payments.SkipSSL, false) || BoolVal(search.SkipSSL, false) {
+		t.Error("skip_ssl from [defaults] should only apply to its own file")
+	}
+	if payments.Timeout != 7*time.Second {
+		t.Errorf("Expected the global timeout to apply to included targets, got %v", payments.Timeout)
+	}
+	if refunds.Labels["team"] != "payments" || refunds.Labels["tier"] != "2" || refunds.Labels["env"] != "prod" {
+		t.Errorf("Expected labels to merge defaults, target and global labels, got %v", refunds.Labels)
+	}
+	if want := filepath.Join(dir, "targets.d", "payments.toml"); payments.Source != want {
+		t.Errorf("Expected Source=%s, got %s", want, payments.Source)
+	}
+	if want := filepath.Join(dir, "updo.toml"); cfg.Targets[0].Source != want {
+		t.Errorf("Expected Source=%s, got %s", want, cfg.Targets[0].Source)
+	}
+}
+
+func TestLoadConfigIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml": `include = ["teams/*.toml", "missing.toml"]
+
+[[targets]]
+url = "https://example.com"
+name = "API"
+`,
+		"teams/a.toml": `[[targets]]
+url = "https://a.example.com"
+name = "API"
+
+[[targets]]
+url = "a.example.com"
+`,
+		"teams/b.toml": "[global]\ntimeout = 5\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "updo.toml"))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	teamA, teamB := filepath.Join(dir, "teams", "a.toml"), filepath.Join(dir, "teams", "b.toml")
+	want := []string{
+		"line 1: include[1]: " + filepath.Join(dir, "missing.toml") + " does not exist",
+		teamA + `: line 3: targets[0].name: duplicate name "API", also used by targets[0] in ` + filepath.Join(dir, "updo.toml"),
+		teamA + `: line 6: targets[1].url: invalid URL "a.example.com"`,
+		teamB + `: line 1: unknown key "global"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d:\n%v", len(want), len(errs), errs)
+	}
+	for i := range want {
+		if got := errs[i].Error(); !strings.HasPrefix(got, want[i]) {
+			t.Errorf("Error %d: expected prefix %q, got %q", i, want[i], got)
+		}
+	}
+}
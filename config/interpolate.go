@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var (
+	// _interpolation matches ${...} references and the $${ escape.
+	_interpolation = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+	_envVarName    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// interpolate expands ${VAR}, ${VAR:-default} and ${file:path} in s.
+// Relative file paths are resolved against dir, and trailing newlines are
+// removed from file contents. $${ is written as a literal ${.
+func interpolate(s, dir string) (string, error) {
+	var firstErr error
+	result := _interpolation.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		reference := match[2 : len(match)-1]
+		fail := func(err error) string {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+
+		if path, isFile := strings.CutPrefix(reference, "file:"); isFile {
+			if path == "" {
+				return fail(fmt.Errorf("%s: missing file path", match))
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			data, err := os.ReadFile(path) // #nosec G304 -- path comes from the user's own config file
+			if err != nil {
+				return fail(fmt.Errorf("%s: %w", match, err))
+			}
+			return strings.TrimRight(string(data), "\r\n")
+		}
+
+		name, fallback, hasFallback := strings.Cut(reference, ":-")
+		if !_envVarName.MatchString(name) {
+			return fail(fmt.Errorf("%s: invalid environment variable name %q", match, name))
+		}
+		value, isSet := os.LookupEnv(name)
+		switch {
+		case hasFallback && value == "":
+			return fallback
+		case !isSet:
+			return fail(fmt.Errorf("%s: environment variable %s is not set", match, name))
+		}
+		return value
+	})
+	return result, firstErr
+}
+
+// interpolateFields expands the references in every string, string array
+// and string map field of the struct v points to.
+func interpolateFields(v reflect.Value, dir string, doc *docNode, errs *ValidationErrors, path ...any) {
+	v = v.Elem()
+	for i := range v.NumField() {
+		key, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("mapstructure"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		field := v.Field(i)
+		fieldPath := append(path[:len(path):len(path)], key)
+
+		expand := func(value string, valuePath []any) string {
+			expanded, err := interpolate(value, dir)
+			if err != nil {
+				errs.add(doc.lineOf(valuePath...), formatPath(valuePath), "%v", err)
+			}
+			return expanded
+		}
+
+		switch {
+		case field.Kind() == reflect.String:
+			field.SetString(expand(field.String(), fieldPath))
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+			for j := range field.Len() {
+				item := field.Index(j)
+				item.SetString(expand(item.String(), append(fieldPath[:len(fieldPath):len(fieldPath)], j)))
+			}
+		case field.Kind() == reflect.Map && field.Type().Elem().Kind() == reflect.String:
+			for _, mapKey := range field.MapKeys() {
+				value := expand(field.MapIndex(mapKey).String(), append(fieldPath[:len(fieldPath):len(fieldPath)], mapKey.String()))
+				field.SetMapIndex(mapKey, reflect.ValueOf(value))
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UPDO_TEST_HOST", "example.com")
+	t.Setenv("UPDO_TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "plain", input: "https://example.com", want: "https://example.com"},
+		{name: "env", input: "https://${UPDO_TEST_HOST}/health", want: "https://example.com/health"},
+		{name: "empty env", input: "a${UPDO_TEST_EMPTY}b", want: "ab"},
+		{name: "default when unset", input: "${UPDO_TEST_UNSET:-fallback}", want: "fallback"},
+		{name: "default when empty", input: "${UPDO_TEST_EMPTY:-fallback}", want: "fallback"},
+		{name: "default ignored when set", input: "${UPDO_TEST_HOST:-fallback}", want: "example.com"},
+		{name: "relative file", input: "Authorization: Bearer ${file:token}", want: "Authorization: Bearer s3cret"},
+		{name: "absolute file", input: "${file:" + filepath.Join(dir, "token") + "}", want: "s3cret"},
+		{name: "escape", input: "$${UPDO_TEST_HOST} costs $5", want: "${UPDO_TEST_HOST} costs $5"},
+		{name: "unset", input: "${UPDO_TEST_UNSET}", wantErr: "environment variable UPDO_TEST_UNSET is not set"},
+		{name: "missing file", input: "${file:missing}", wantErr: "${file:missing}: open"},
+		{name: "invalid name", input: "${1BAD}", wantErr: `invalid environment variable name "1BAD"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolate(tt.input, dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolate failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("interpolate(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigInterpolation(t *testing.T) {
+	t.Setenv("UPDO_TEST_TOKEN", "abc")
+	content := `[global]
+webhook_url = "${UPDO_TEST_WEBHOOK:-https://hooks.example.com/updo}"
+
+[[targets]]
+url = "https://example.com"
+headers = ["Authorization: Bearer ${UPDO_TEST_TOKEN}"]
+labels = { env = "${UPDO_TEST_ENV:-prod}" }
+`
+	cfg, err := LoadConfig(writeConfig(t, "updo.toml", []byte(content)))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	target := cfg.Targets[0]
+	if target.WebhookURL != "https://hooks.example.com/updo" {
+		t.Errorf("Expected the default webhook URL to be inherited, got %q", target.WebhookURL)
+	}
+	if target.Headers[0] != "Authorization: Bearer abc" {
+		t.Errorf("Expected the header to be interpolated, got %q", target.Headers[0])
+	}
+	if target.Labels["env"] != "prod" {
+		t.Errorf("Expected the label to be interpolated, got %q", target.Labels["env"])
+	}
+
+	content = "[[targets]]\nname = \"API\"\nurl = \"https://${UPDO_TEST_UNSET}/health\"\n"
+	_, err = LoadConfig(writeConfig(t, "updo.toml", []byte(content)))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Expected one validation error, got %v", err)
+	}
+	want := "line 3: targets[0].url: ${UPDO_TEST_UNSET}: environment variable UPDO_TEST_UNSET is not set"
+	if errs[0].Error() != want {
+		t.Errorf("Expected %q, got %q", want, errs[0].Error())
+	}
+}
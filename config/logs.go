@@ -0,0 +1,53 @@
+package config
+
+import "time"
+
+// Logs configures where simple mode output goes and shipping of the --log
+// records under [logs]. Every shipping output with a URL is enabled.
+type Logs struct {
+	// Output is stdout, syslog, journald or a syslog address such as
+	// udp://host:514.
+	Output string       `mapstructure:"output"`
+	Syslog SyslogOutput `mapstructure:"syslog"`
+	// Format is json (default) or logfmt.
+	Format string `mapstructure:"format"`
+	// Fields lists the record fields to write, or with a leading "-" the
+	// ones to leave out.
+	Fields []string `mapstructure:"fields"`
+	// RedactHeaders and RedactBody mask values in the records only, on top
+	// of [redact].
+	RedactHeaders []string            `mapstructure:"redact_headers"`
+	RedactBody    []string            `mapstructure:"redact_body"`
+	MaxBodyLength int                 `mapstructure:"max_body_length"`
+	Loki          LokiOutput          `mapstructure:"loki"`
+	Elasticsearch ElasticsearchOutput `mapstructure:"elasticsearch"`
+}
+
+type SyslogOutput struct {
+	Facility string `mapstructure:"facility"`
+	AppName  string `mapstructure:"app_name"`
+}
+
+type LokiOutput struct {
+	URL      string `mapstructure:"url"`
+	TenantID string `mapstructure:"tenant_id"`
+	Username string `mapstructure:"username"`
+	// Password is better supplied through UPDO_LOKI_PASSWORD.
+	Password      string        `mapstructure:"password"` // #nosec G117 -- credential read from config, never serialized
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// ElasticsearchOutput also covers OpenSearch, which accepts the same bulk
+// requests.
+type ElasticsearchOutput struct {
+	URL      string `mapstructure:"url"`
+	Index    string `mapstructure:"index"`
+	Username string `mapstructure:"username"`
+	// Password and APIKey are better supplied through
+	// UPDO_ELASTICSEARCH_PASSWORD and UPDO_ELASTICSEARCH_API_KEY.
+	Password      string        `mapstructure:"password"` // #nosec G117 -- credential read from config, never serialized
+	APIKey        string        `mapstructure:"api_key"`  // #nosec G117 -- credential read from config, never serialized
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
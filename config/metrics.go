@@ -0,0 +1,80 @@
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	RelabelDrop   = "drop"
+	RelabelRename = "rename"
+	RelabelHash   = "hash"
+)
+
+const (
+	SinkPrometheus = "prometheus"
+	SinkInfluxDB   = "influxdb"
+	SinkStatsD     = "statsd"
+	SinkGraphite   = "graphite"
+)
+
+// Metrics configures the metrics outputs under [metrics]. Every output
+// with an address is enabled unless Sinks names the ones to use.
+type Metrics struct {
+	Sinks      []string       `mapstructure:"sinks"`
+	Prometheus PrometheusSink `mapstructure:"prometheus"`
+	InfluxDB   InfluxDBSink   `mapstructure:"influxdb"`
+	StatsD     StatsDSink     `mapstructure:"statsd"`
+	Graphite   GraphiteSink   `mapstructure:"graphite"`
+	Relabel    []RelabelRule  `mapstructure:"relabel"`
+}
+
+// RelabelRule rewrites a label of every exported series, in the order the
+// rules are listed: drop removes it, rename moves its value to To and hash
+// replaces its value with a short hash.
+type RelabelRule struct {
+	Action string `mapstructure:"action"`
+	Label  string `mapstructure:"label"`
+	To     string `mapstructure:"to"`
+}
+
+type PrometheusSink struct {
+	URL string `mapstructure:"url"`
+	// Protocol is the remote write version, "2.0" (default) or "1.0".
+	Protocol      string        `mapstructure:"protocol"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// WALDir keeps unsent samples on disk across restarts.
+	WALDir       string `mapstructure:"wal_dir"`
+	WALMaxSizeMB int    `mapstructure:"wal_max_size_mb"`
+	Shards       int    `mapstructure:"shards"`
+}
+
+type InfluxDBSink struct {
+	URL    string `mapstructure:"url"`
+	Org    string `mapstructure:"org"`
+	Bucket string `mapstructure:"bucket"`
+	// Token is better supplied through UPDO_INFLUXDB_TOKEN.
+	Token         string        `mapstructure:"token"` // #nosec G117 -- API token read from config, never serialized
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+type StatsDSink struct {
+	Address       string        `mapstructure:"address"`
+	Prefix        string        `mapstructure:"prefix"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+type GraphiteSink struct {
+	Address       string        `mapstructure:"address"`
+	Prefix        string        `mapstructure:"prefix"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// SinkEnabled reports whether the named output should be started given
+// whether it has an address configured.
+func (m Metrics) SinkEnabled(name string, configured bool) bool {
+	if len(m.Sinks) == 0 {
+		return configured
+	}
+	return slices.Contains(m.Sinks, name)
+}
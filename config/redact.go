@@ -0,0 +1,13 @@
+package config
+
+// Redact configures the secrets masked wherever results are rendered or
+// exported, under [redact]. Well-known credential headers and query
+// parameters are always masked unless DisableDefaults is set.
+type Redact struct {
+	Headers     []string `mapstructure:"headers"`
+	QueryParams []string `mapstructure:"query_params"`
+	// BodyPatterns are regular expressions matched against bodies and
+	// error messages.
+	BodyPatterns    []string `mapstructure:"body_patterns"`
+	DisableDefaults bool     `mapstructure:"disable_defaults"`
+}
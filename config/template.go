@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// templateResolver applies [templates.x] blocks to the targets extending
+// them. Templates may extend other templates.
+type templateResolver struct {
+	templates map[string]Target
+	resolved  map[string]Target
+	// keys holds the keys each resolved template sets, itself or through
+	// the templates it extends.
+	keys      map[string]map[string]bool
+	resolving map[string]bool
+	doc       *docNode
+	errs      *ValidationErrors
+}
+
+func newTemplateResolver(templates map[string]Target, doc *docNode, errs *ValidationErrors) *templateResolver {
+	r := &templateResolver{
+		templates: templates,
+		resolved:  make(map[string]Target, len(templates)),
+		keys:      make(map[string]map[string]bool, len(templates)),
+		resolving: make(map[string]bool),
+		doc:       doc,
+		errs:      errs,
+	}
+	for _, name := range sortedKeys(templates) {
+		r.resolve(name, []string{name})
+	}
+	return r
+}
+
+func (r *templateResolver) resolve(name string, chain []string) Target {
+	if template, exists := r.resolved[name]; exists {
+		return template
+	}
+	r.resolving[name] = true
+	defer delete(r.resolving, name)
+
+	template := r.templates[name]
+	base, baseKeys := Target{}, map[string]bool{}
+	for j, parent := range template.Extends {
+		if r.resolving[parent] {
+			r.errs.add(r.doc.lineOf("templates", name, "extends", j), fmt.Sprintf("templates.%s.extends[%d]", name, j),
+				"template cycle %s", strings.Join(append(chain, parent), " -> "))
+			continue
+		}
+		if _, exists := r.templates[parent]; !exists {
+			r.unknown(parent, r.doc, r.errs, "templates", name, "extends", j)
+			continue
+		}
+		base = mergeTarget(base, r.resolve(parent, append(chain, parent)), r.keys[parent])
+		maps.Copy(baseKeys, r.keys[parent])
+	}
+
+	keys := setKeys(r.doc.lookup("templates", name))
+	resolved := mergeTarget(base, template, keys)
+	maps.Copy(baseKeys, keys)
+	r.resolved[name], r.keys[name] = resolved, baseKeys
+	return resolved
+}
+
+// extend merges the templates target extends into it, reporting unknown
+// templates against doc, the file target comes from at path. It also
+// returns the keys set by the target or its templates.
+func (r *templateResolver) extend(target Target, doc *docNode, errs *ValidationErrors, path ...any) (Target, map[string]bool) {
+	base, baseKeys := Target{}, map[string]bool{}
+	for j, name := range target.Extends {
+		template, exists := r.resolved[name]
+		if !exists {
+			r.unknown(name, doc, errs, append(path[:len(path):len(path)], "extends", j)...)
+			continue
+		}
+		base = mergeTarget(base, template, r.keys[name])
+		maps.Copy(baseKeys, r.keys[name])
+	}
+
+	keys := setKeys(doc.lookup(path...))
+	maps.Copy(baseKeys, keys)
+	return mergeTarget(base, target, keys), baseKeys
+}
+
+func (r *templateResolver) unknown(name string, doc *docNode, errs *ValidationErrors, path ...any) {
+	if suggestion := closestKey(name, sortedKeys(r.templates)); suggestion != "" {
+		errs.add(doc.lineOf(path...), formatPath(path), "unknown template %q, did you mean %q?", name, suggestion)
+		return
+	}
+	errs.add(doc.lineOf(path...), formatPath(path), "unknown template %q", name)
+}
+
+// setKeys returns the keys set in the table node, which may be nil.
+func setKeys(node *docNode) map[string]bool {
+	keys := make(map[string]bool)
+	if node != nil {
+		for _, key := range node.keys {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// mergeTarget returns base with the settings of override named in keys
+// replacing its own, even when override sets them to their zero value.
+// Headers and labels are merged by name instead, so that the ones override
+// does not mention are kept. The result extends what override extends.
+func mergeTarget(base, override Target, keys map[string]bool) Target {
+	merged := base
+	value, overrideValue := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(override)
+	for i := range value.NumField() {
+		key, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("mapstructure"), ",")
+		if keys[key] {
+			value.Field(i).Set(overrideValue.Field(i))
+		}
+	}
+
+	merged.Headers = mergeHeaders(base.Headers, override.Headers)
+	merged.WebhookHeaders = mergeHeaders(base.WebhookHeaders, override.WebhookHeaders)
+	switch {
+	case len(override.Labels) == 0:
+		merged.Labels = base.Labels
+	case len(base.Labels) > 0:
+		merged.Labels = maps.Clone(base.Labels)
+		maps.Copy(merged.Labels, override.Labels)
+	}
+	merged.Extends = override.Extends
+	return merged
+}
+
+// mergeHeaders appends the "Name: value" headers of override to those of
+// base, replacing base headers of the same name in place.
+func mergeHeaders(base, override []string) []string {
+	if len(base) == 0 || len(override) == 0 {
+		if len(override) > 0 {
+			return override
+		}
+		return base
+	}
+
+	merged := append([]string(nil), base...)
+	for _, header := range override {
+		name, _, _ := strings.Cut(header, ":")
+		replaced := false
+		for i, existing := range merged {
+			existingName, _, _ := strings.Cut(existing, ":")
+			if strings.EqualFold(strings.TrimSpace(existingName), strings.TrimSpace(name)) {
+				merged[i] = header
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, header)
+		}
+	}
+	return merged
+}
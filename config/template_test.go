@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigTemplates(t *testing.T) {
+	content := `[global]
+timeout = 10
+labels = { env = "prod" }
+
+[templates.base]
+method = "POST"
+headers = ["Accept: application/json"]
+labels = { tier = "1" }
+
+[templates.internal-api]
+extends = ["base"]
+headers = ["X-Internal: 1"]
+timeout = 3
+skip_ssl = true
+
+[templates.auth]
+headers = ["Authorization: Bearer token", "Accept: */*"]
+assert_text = "ok"
+
+[[targets]]
+url = "https://api.example.com"
+name = "API"
+extends = ["internal-api", "auth"]
+skip_ssl = false
+labels = { team = "api" }
+
+[[targets]]
+url = "https://other.example.com"
+name = "Other"
+extends = ["auth"]
+headers = ["Authorization: Bearer other"]
+`
+	cfg, err := LoadConfig(writeConfig(t, "updo.toml", []byte(content)))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	api, other := cfg.Targets[0], cfg.Targets[1]
+	if api.Method != "POST" || api.Timeout != 3*time.Second || api.AssertText != "ok" {
+		t.Errorf("Expected settings from the template chain, got method=%s timeout=%v assert_text=%q", api.Method, api.Timeout, api.AssertText)
+	}
+	if BoolVal(api.SkipSSL, true) {
+		t.Error("skip_ssl set on the target should win over its templates")
+	}
+	wantHeaders := []string{"Accept: */*", "X-Internal: 1", "Authorization: Bearer token"}
+	if !reflect.DeepEqual(api.Headers, wantHeaders) {
+		t.Errorf("Expected headers %v, got %v", wantHeaders, api.Headers)
+	}
+	wantLabels := map[string]string{"env": "prod", "tier": "1", "team": "api"}
+	if !reflect.DeepEqual(api.Labels, wantLabels) {
+		t.Errorf("Expected labels %v, got %v", wantLabels, api.Labels)
+	}
+
+	if other.Timeout != 10*time.Second || other.Method != _defaultMethod {
+		t.Errorf("Expected global defaults for settings no template sets, got timeout=%v method=%s", other.Timeout, other.Method)
+	}
+	wantHeaders = []string{"Authorization: Bearer other", "Accept: */*"}
+	if !reflect.DeepEqual(other.Headers, wantHeaders) {
+		t.Errorf("Expected headers %v, got %v", wantHeaders, other.Headers)
+	}
+}
+
+func TestLoadConfigTemplateZeroOverrides(t *testing.T) {
+	content := `[templates.negative]
+should_fail = true
+apdex_threshold = 0.5
+assert_text = "down"
+
+[templates.child]
+extends = ["negative"]
+assert_text = ""
+
+[[targets]]
+url = "https://api.example.com"
+name = "API"
+extends = ["negative"]
+should_fail = false
+apdex_threshold = 0
+
+[[targets]]
+url = "https://web.example.com"
+name = "Web"
+extends = ["child"]
+`
+	cfg, err := LoadConfig(writeConfig(t, "updo.toml", []byte(content)))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	api, web := cfg.Targets[0], cfg.Targets[1]
+	if api.ShouldFail || api.ApdexThreshold != 0 {
+		t.Errorf("Explicit zero values should override the template, got should_fail=%v apdex_threshold=%v", api.ShouldFail, api.ApdexThreshold)
+	}
+	if api.AssertText != "down" {
+		t.Errorf("Settings the target does not mention should come from the template, got assert_text=%q", api.AssertText)
+	}
+	if !web.ShouldFail || web.AssertText != "" {
+		t.Errorf("Explicit zero values should override a parent template, got should_fail=%v assert_text=%q", web.ShouldFail, web.AssertText)
+	}
+}
+
+func TestLoadConfigTemplateErrors(t *testing.T) {
+	content := `[templates.a]
+extends = ["b"]
+
+[templates.b]
+extends = ["a"]
+
+[templates.auth]
+timeout = -1
+
+[[targets]]
+url = "https://example.com"
+extends = ["ath"]
+`
+	_, err := LoadConfig(writeConfig(t, "updo.toml", []byte(content)))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	want := []string{
+		"line 5: templates.b.extends[0]: template cycle a -> b -> a",
+		"line 8: templates.auth.timeout: must not be negative",
+		`line 12: targets[0].extends[0]: unknown template "ath", did you mean "auth"?`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d:\n%v", len(want), len(errs), errs)
+	}
+	for i := range want {
+		if got := errs[i].Error(); !strings.HasPrefix(got, want[i]) {
+			t.Errorf("Error %d: expected prefix %q, got %q", i, want[i], got)
+		}
+	}
+}
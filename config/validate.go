@@ -0,0 +1,390 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SupportedRegions are the AWS regions updo deploys its Lambda function to.
+var SupportedRegions = []string{
+	"us-east-1",      // N. Virginia
+	"us-west-1",      // N. California
+	"us-west-2",      // Oregon
+	"eu-west-1",      // Ireland
+	"eu-central-1",   // Frankfurt
+	"ap-southeast-1", // Singapore
+	"ap-southeast-2", // Sydney
+	"ap-northeast-1", // Tokyo
+	"ap-northeast-2", // Seoul
+	"ap-south-1",     // Mumbai
+	"sa-east-1",      // São Paulo
+	"ca-central-1",   // Canada
+	"eu-west-2",      // London
+}
+
+var _httpMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE", "CONNECT"}
+
+var _durationType = reflect.TypeOf(time.Duration(0))
+
+// ValidationError points at a problem in a config file.
+type ValidationError struct {
+	// File is set when the problem is in a file pulled in by include
+	// rather than in the config file itself.
+	File string
+	// Line is zero for problems with a file as a whole.
+	Line int
+	// Path is the key the problem is about, such as targets[1].url.
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	var parts []string
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+	if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	return strings.Join(append(parts, e.Message), ": ")
+}
+
+// ValidationErrors lists every problem found in a config file and the
+// files it includes, in the order of the lines they are on.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationErrors) add(line int, path, format string, args ...any) {
+	*e = append(*e, ValidationError{Line: line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e ValidationErrors) sorted() ValidationErrors {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].File != e[j].File {
+			return e[i].File < e[j].File
+		}
+		return e[i].Line < e[j].Line
+	})
+	return e
+}
+
+// inFile attributes errors to an included file.
+func (e ValidationErrors) inFile(file string) ValidationErrors {
+	for i := range e {
+		e[i].File = file
+	}
+	return e
+}
+
+// checkSchema reports keys of node that do not exist in t and values of
+// the wrong type.
+func checkSchema(node *docNode, t reflect.Type, path string, errs *ValidationErrors) {
+	if node.kind == docNull {
+		return
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == _durationType {
+		switch node.kind {
+		case docInteger, docFloat:
+		case docString:
+			if _, err := ParseDuration(node.text); err != nil {
+				errs.add(node.line, path, "%v", err)
+			}
+		default:
+			errs.add(node.line, path, "expected a duration such as \"5s\", got %s", kindName(node.kind))
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.kind != docTable {
+			errs.add(node.line, path, "expected a table, got %s", kindName(node.kind))
+			return
+		}
+		fields := structFields(t)
+		for _, key := range node.keys {
+			child := node.fields[key]
+			field, exists := fields[key]
+			if !exists {
+				if suggestion := closestKey(key, sortedKeys(fields)); suggestion != "" {
+					errs.add(child.line, path, "unknown key %q, did you mean %q?", key, suggestion)
+				} else {
+					errs.add(child.line, path, "unknown key %q", key)
+				}
+				continue
+			}
+			checkSchema(child, field.Type, joinPath(path, key), errs)
+		}
+	case reflect.Map:
+		if node.kind != docTable {
+			errs.add(node.line, path, "expected a table, got %s", kindName(node.kind))
+			return
+		}
+		for _, key := range node.keys {
+			checkSchema(node.fields[key], t.Elem(), joinPath(path, key), errs)
+		}
+	case reflect.Slice:
+		if node.kind != docArray {
+			errs.add(node.line, path, "expected an array, got %s", kindName(node.kind))
+			return
+		}
+		for i, item := range node.items {
+			checkSchema(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.String:
+		if node.kind != docString {
+			errs.add(node.line, path, "expected a string, got %s", kindName(node.kind))
+		}
+	case reflect.Bool:
+		if node.kind != docBool {
+			errs.add(node.line, path, "expected a boolean, got %s", kindName(node.kind))
+		}
+	case reflect.Int, reflect.Int64:
+		if node.kind != docInteger {
+			errs.add(node.line, path, "expected an integer, got %s", kindName(node.kind))
+		}
+	case reflect.Float64:
+		if node.kind != docInteger && node.kind != docFloat {
+			errs.add(node.line, path, "expected a number, got %s", kindName(node.kind))
+		}
+	}
+}
+
+// structFields maps the config keys of a struct to its fields.
+func structFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+func kindName(kind docKind) string {
+	switch kind {
+	case docTable:
+		return "a table"
+	case docArray:
+		return "an array"
+	case docString:
+		return "a string"
+	case docInteger:
+		return "an integer"
+	case docFloat:
+		return "a float"
+	case docBool:
+		return "a boolean"
+	case docNull:
+		return "null"
+	default:
+		return "a date"
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// closestKey suggests which of the known keys a misspelled one was meant
+// to be.
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// checkGlobal reports invalid values of the decoded [global] table.
+func checkGlobal(global Global, doc *docNode, errs *ValidationErrors) {
+	if global.RefreshInterval < MinRefreshInterval {
+		errs.add(doc.lineOf("global", "refresh_interval"), "global.refresh_interval", "must be at least %s", MinRefreshInterval)
+	}
+	if global.Timeout <= 0 {
+		errs.add(doc.lineOf("global", "timeout"), "global.timeout", "must be positive")
+	}
+	if global.Count < 0 {
+		errs.add(doc.lineOf("global", "count"), "global.count", "must not be negative")
+	}
+	if global.BodySizeLimit < 0 {
+		errs.add(doc.lineOf("global", "body_size_limit"), "global.body_size_limit", "must not be negative")
+	}
+	if global.ApdexThreshold < 0 {
+		errs.add(doc.lineOf("global", "apdex_threshold"), "global.apdex_threshold", "must not be negative")
+	}
+	checkHeaders(global.WebhookHeaders, doc, errs, "global", "webhook_headers")
+	checkRegions(global.Regions, doc, errs, "global", "regions")
+	checkURL(global.WebhookURL, false, doc, errs, "global", "webhook_url")
+	checkLabels(global.Labels, doc, errs, "global", "labels")
+}
+
+// checkTarget reports invalid values of a decoded target, before it
+// inherits the global settings. The url is only required of targets, not
+// of the [defaults] of included files.
+func checkTarget(target Target, requireURL bool, doc *docNode, errs *ValidationErrors, path ...any) {
+	sub := func(key string) []any { return append(path[:len(path):len(path)], key) }
+	notNegative := func(key string, negative bool) {
+		if negative {
+			errs.add(doc.lineOf(sub(key)...), formatPath(sub(key)), "must not be negative")
+		}
+	}
+
+	checkURL(target.URL, requireURL, doc, errs, sub("url")...)
+	checkURL(target.WebhookURL, false, doc, errs, sub("webhook_url")...)
+	if target.RefreshInterval != 0 && target.RefreshInterval < MinRefreshInterval {
+		errs.add(doc.lineOf(sub("refresh_interval")...), formatPath(sub("refresh_interval")), "must be at least %s", MinRefreshInterval)
+	}
+	notNegative("timeout", target.Timeout < 0)
+	notNegative("body_size_limit", target.BodySizeLimit != nil && *target.BodySizeLimit < 0)
+	notNegative("apdex_threshold", target.ApdexThreshold < 0)
+	checkMethod(target.Method, doc, errs, sub("method")...)
+	checkHeaders(target.Headers, doc, errs, sub("headers")...)
+	checkHeaders(target.WebhookHeaders, doc, errs, sub("webhook_headers")...)
+	checkRegions(target.Regions, doc, errs, sub("regions")...)
+	checkLabels(target.Labels, doc, errs, sub("labels")...)
+}
+
+// targetRef locates a target for duplicate name errors.
+type targetRef struct {
+	file  string
+	index int
+}
+
+// checkName reports a target whose name is already used by another target,
+// in the same file or another one.
+func checkName(name string, ref targetRef, names map[string]targetRef, doc *docNode, errs *ValidationErrors) {
+	if name == "" {
+		return
+	}
+	first, exists := names[name]
+	if !exists {
+		names[name] = ref
+		return
+	}
+	path := fmt.Sprintf("targets[%d].name", ref.index)
+	if first.file == ref.file {
+		errs.add(doc.lineOf("targets", ref.index, "name"), path, "duplicate name %q, also used by targets[%d]", name, first.index)
+		return
+	}
+	errs.add(doc.lineOf("targets", ref.index, "name"), path, "duplicate name %q, also used by targets[%d] in %s", name, first.index, first.file)
+}
+
+func formatPath(path []any) string {
+	var b strings.Builder
+	for _, step := range path {
+		switch s := step.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s)
+		case int:
+			fmt.Fprintf(&b, "[%d]", s)
+		}
+	}
+	return b.String()
+}
+
+func checkURL(raw string, required bool, doc *docNode, errs *ValidationErrors, path ...any) {
+	if raw == "" {
+		if required {
+			errs.add(doc.lineOf(path...), formatPath(path), "is required")
+		}
+		return
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		errs.add(doc.lineOf(path...), formatPath(path), "invalid URL %q, expected http:// or https:// followed by a host", raw)
+	}
+}
+
+func checkMethod(method string, doc *docNode, errs *ValidationErrors, path ...any) {
+	if method != "" && !slices.Contains(_httpMethods, strings.ToUpper(method)) {
+		errs.add(doc.lineOf(path...), formatPath(path), "unknown HTTP method %q, expected one of %s", method, strings.Join(_httpMethods, ", "))
+	}
+}
+
+func checkHeaders(headers []string, doc *docNode, errs *ValidationErrors, path ...any) {
+	for i, header := range headers {
+		name, _, found := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" || strings.ContainsAny(name, " \t\"(),/;<=>?@[\\]{}") {
+			itemPath := append(slices.Clone(path), i)
+			errs.add(doc.lineOf(itemPath...), formatPath(itemPath), "invalid header %q, expected \"Name: value\"", header)
+		}
+	}
+}
+
+func checkRegions(regions []string, doc *docNode, errs *ValidationErrors, path ...any) {
+	for i, region := range regions {
+		if !slices.Contains(SupportedRegions, region) {
+			itemPath := append(slices.Clone(path), i)
+			errs.add(doc.lineOf(itemPath...), formatPath(itemPath), "unknown region %q, expected one of %s", region, strings.Join(SupportedRegions, ", "))
+		}
+	}
+}
+
+func checkLabels(labels map[string]string, doc *docNode, errs *ValidationErrors, path ...any) {
+	for _, name := range sortedKeys(labels) {
+		if err := ValidateLabels(map[string]string{name: ""}); err != nil {
+			labelPath := append(slices.Clone(path), name)
+			errs.add(doc.lineOf(labelPath...), formatPath(path), "%v", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			content: "[global]\nregions = [\"us-east-1\"]\n\n[[targets]]\nurl = \"https://example.com\"\nheaders = [\"Accept: */*\"]\n",
+		},
+		{
+			name:    "unknown key with suggestion",
+			content: "[global]\ntimout = 5\n\n[[targets]]\nurl = \"https://example.com\"\n",
+			want:    []string{`line 2: global: unknown key "timout", did you mean "timeout"?`},
+		},
+		{
+			name:    "unknown table",
+			content: "[[targets]]\nurl = \"https://example.com\"\n\n[alerting]\nenabled = true\n",
+			want:    []string{`line 4: unknown key "alerting"`},
+		},
+		{
+			name:    "wrong types",
+			content: "[[targets]]\nurl = \"https://example.com\"\nrefresh_interval = true\nskip_ssl = 1\nheaders = \"Accept: */*\"\n",
+			want: []string{
+				`line 3: targets[0].refresh_interval: expected a duration such as "5s", got a boolean`,
+				"line 4: targets[0].skip_ssl: expected a boolean, got an integer",
+				"line 5: targets[0].headers: expected an array, got a string",
+			},
+		},
+		{
+			name:    "invalid duration",
+			content: "[[targets]]\nurl = \"https://example.com\"\n\n[logs.loki]\nflush_interval = \"soon\"\n",
+			want:    []string{`line 5: logs.loki.flush_interval: invalid duration "soon"`},
+		},
+		{
+			name:    "refresh interval too short",
+			content: "[global]\nrefresh_interval = \"10ms\"\n\n[[targets]]\nurl = \"https://example.com\"\nrefresh_interval = 0.05\n",
+			want: []string{
+				"line 2: global.refresh_interval: must be at least 100ms",
+				"line 6: targets[0].refresh_interval: must be at least 100ms",
+			},
+		},
+		{
+			name:    "invalid values",
+			content: "[[targets]]\nname = \"API\"\nmethod = \"FETCH\"\nheaders = [\"Accept */*\"]\nregions = [\"us-east-9\"]\n\n[[targets]]\nurl = \"ftp://example.com\"\nname = \"API\"\n",
+			want: []string{
+				"line 1: targets[0].url: is required",
+				`line 3: targets[0].method: unknown HTTP method "FETCH"`,
+				`line 4: targets[0].headers[0]: invalid header "Accept */*"`,
+				`line 5: targets[0].regions[0]: unknown region "us-east-9"`,
+				`line 8: targets[1].url: invalid URL "ftp://example.com"`,
+				`line 9: targets[1].name: duplicate name "API", also used by targets[0]`,
+			},
+		},
+		{
+			name:    "no targets",
+			content: "[global]\ntimeout = 5\n",
+			want:    []string{"line 1: no targets defined"},
+		},
+		{
+			name:    "syntax error",
+			content: "[[targets]]\nurl = \"https://example.com\"\nname = \n",
+			want:    []string{"line 3: "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "updo.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadConfig(path)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig failed: %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Expected %d errors, got %d:\n%v", len(tt.want), len(errs), errs)
+			}
+			for i, want := range tt.want {
+				if got := errs[i].Error(); !strings.HasPrefix(got, want) {
+					t.Errorf("Error %d: expected prefix %q, got %q", i, want, got)
+				}
+			}
+		})
+	}
+}
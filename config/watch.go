@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// _reloadDebounce coalesces the several events editors produce for a
+// single save into one reload.
+const _reloadDebounce = 250 * time.Millisecond
+
+// Watcher requests a reload on C whenever the config file or one of the
+// files it includes or discovers targets from changes, or the process
+// receives SIGHUP.
+type Watcher struct {
+	C <-chan struct{}
+
+	watcher *fsnotify.Watcher
+	signals chan os.Signal
+	done    chan struct{}
+
+	mu       sync.Mutex
+	patterns []string
+	dirs     map[string]bool
+}
+
+// Watch starts watching the config file at path. Its directory is watched
+// rather than the file itself, so that editors replacing the file on save
+// and symlink swaps such as Kubernetes ConfigMap updates are noticed.
+func Watch(path string) (*Watcher, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := fsWatcher.Add(filepath.Dir(absPath)); err != nil {
+		_ = fsWatcher.Close()
+		return nil, err
+	}
+
+	reloads := make(chan struct{}, 1)
+	w := &Watcher{
+		C:       reloads,
+		watcher: fsWatcher,
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+		dirs:    map[string]bool{filepath.Dir(absPath): true},
+	}
+	signal.Notify(w.signals, syscall.SIGHUP)
+
+	go w.run(absPath, reloads)
+	return w, nil
+}
+
+// WatchFiles replaces the glob patterns of the included and discovery
+// files to watch, such as Config.IncludePatterns and those returned by
+// Config.FileSDPatterns. Their directories must exist, files in them are
+// noticed as they are created and removed.
+func (w *Watcher) WatchFiles(patterns []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.patterns = w.patterns[:0]
+	var errs []error
+	for _, pattern := range patterns {
+		absPattern, err := filepath.Abs(pattern)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.patterns = append(w.patterns, absPattern)
+
+		dirs := []string{filepath.Dir(absPattern)}
+		if hasMeta(dirs[0]) {
+			dirs, _ = filepath.Glob(dirs[0])
+		}
+		for _, dir := range dirs {
+			if w.dirs[dir] {
+				continue
+			}
+			if err := w.watcher.Add(dir); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			w.dirs[dir] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// watched reports whether name is one of the included or discovery files.
+func (w *Watcher) watched(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, pattern := range w.patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) run(path string, reloads chan<- struct{}) {
+	realPath, _ := filepath.EvalSymlinks(path)
+	var debounce <-chan time.Time
+
+	notify := func() {
+		select {
+		case reloads <- struct{}{}:
+		default:
+		}
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			currentPath, _ := filepath.EvalSymlinks(path)
+			changed := filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+			// Removing an included or discovery file removes its targets.
+			watched := event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 && w.watched(filepath.Clean(event.Name))
+			if changed || watched || (currentPath != "" && currentPath != realPath) {
+				realPath = currentPath
+				debounce = time.After(_reloadDebounce)
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce:
+			debounce = nil
+			notify()
+		case <-w.signals:
+			notify()
+		}
+	}
+}
+
+// Close stops watching for changes and signals.
+func (w *Watcher) Close() error {
+	signal.Stop(w.signals)
+	close(w.done)
+	return w.watcher.Close()
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updo.toml")
+	if err := os.WriteFile(path, []byte("[[targets]]\nurl = \"https://example.com\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := Watch(path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "other.toml"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watcher.C:
+		t.Fatal("Changes to other files should not request a reload")
+	case <-time.After(2 * _reloadDebounce):
+	}
+
+	// Replace the file the way editors do on save.
+	replacement := path + ".tmp"
+	if err := os.WriteFile(replacement, []byte("[[targets]]\nurl = \"https://example.org\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-watcher.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a reload request")
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml":       "[[file_sd]]\nfiles = [\"sd/*.json\"]\n",
+		"sd/targets.json": "[]",
+	})
+
+	watcher, err := Watch(filepath.Join(dir, "updo.toml"))
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+	if err := watcher.WatchFiles([]string{filepath.Join(dir, "sd", "*.json")}); err != nil {
+		t.Fatalf("WatchFiles failed: %v", err)
+	}
+
+	expectReload := func(reason string) {
+		t.Helper()
+		select {
+		case <-watcher.C:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for a reload request after %s", reason)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"sd/notes.txt": "x"})
+	select {
+	case <-watcher.C:
+		t.Fatal("Files not matching the patterns should not request a reload")
+	case <-time.After(2 * _reloadDebounce):
+	}
+
+	writeFiles(t, dir, map[string]string{"sd/more.json": `[{"targets": ["api:80"]}]`})
+	expectReload("creating a discovery file")
+
+	if err := os.Remove(filepath.Join(dir, "sd", "targets.json")); err != nil {
+		t.Fatal(err)
+	}
+	expectReload("removing a discovery file")
+}
+
+func TestWatchIncludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"updo.toml":           "include = [\"targets.d/*.toml\"]\n",
+		"targets.d/api.toml":  "[[targets]]\nurl = \"https://api.example.com\"\n",
+		"targets.d/notes.txt": "x",
+	})
+	path := filepath.Join(dir, "updo.toml")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if want := []string{filepath.Join(dir, "targets.d", "*.toml")}; !slices.Equal(cfg.IncludePatterns, want) {
+		t.Fatalf("IncludePatterns = %v, want %v", cfg.IncludePatterns, want)
+	}
+
+	watcher, err := Watch(path)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+	if err := watcher.WatchFiles(cfg.IncludePatterns); err != nil {
+		t.Fatalf("WatchFiles failed: %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"targets.d/notes.txt": "y"})
+	select {
+	case <-watcher.C:
+		t.Fatal("Files not matching the patterns should not request a reload")
+	case <-time.After(2 * _reloadDebounce):
+	}
+
+	writeFiles(t, dir, map[string]string{"targets.d/api.toml": "[[targets]]\nurl = \"https://api.example.org\"\n"})
+	select {
+	case <-watcher.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a reload request after editing an included file")
+	}
+}
@@ -0,0 +1,99 @@
+package exporter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
+)
+
+var _shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Curl renders target as a curl command sending the request its checks
+// send, one option per line. Secrets are masked by r unless it is nil.
+func Curl(target config.Target, r *redact.Redactor) string {
+	if r != nil {
+		target = RedactTarget(r, target)
+	}
+
+	method := strings.ToUpper(target.Method)
+	if method == "" {
+		method = "GET"
+	}
+	// curl sends POST whenever it has a body, so any other method must be
+	// given explicitly then.
+	command := "curl"
+	switch {
+	case target.Body != "":
+		if method != "POST" {
+			command += " -X " + method
+		}
+	case method == "HEAD":
+		command += " --head"
+	case method != "GET":
+		command += " -X " + method
+	}
+	args := []string{command + " " + shellQuote(target.URL)}
+
+	userAgent := false
+	for _, header := range target.Headers {
+		name, _, _ := strings.Cut(header, ":")
+		userAgent = userAgent || strings.EqualFold(strings.TrimSpace(name), "User-Agent")
+		args = append(args, "-H "+shellQuote(header))
+	}
+	if !userAgent {
+		args = append(args, "-A "+shellQuote(net.UserAgent))
+	}
+	if target.Body != "" {
+		args = append(args, "--data-raw "+shellQuote(target.Body))
+	}
+
+	var options []string
+	if config.BoolVal(target.FollowRedirects, false) {
+		options = append(options, "-L")
+	}
+	if config.BoolVal(target.SkipSSL, false) {
+		options = append(options, "-k")
+	}
+	if target.Timeout > 0 {
+		options = append(options, "--max-time "+strconv.FormatFloat(target.Timeout.Seconds(), 'f', -1, 64))
+	}
+	if len(options) > 0 {
+		args = append(args, strings.Join(options, " "))
+	}
+
+	return strings.Join(args, " \\\n  ") + "\n"
+}
+
+// RedactTarget masks the secrets of target: sensitive headers and query
+// parameters, and body patterns.
+func RedactTarget(r *redact.Redactor, target config.Target) config.Target {
+	target.URL = r.URL(target.URL)
+	target.WebhookURL = r.URL(target.WebhookURL)
+	target.Body = r.Body(target.Body)
+	target.Headers = redactHeaderLines(r, target.Headers)
+	target.WebhookHeaders = redactHeaderLines(r, target.WebhookHeaders)
+	return target
+}
+
+func redactHeaderLines(r *redact.Redactor, headers []string) []string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make([]string, len(headers))
+	for i, header := range headers {
+		redacted[i] = r.HeaderLine(header)
+	}
+	return redacted
+}
+
+// shellQuote quotes s for POSIX shells, leaving plain words as they are.
+func shellQuote(s string) string {
+	if _shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/importer"
+	"github.com/Owloops/updo/redact"
+)
+
+func TestCurl(t *testing.T) {
+	yes := true
+	tests := []struct {
+		name   string
+		target config.Target
+		want   string
+	}{
+		{
+			name:   "get",
+			target: config.Target{URL: "https://example.com/health"},
+			want:   "curl https://example.com/health \\\n  -A updo/1.0\n",
+		},
+		{
+			name: "post",
+			target: config.Target{
+				URL:             "https://example.com/items?a=1&b=2",
+				Method:          "POST",
+				Headers:         []string{"Content-Type: application/json", "User-Agent: probe"},
+				Body:            `{"name": "it's"}`,
+				FollowRedirects: &yes,
+				SkipSSL:         &yes,
+				Timeout:         1500 * time.Millisecond,
+			},
+			want: `curl 'https://example.com/items?a=1&b=2' \
+  -H 'Content-Type: application/json' \
+  -H 'User-Agent: probe' \
+  --data-raw '{"name": "it'\''s"}' \
+  -L -k --max-time 1.5
+`,
+		},
+		{
+			name:   "head",
+			target: config.Target{URL: "https://example.com", Method: "HEAD"},
+			want:   "curl --head https://example.com \\\n  -A updo/1.0\n",
+		},
+		{
+			name:   "get with body",
+			target: config.Target{URL: "https://example.com", Body: "x=1"},
+			want:   "curl -X GET https://example.com \\\n  -A updo/1.0 \\\n  --data-raw x=1\n",
+		},
+		{
+			name:   "head with body",
+			target: config.Target{URL: "https://example.com", Method: "HEAD", Body: "x=1"},
+			want:   "curl -X HEAD https://example.com \\\n  -A updo/1.0 \\\n  --data-raw x=1\n",
+		},
+		{
+			name:   "delete",
+			target: config.Target{URL: "https://example.com/x", Method: "DELETE", Headers: []string{"User-Agent: probe"}},
+			want:   "curl -X DELETE https://example.com/x \\\n  -H 'User-Agent: probe'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Curl(tt.target, nil); got != tt.want {
+				t.Errorf("Curl() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlRedacted(t *testing.T) {
+	r, err := redact.New(nil, nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := config.Target{URL: "https://example.com/?token=abc", Headers: []string{"Authorization: Bearer abc", "User-Agent: probe"}}
+	want := "curl 'https://example.com/?token=[REDACTED]' \\\n  -H 'Authorization: [REDACTED]' \\\n  -H 'User-Agent: probe'\n"
+	if got := Curl(target, r); got != want {
+		t.Errorf("Curl() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCurlRoundTrip(t *testing.T) {
+	yes := true
+	target := config.Target{
+		URL:             "https://example.com/items",
+		Name:            "PUT example.com/items",
+		Method:          "PUT",
+		Headers:         []string{"Content-Type: text/plain", "User-Agent: probe"},
+		Body:            "line one\nline 'two'",
+		FollowRedirects: &yes,
+		Timeout:         3 * time.Second,
+	}
+
+	imported, err := importer.Curl(Curl(target, nil))
+	if err != nil {
+		t.Fatalf("Importing the exported command failed: %v", err)
+	}
+	if len(imported.Targets) != 1 || !reflect.DeepEqual(imported.Targets[0], target) {
+		t.Errorf("Round trip changed the target:\n%+v\nwant\n%+v", imported.Targets, target)
+	}
+}
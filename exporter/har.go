@@ -0,0 +1,269 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
+)
+
+const (
+	_harVersion         = "1.2"
+	_defaultHTTPVersion = "HTTP/1.1"
+)
+
+// HAR 1.2 document types, see http://www.softwareishard.com/blog/har-12-spec/.
+type (
+	harDocument struct {
+		Log harLog `json:"log"`
+	}
+
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []Entry    `json:"entries"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	// Entry is a HAR 1.2 entry recording one check.
+	Entry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Comment  string `json:"comment,omitempty"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+		SSL     float64 `json:"ssl"`
+	}
+)
+
+// HAREntry records result as a HAR entry, with its request and response
+// headers and bodies and its timings. Secrets are masked by r unless it is
+// nil.
+func HAREntry(result net.WebsiteCheckResult, r *redact.Redactor) Entry {
+	requestHeaders, responseHeaders := result.RequestHeaders, result.ResponseHeaders
+	rawURL, requestBody, responseBody := result.URL, result.RequestBody, result.ResponseBody
+	if r != nil {
+		requestHeaders, responseHeaders = r.Headers(requestHeaders), r.Headers(responseHeaders)
+		rawURL, requestBody, responseBody = r.URL(rawURL), r.Body(requestBody), r.Body(responseBody)
+	}
+
+	method := result.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	httpVersion := result.HTTPVersion
+	if httpVersion == "" {
+		httpVersion = _defaultHTTPVersion
+	}
+
+	entry := Entry{
+		StartedDateTime: result.LastCheckTime.Format(time.RFC3339Nano),
+		Time:            milliseconds(result.ResponseTime),
+		Request: harRequest{
+			Method:      method,
+			URL:         rawURL,
+			HTTPVersion: httpVersion,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(requestHeaders),
+			QueryString: harQueryString(rawURL),
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: harResponse{
+			Status:      result.StatusCode,
+			StatusText:  http.StatusText(result.StatusCode),
+			HTTPVersion: httpVersion,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(responseHeaders),
+			Content: harContent{
+				Size:     len(responseBody),
+				MimeType: http.Header(responseHeaders).Get("Content-Type"),
+				Text:     responseBody,
+			},
+			RedirectURL: http.Header(responseHeaders).Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(responseBody),
+		},
+		Timings:         harTimingsOf(result),
+		ServerIPAddress: result.ResolvedIP,
+		Comment:         checkOutcome(result),
+	}
+	if requestBody != "" {
+		entry.Request.PostData = &harPostData{
+			MimeType: http.Header(requestHeaders).Get("Content-Type"),
+			Text:     requestBody,
+		}
+	}
+	if result.TraceInfo != nil {
+		entry.Time = 0
+		for _, phase := range []float64{entry.Timings.Blocked, entry.Timings.DNS, entry.Timings.Connect, entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+			entry.Time += max(phase, 0)
+		}
+	}
+	if result.ResponseTruncated {
+		entry.Response.Content.Comment = "body truncated at the body size limit"
+	}
+	if result.StatusCode == 0 {
+		entry.Response.HTTPVersion = ""
+		entry.Response.BodySize = -1
+	}
+	return entry
+}
+
+// HAR writes entries as a HAR 1.2 document.
+func HAR(entries ...Entry) ([]byte, error) {
+	doc := harDocument{Log: harLog{
+		Version: _harVersion,
+		Creator: harCreator{Name: "updo", Version: strings.TrimPrefix(net.UserAgent, "updo/")},
+		Entries: entries,
+	}}
+	if doc.Log.Entries == nil {
+		doc.Log.Entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func harHeaders(headers http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		for _, value := range headers[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+func harQueryString(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		pairs = append(pairs, harNameValue{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// harTimingsOf maps the trace of result to HAR timings, which are -1 for
+// the phases that did not happen or were not traced. Response times only
+// run until the headers arrive, so the body download comes on top.
+func harTimingsOf(result net.WebsiteCheckResult) harTimings {
+	timings := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: milliseconds(result.ResponseTime)}
+	trace := result.TraceInfo
+	if trace == nil {
+		return timings
+	}
+
+	optional := func(d time.Duration) float64 {
+		if d <= 0 || d > result.ResponseTime {
+			return -1
+		}
+		return milliseconds(d)
+	}
+	required := func(d time.Duration) float64 {
+		return milliseconds(max(d, 0))
+	}
+	timings.Blocked = optional(trace.Wait)
+	timings.DNS = optional(trace.DNSLookup)
+	timings.Connect = optional(trace.TCPConnection)
+	timings.SSL = optional(trace.TLSHandshake)
+	timings.Wait = required(trace.TimeToFirstByte)
+	timings.Receive = required(trace.DownloadDuration)
+	return timings
+}
+
+func checkOutcome(result net.WebsiteCheckResult) string {
+	switch {
+	case result.IsUp:
+		return "check succeeded"
+	case result.StatusCode == 0:
+		return "check failed: request failed"
+	case result.AssertText != "" && !result.AssertionPassed:
+		return fmt.Sprintf("check failed: response does not contain %q", result.AssertText)
+	default:
+		return fmt.Sprintf("check failed: status %d", result.StatusCode)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
+)
+
+func TestHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	result := net.CheckWebsite(server.URL+"/items?page=2&token=abc", net.NetworkConfig{
+		Timeout: 5 * time.Second,
+		Method:  "POST",
+		Headers: []string{"Content-Type: application/json", "Authorization: Bearer abc"},
+		Body:    `{"name":"a"}`,
+	})
+	r, err := redact.New(nil, nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := HAR(HAREntry(result, r))
+	if err != nil {
+		t.Fatalf("HAR failed: %v", err)
+	}
+
+	var doc struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				StartedDateTime string  `json:"startedDateTime"`
+				Time            float64 `json:"time"`
+				Request         struct {
+					Method      string         `json:"method"`
+					URL         string         `json:"url"`
+					Headers     []harNameValue `json:"headers"`
+					QueryString []harNameValue `json:"queryString"`
+					PostData    harPostData    `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status      int            `json:"status"`
+					StatusText  string         `json:"statusText"`
+					HTTPVersion string         `json:"httpVersion"`
+					Headers     []harNameValue `json:"headers"`
+					Content     harContent     `json:"content"`
+				} `json:"response"`
+				Timings map[string]float64 `json:"timings"`
+				Comment string             `json:"comment"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("HAR output is not valid JSON: %v\n%s", err, data)
+	}
+
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 1 {
+		t.Fatalf("Expected a HAR 1.2 log with one entry, got:\n%s", data)
+	}
+	entry := doc.Log.Entries[0]
+	if _, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err != nil {
+		t.Errorf("Invalid startedDateTime %q", entry.StartedDateTime)
+	}
+	if entry.Request.Method != "POST" || entry.Request.URL != server.URL+"/items?page=2&token=[REDACTED]" {
+		t.Errorf("Unexpected request %s %s", entry.Request.Method, entry.Request.URL)
+	}
+	if entry.Request.PostData.MimeType != "application/json" || entry.Request.PostData.Text != `{"name":"a"}` {
+		t.Errorf("Unexpected postData %+v", entry.Request.PostData)
+	}
+	if header := find(entry.Request.Headers, "Authorization"); header != redact.Mask {
+		t.Errorf("Expected the Authorization header to be masked, got %q", header)
+	}
+	if page := find(entry.Request.QueryString, "page"); page != "2" {
+		t.Errorf("Expected the page query parameter, got %q", page)
+	}
+	if entry.Response.Status != 201 || entry.Response.StatusText != "Created" || entry.Response.HTTPVersion != "HTTP/1.1" {
+		t.Errorf("Unexpected response status %d %q %s", entry.Response.Status, entry.Response.StatusText, entry.Response.HTTPVersion)
+	}
+	if cookie := find(entry.Response.Headers, "Set-Cookie"); cookie != redact.Mask {
+		t.Errorf("Expected the Set-Cookie header to be masked, got %q", cookie)
+	}
+	if entry.Response.Content.Text != `{"ok":true}` || entry.Response.Content.MimeType != "application/json" || entry.Response.Content.Size != 11 {
+		t.Errorf("Unexpected content %+v", entry.Response.Content)
+	}
+	for _, phase := range []string{"blocked", "dns", "connect", "send", "wait", "receive"} {
+		if _, exists := entry.Timings[phase]; !exists {
+			t.Errorf("Missing required timing %s", phase)
+		}
+	}
+	if entry.Timings["wait"] < 0 || entry.Timings["receive"] < 0 || entry.Time <= 0 {
+		t.Errorf("Unexpected timings %v, time %v", entry.Timings, entry.Time)
+	}
+	if entry.Comment != "check succeeded" {
+		t.Errorf("Unexpected comment %q", entry.Comment)
+	}
+}
+
+func TestHAREntryFailedRequest(t *testing.T) {
+	entry := HAREntry(net.WebsiteCheckResult{URL: "https://unreachable.invalid", LastCheckTime: time.Now()}, nil)
+	if entry.Response.Status != 0 || entry.Response.BodySize != -1 || entry.Comment != "check failed: request failed" {
+		t.Errorf("Unexpected entry for a failed request: %+v", entry)
+	}
+	if entry.Timings.DNS != -1 || entry.Timings.Send != 0 {
+		t.Errorf("Expected untraced timings, got %+v", entry.Timings)
+	}
+}
+
+func find(pairs []harNameValue, name string) string {
+	for _, pair := range pairs {
+		if pair.Name == name {
+			return pair.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,457 @@
+package importer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Owloops/updo/config"
+)
+
+// _curlIgnored are curl options that do not change the request updo would
+// send, mapped to whether they take an argument.
+var _curlIgnored = map[string]bool{
+	"-s": false, "--silent": false, "-S": false, "--show-error": false,
+	"-v": false, "--verbose": false, "-i": false, "--include": false,
+	"-f": false, "--fail": false, "--fail-with-body": false,
+	"--compressed": false, "-g": false, "--globoff": false,
+	"-N": false, "--no-buffer": false, "-#": false, "--progress-bar": false,
+	"--http1.0": false, "--http1.1": false, "--http2": false, "--http2-prior-knowledge": false, "--http3": false,
+	"-4": false, "--ipv4": false, "-6": false, "--ipv6": false,
+	"-o": true, "--output": true, "-w": true, "--write-out": true,
+	"-D": true, "--dump-header": true, "-c": true, "--cookie-jar": true,
+	"-x": true, "--proxy": true, "--connect-timeout": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true,
+	"--max-redirs": true, "--resolve": true, "--cacert": true,
+}
+
+// _curlArgs are the curl options read by Curl that take an argument.
+var _curlArgs = map[string]bool{
+	"-X": true, "--request": true, "-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true,
+	"--data-urlencode": true, "--json": true, "-u": true, "--user": true,
+	"-A": true, "--user-agent": true, "-e": true, "--referer": true,
+	"-b": true, "--cookie": true, "-m": true, "--max-time": true, "--url": true,
+}
+
+// Curl reads the targets of one or more curl command lines, as copied
+// from a browser or API documentation. Commands may span lines ending in a
+// backslash and are separated by new lines or semicolons.
+func Curl(input string) (Result, error) {
+	commands, err := splitCommands(input)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(commands) == 0 {
+		return Result{}, fmt.Errorf("no curl command found")
+	}
+
+	var result Result
+	for i, args := range commands {
+		if name := path.Base(args[0]); name != "curl" && name != "curl.exe" {
+			return Result{}, fmt.Errorf("command %d: expected a curl command, got %q", i+1, args[0])
+		}
+		targets, err := curlArgs(args[1:])
+		if err != nil {
+			if len(commands) > 1 {
+				return Result{}, fmt.Errorf("command %d: %w", i+1, err)
+			}
+			return Result{}, err
+		}
+		result.Targets = append(result.Targets, targets...)
+	}
+	result.Targets = finish(result.Targets)
+	return result, nil
+}
+
+// CurlArgs reads the targets of the arguments of a curl command, one per
+// URL it requests.
+func CurlArgs(args []string) ([]config.Target, error) {
+	targets, err := curlArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return finish(targets), nil
+}
+
+func curlArgs(args []string) ([]config.Target, error) {
+	var target config.Target
+	var urls, data []string
+	var method string
+	var head, get, jsonBody bool
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			urls = append(urls, arg)
+			continue
+		}
+
+		name, value, hasValue := arg, "", false
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue = strings.Cut(arg, "=")
+			if hasValue && !_curlArgs[name] && !_curlIgnored[name] {
+				return nil, fmt.Errorf("unsupported curl option %q", arg)
+			}
+		case len(arg) > 2:
+			// Short options may be grouped, as in -sSL, with the argument
+			// of the last one attached, as in -XPOST.
+			flags, rest := arg[1:], ""
+			for j := 0; j < len(flags); j++ {
+				flag := "-" + flags[j:j+1]
+				if _curlArgs[flag] || _curlIgnored[flag] {
+					name, rest = flag, flags[j+1:]
+					break
+				}
+				if err := curlSwitch(flag, &target, &head, &get); err != nil {
+					return nil, err
+				}
+				name = ""
+			}
+			if name == "" {
+				continue
+			}
+			if rest != "" {
+				value, hasValue = rest, true
+			}
+		}
+
+		takesArg, known := _curlArgs[name]
+		if ignoredArg, ignored := _curlIgnored[name]; ignored {
+			takesArg, known = ignoredArg, true
+		}
+		if !known {
+			if err := curlSwitch(name, &target, &head, &get); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if takesArg && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("curl option %s needs an argument", name)
+			}
+			i++
+			value = args[i]
+		}
+		if _, ignored := _curlIgnored[name]; ignored {
+			continue
+		}
+
+		switch name {
+		case "-X", "--request":
+			method = strings.ToUpper(value)
+		case "-H", "--header":
+			if header, ok := curlHeader(value); ok {
+				target.Headers = setHeader(target.Headers, header, true)
+			}
+		case "-d", "--data", "--data-ascii", "--data-binary", "--json":
+			if strings.HasPrefix(value, "@") {
+				return nil, fmt.Errorf("reading request data from %s is not supported", value[1:])
+			}
+			data = append(data, value)
+			jsonBody = jsonBody || name == "--json"
+		case "--data-raw":
+			data = append(data, value)
+		case "--data-urlencode":
+			data = append(data, urlEncodeData(value))
+		case "-u", "--user":
+			credentials := base64.StdEncoding.EncodeToString([]byte(value))
+			target.Headers = setHeader(target.Headers, "Authorization: Basic "+credentials, true)
+		case "-A", "--user-agent":
+			target.Headers = setHeader(target.Headers, "User-Agent: "+value, true)
+		case "-e", "--referer":
+			target.Headers = setHeader(target.Headers, "Referer: "+value, true)
+		case "-b", "--cookie":
+			if !strings.Contains(value, "=") {
+				return nil, fmt.Errorf("reading cookies from %s is not supported", value)
+			}
+			target.Headers = setHeader(target.Headers, "Cookie: "+value, true)
+		case "-m", "--max-time":
+			timeout, err := strconv.ParseFloat(value, 64)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid --max-time %q", value)
+			}
+			target.Timeout = time.Duration(timeout * float64(time.Second))
+		case "--url":
+			urls = append(urls, value)
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("curl command has no URL")
+	}
+
+	body := strings.Join(data, "&")
+	if jsonBody {
+		target.Headers = setHeader(target.Headers, "Content-Type: application/json", false)
+		target.Headers = setHeader(target.Headers, "Accept: application/json", false)
+	}
+	switch {
+	case get:
+		if method == "" {
+			method = "GET"
+		}
+	case head:
+		if method == "" {
+			method = "HEAD"
+		}
+	case len(data) > 0:
+		if method == "" {
+			method = "POST"
+		}
+		target.Body = body
+		if !jsonBody {
+			target.Headers = setHeader(target.Headers, "Content-Type: application/x-www-form-urlencoded", false)
+		}
+	}
+	if method == "" {
+		method = "GET"
+	}
+	// Unlike updo, curl only follows redirects when told to with -L.
+	if target.FollowRedirects == nil {
+		follow := false
+		target.FollowRedirects = &follow
+	}
+
+	targets := make([]config.Target, 0, len(urls))
+	for _, rawURL := range urls {
+		if !strings.Contains(rawURL, "://") {
+			rawURL = "http://" + rawURL
+		}
+		if get && body != "" {
+			separator := "?"
+			if strings.Contains(rawURL, "?") {
+				separator = "&"
+			}
+			rawURL += separator + body
+		}
+
+		t := target
+		t.URL = rawURL
+		t.Name = requestName(method, rawURL)
+		t.Headers = append([]string(nil), target.Headers...)
+		if method != "GET" {
+			t.Method = method
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// curlSwitch applies a curl option that takes no argument.
+func curlSwitch(name string, target *config.Target, head, get *bool) error {
+	switch name {
+	case "-k", "--insecure":
+		skip := true
+		target.SkipSSL = &skip
+	case "-L", "--location":
+		follow := true
+		target.FollowRedirects = &follow
+	case "-I", "--head":
+		*head = true
+	case "-G", "--get":
+		*get = true
+	default:
+		if _, ignored := _curlIgnored[name]; !ignored {
+			return fmt.Errorf("unsupported curl option %q", name)
+		}
+	}
+	return nil
+}
+
+// curlHeader normalizes the argument of -H. Headers curl would remove,
+// given as "Name:" with no value, are dropped.
+func curlHeader(value string) (string, bool) {
+	name, headerValue, found := strings.Cut(value, ":")
+	name, headerValue = strings.TrimSpace(name), strings.TrimSpace(headerValue)
+	if !found || name == "" || headerValue == "" {
+		return "", false
+	}
+	return name + ": " + headerValue, true
+}
+
+// setHeader sets header in headers, replacing any header of the same name
+// when replace is true and otherwise keeping it.
+func setHeader(headers []string, header string, replace bool) []string {
+	name, _, _ := strings.Cut(header, ":")
+	for i, existing := range headers {
+		existingName, _, _ := strings.Cut(existing, ":")
+		if strings.EqualFold(existingName, name) {
+			if replace {
+				headers[i] = header
+			}
+			return headers
+		}
+	}
+	return append(headers, header)
+}
+
+// urlEncodeData encodes the argument of --data-urlencode, either content,
+// =content or name=content.
+func urlEncodeData(value string) string {
+	name, content, found := strings.Cut(value, "=")
+	if !found {
+		return url.QueryEscape(value)
+	}
+	if name == "" {
+		return url.QueryEscape(content)
+	}
+	return name + "=" + url.QueryEscape(content)
+}
+
+// splitCommands splits shell command lines into their arguments following
+// POSIX shell quoting, including the $'...' quoting browsers use when
+// copying requests as curl. Commands end at new lines, ";", "&&" or "|",
+// and anything piped to is dropped.
+func splitCommands(input string) ([][]string, error) {
+	var commands [][]string
+	var args []string
+	var word strings.Builder
+	inWord, piped := false, false
+
+	endWord := func() {
+		if inWord && !piped {
+			args = append(args, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		endWord()
+		if len(args) > 0 {
+			commands = append(commands, args)
+		}
+		args = nil
+		piped = false
+	}
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(input) && input[i+1] == '\n' {
+				i++
+				continue
+			}
+			if i+2 < len(input) && input[i+1] == '\r' && input[i+2] == '\n' {
+				i += 2
+				continue
+			}
+			if i+1 < len(input) {
+				i++
+				word.WriteByte(input[i])
+			}
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(input[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated ' quote")
+			}
+			word.WriteString(input[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			closed := false
+			for i++; i < len(input); i++ {
+				if input[i] == '"' {
+					closed = true
+					break
+				}
+				if input[i] == '\\' && i+1 < len(input) && strings.IndexByte("\"\\$`\n", input[i+1]) >= 0 {
+					i++
+					if input[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(input[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf(`unterminated " quote`)
+			}
+			inWord = true
+		case c == '$' && i+1 < len(input) && input[i+1] == '\'':
+			n, err := ansiCQuote(input[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inWord = true
+		case c == '#' && !inWord:
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			endCommand()
+		case c == '\n' || c == ';':
+			endCommand()
+		case c == '&' && i+1 < len(input) && input[i+1] == '&':
+			i++
+			endCommand()
+		case c == '|':
+			endWord()
+			piped = true
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+// ansiCQuote decodes the body of a $'...' string from s into word and
+// returns the number of bytes read, including the closing quote.
+func ansiCQuote(s string, word *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return i + 1, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			word.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			word.WriteByte('\n')
+		case 't':
+			word.WriteByte('\t')
+		case 'r':
+			word.WriteByte('\r')
+		case '\\', '\'', '"', '?':
+			word.WriteByte(s[i])
+		case 'x', 'u', 'U':
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[i]]
+			end := i + 1
+			for end < len(s) && end < i+1+digits && isHexDigit(s[end]) {
+				end++
+			}
+			code, err := strconv.ParseUint(s[i+1:end], 16, 32)
+			if err != nil {
+				return 0, fmt.Errorf(`invalid escape \%s in $'...' string`, s[i:end])
+			}
+			if s[i] == 'x' {
+				word.WriteByte(byte(code))
+			} else {
+				word.WriteString(string(rune(code)))
+			}
+			i = end - 1
+		default:
+			word.WriteByte('\\')
+			word.WriteByte(s[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated $' quote")
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+)
+
+func TestCurl(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		input   string
+		want    []config.Target
+		wantErr string
+	}{
+		{
+			name:  "get",
+			input: "curl https://example.com/health",
+			want:  []config.Target{{URL: "https://example.com/health", Name: "example.com/health", FollowRedirects: &no}},
+		},
+		{
+			name: "browser copy",
+			input: `curl 'https://api.example.com/v1/users?page=2' \
+  -H 'accept: application/json' \
+  -H $'x-note: it\'s ${HOME}' \
+  --compressed -sSLk`,
+			want: []config.Target{{
+				URL:             "https://api.example.com/v1/users?page=2",
+				Name:            "api.example.com/v1/users",
+				Headers:         []string{"accept: application/json", "x-note: it's $${HOME}"},
+				FollowRedirects: &yes,
+				SkipSSL:         &yes,
+			}},
+		},
+		{
+			name:  "json body",
+			input: `curl -XPOST --json '{"user": "a"}' -m 2.5 "https://example.com/login"`,
+			want: []config.Target{{
+				URL:             "https://example.com/login",
+				Name:            "POST example.com/login",
+				Method:          "POST",
+				Body:            `{"user": "a"}`,
+				Headers:         []string{"Content-Type: application/json", "Accept: application/json"},
+				Timeout:         2500 * time.Millisecond,
+				FollowRedirects: &no,
+			}},
+		},
+		{
+			name:  "form data and basic auth",
+			input: "curl -d a=1 --data-urlencode 'q=x y' -u bob:secret example.com/search",
+			want: []config.Target{{
+				URL:             "http://example.com/search",
+				Name:            "POST example.com/search",
+				Method:          "POST",
+				Body:            "a=1&q=x+y",
+				Headers:         []string{"Authorization: Basic Ym9iOnNlY3JldA==", "Content-Type: application/x-www-form-urlencoded"},
+				FollowRedirects: &no,
+			}},
+		},
+		{
+			name:  "redirects followed only with -L",
+			input: "curl -sS https://example.com/old; curl --location https://example.com/new",
+			want: []config.Target{
+				{URL: "https://example.com/old", Name: "example.com/old", FollowRedirects: &no},
+				{URL: "https://example.com/new", Name: "example.com/new", FollowRedirects: &yes},
+			},
+		},
+		{
+			name:  "get with data",
+			input: "curl -G -d a=1 https://example.com/search?b=2",
+			want:  []config.Target{{URL: "https://example.com/search?b=2&a=1", Name: "example.com/search", FollowRedirects: &no}},
+		},
+		{
+			name:  "several commands",
+			input: "curl -I https://example.com; curl https://example.com && curl -X delete https://example.com/x | jq .\n# done\n",
+			want: []config.Target{
+				{URL: "https://example.com", Name: "HEAD example.com", Method: "HEAD", FollowRedirects: &no},
+				{URL: "https://example.com", Name: "example.com", FollowRedirects: &no},
+				{URL: "https://example.com/x", Name: "DELETE example.com/x", Method: "DELETE", FollowRedirects: &no},
+			},
+		},
+		{
+			name:  "duplicate names",
+			input: "curl https://example.com/a https://example.com/a",
+			want: []config.Target{
+				{URL: "https://example.com/a", Name: "example.com/a", FollowRedirects: &no},
+				{URL: "https://example.com/a", Name: "example.com/a (2)", FollowRedirects: &no},
+			},
+		},
+		{
+			name:    "no redirect options",
+			input:   "curl --location=no https://example.com",
+			wantErr: `unsupported curl option "--location=no"`,
+		},
+		{name: "not curl", input: "wget https://example.com", wantErr: `expected a curl command, got "wget"`},
+		{name: "no url", input: "curl -s", wantErr: "curl command has no URL"},
+		{name: "data file", input: "curl -d @body.json https://example.com", wantErr: "reading request data from body.json is not supported"},
+		{name: "unsupported option", input: "curl -F a=@file https://example.com", wantErr: `unsupported curl option "-F"`},
+		{name: "unterminated quote", input: "curl 'https://example.com", wantErr: "unterminated ' quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Curl(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Curl failed: %v", err)
+			}
+			if !reflect.DeepEqual(got.Targets, tt.want) {
+				t.Errorf("Curl() =\n%+v\nwant\n%+v", got.Targets, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+)
+
+// _harSkippedHeaders are request headers browsers manage themselves, which
+// updo sets on its own or which only make sense within the recorded
+// connection.
+var _harSkippedHeaders = map[string]bool{
+	"host": true, "content-length": true, "connection": true, "keep-alive": true,
+	"accept-encoding": true, "transfer-encoding": true, "upgrade": true,
+	"te": true, "priority": true, "upgrade-insecure-requests": true,
+}
+
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method   string         `json:"method"`
+		URL      string         `json:"url"`
+		Headers  []harNameValue `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status int `json:"status"`
+	} `json:"response"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HAR reads the targets of the requests recorded in a HAR file, such as a
+// browser's network log export. Requests repeated with the same method,
+// URL and body become a single target, and the recorded response status is
+// what the check expects.
+func HAR(data []byte) (Result, error) {
+	var file harFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Result{}, fmt.Errorf("invalid HAR file: %w", err)
+	}
+	if len(file.Log.Entries) == 0 {
+		return Result{}, fmt.Errorf("HAR file has no entries")
+	}
+
+	var result Result
+	seen := make(map[string]bool)
+	for i, entry := range file.Log.Entries {
+		request := entry.Request
+		if !strings.HasPrefix(request.URL, "http://") && !strings.HasPrefix(request.URL, "https://") {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("entry %d: %s is not an HTTP URL", i, truncate(request.URL)))
+			continue
+		}
+
+		method := strings.ToUpper(request.Method)
+		if method == "" {
+			method = "GET"
+		}
+		var body string
+		if request.PostData != nil {
+			body = request.PostData.Text
+		}
+		key := method + " " + request.URL + "\n" + body
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		target := config.Target{
+			URL:  request.URL,
+			Name: requestName(method, request.URL),
+			Body: body,
+		}
+		if method != "GET" {
+			target.Method = method
+		}
+		for _, header := range request.Headers {
+			name := strings.TrimSpace(header.Name)
+			lower := strings.ToLower(name)
+			if name == "" || strings.HasPrefix(name, ":") || _harSkippedHeaders[lower] || strings.HasPrefix(lower, "sec-") {
+				continue
+			}
+			target.Headers = setHeader(target.Headers, name+": "+header.Value, true)
+		}
+		if request.PostData != nil && request.PostData.MimeType != "" {
+			target.Headers = setHeader(target.Headers, "Content-Type: "+request.PostData.MimeType, false)
+		}
+		expectStatus(&target, entry.Response.Status)
+
+		result.Targets = append(result.Targets, target)
+	}
+	result.Targets = finish(result.Targets)
+	return result, nil
+}
+
+// truncate shortens s, such as a data: URL, for messages.
+func truncate(s string) string {
+	const limit = 60
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Owloops/updo/config"
+)
+
+func TestHAR(t *testing.T) {
+	data := `{"log": {"version": "1.2", "entries": [
+  {"request": {"method": "GET", "url": "https://example.com/", "headers": [
+    {"name": ":authority", "value": "example.com"},
+    {"name": "Accept", "value": "text/html"},
+    {"name": "Accept-Encoding", "value": "gzip"},
+    {"name": "sec-fetch-mode", "value": "navigate"}
+  ]}, "response": {"status": 200}},
+  {"request": {"method": "GET", "url": "https://example.com/", "headers": []}, "response": {"status": 200}},
+  {"request": {"method": "POST", "url": "https://example.com/api", "headers": [
+    {"name": "Authorization", "value": "Bearer abc"}
+  ], "postData": {"mimeType": "application/json", "text": "{\"a\":1}"}}, "response": {"status": 201}},
+  {"request": {"method": "GET", "url": "https://example.com/old", "headers": []}, "response": {"status": 301}},
+  {"request": {"method": "GET", "url": "https://example.com/missing", "headers": []}, "response": {"status": 404}},
+  {"request": {"method": "GET", "url": "data:image/png;base64,AAAA", "headers": []}, "response": {"status": 200}}
+]}}`
+
+	got, err := HAR([]byte(data))
+	if err != nil {
+		t.Fatalf("HAR failed: %v", err)
+	}
+
+	yes, no := true, false
+	want := []config.Target{
+		{URL: "https://example.com/", Name: "example.com", Headers: []string{"Accept: text/html"}},
+		{
+			URL:     "https://example.com/api",
+			Name:    "POST example.com/api",
+			Method:  "POST",
+			Body:    `{"a":1}`,
+			Headers: []string{"Authorization: Bearer abc", "Content-Type: application/json"},
+		},
+		{URL: "https://example.com/old", Name: "example.com/old", AcceptRedirects: &yes, FollowRedirects: &no},
+		{URL: "https://example.com/missing", Name: "example.com/missing", ShouldFail: true},
+	}
+	if !reflect.DeepEqual(got.Targets, want) {
+		t.Errorf("HAR() =\n%+v\nwant\n%+v", got.Targets, want)
+	}
+	if len(got.Skipped) != 1 {
+		t.Errorf("Expected the data: URL to be skipped, got %v", got.Skipped)
+	}
+
+	if _, err := HAR([]byte(`{"log": {"entries": []}}`)); err == nil {
+		t.Error("Expected an error for a HAR file without entries")
+	}
+}
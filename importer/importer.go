@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+)
+
+const (
+	FormatCurl    = "curl"
+	FormatHAR     = "har"
+	FormatOpenAPI = "openapi"
+)
+
+// Result holds the targets read from an import source.
+type Result struct {
+	Targets []config.Target
+	// Skipped describes the requests that could not be turned into
+	// targets, and why.
+	Skipped []string
+}
+
+// Options tune how an import source is read.
+type Options struct {
+	// BaseURL replaces the server URL of OpenAPI documents.
+	BaseURL string
+}
+
+// Import reads the targets of data, a curl command line, a HAR file or an
+// OpenAPI 3 document. An empty format is detected from the content.
+func Import(data []byte, format string, opts Options) (Result, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+
+	switch format {
+	case FormatCurl:
+		return Curl(string(data))
+	case FormatHAR:
+		return HAR(data)
+	case FormatOpenAPI:
+		return OpenAPI(data, opts.BaseURL)
+	}
+	return Result{}, fmt.Errorf("unknown import format %q, expected curl, har or openapi", format)
+}
+
+// ParseFormat validates a format name given on the command line.
+func ParseFormat(name string) (string, error) {
+	switch format := strings.ToLower(name); format {
+	case FormatCurl, FormatHAR, FormatOpenAPI:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown import format %q, expected curl, har or openapi", name)
+}
+
+// Detect guesses the format of data: HAR files are JSON documents with a
+// "log" object, OpenAPI documents have a top-level "openapi" key, and
+// anything else is read as curl commands.
+func Detect(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var probe map[string]json.RawMessage
+		if json.Unmarshal(trimmed, &probe) == nil {
+			if _, ok := probe["log"]; ok {
+				return FormatHAR
+			}
+			if _, ok := probe["openapi"]; ok {
+				return FormatOpenAPI
+			}
+			if _, ok := probe["swagger"]; ok {
+				return FormatOpenAPI
+			}
+		}
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		if strings.HasPrefix(line, "openapi:") || strings.HasPrefix(line, "swagger:") {
+			return FormatOpenAPI
+		}
+	}
+	return FormatCurl
+}
+
+// expectStatus sets the options under which a check of target succeeds
+// when the server answers with status, as it did when the request was
+// recorded or documented.
+func expectStatus(target *config.Target, status int) {
+	switch {
+	case status >= 300 && status < 400:
+		accept, follow := true, false
+		target.AcceptRedirects = &accept
+		target.FollowRedirects = &follow
+	case status >= 400:
+		target.ShouldFail = true
+	}
+}
+
+// requestName names a target after its method and URL, leaving out the
+// method for GET requests.
+func requestName(method, rawURL string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		name = u.Host + strings.TrimSuffix(u.Path, "/")
+	}
+	if method != "" && method != "GET" {
+		name = method + " " + name
+	}
+	return name
+}
+
+// finish makes target names unique and escapes the "${" sequences that
+// config files would otherwise interpolate.
+func finish(targets []config.Target) []config.Target {
+	used := make(map[string]int, len(targets))
+	for i := range targets {
+		target := &targets[i]
+		if target.Name != "" {
+			used[target.Name]++
+			if n := used[target.Name]; n > 1 {
+				target.Name = fmt.Sprintf("%s (%d)", target.Name, n)
+			}
+		}
+
+		target.Name = escape(target.Name)
+		target.URL = escape(target.URL)
+		target.Body = escape(target.Body)
+		for j, header := range target.Headers {
+			target.Headers[j] = escape(header)
+		}
+	}
+	return targets
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(s, "${", "$${")
+}
@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Owloops/updo/config"
+)
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		`{"log": {"entries": []}}`:           FormatHAR,
+		`{"openapi": "3.0.0", "paths": {}}`:  FormatOpenAPI,
+		"openapi: 3.0.0\npaths: {}\n":        FormatOpenAPI,
+		"# health\ncurl https://example.com": FormatCurl,
+		`{"url": "https://example.com"}`:     FormatCurl,
+	}
+	for input, want := range tests {
+		if got := Detect([]byte(input)); got != want {
+			t.Errorf("Detect(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestImportWritesValidConfig(t *testing.T) {
+	input := `curl -X PUT 'https://example.com/items/${id}' -H 'Authorization: Bearer ${TOKEN}' -d '{"price": "$5"}'
+curl -k --max-time 0.5 https://example.com/health`
+	result, err := Import([]byte(input), "", Options{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	for _, format := range []string{config.FormatTOML, config.FormatYAML, config.FormatJSON} {
+		data, err := config.Marshal(struct {
+			Targets []config.Target `mapstructure:"targets"`
+		}{result.Targets}, format)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "updo."+format)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: imported config is not valid: %v\n%s", format, err, data)
+		}
+		target := cfg.Targets[0]
+		if target.URL != "https://example.com/items/${id}" || target.Headers[0] != "Authorization: Bearer ${TOKEN}" {
+			t.Errorf("%s: expected ${...} to be kept literally, got %s and %v", format, target.URL, target.Headers)
+		}
+		if cfg.Targets[1].Timeout.Milliseconds() != 500 {
+			t.Errorf("%s: expected a 500ms timeout, got %v", format, cfg.Targets[1].Timeout)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+	"gopkg.in/yaml.v3"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string          `yaml:"openapi"`
+	Swagger    string          `yaml:"swagger"`
+	Servers    []openAPIServer `yaml:"servers"`
+	Paths      yaml.Node       `yaml:"paths"`
+	Components struct {
+		Parameters map[string]openAPIParameter `yaml:"parameters"`
+	} `yaml:"components"`
+}
+
+type openAPIServer struct {
+	URL       string `yaml:"url"`
+	Variables map[string]struct {
+		Default string `yaml:"default"`
+	} `yaml:"variables"`
+}
+
+type openAPIPathItem struct {
+	Servers    []openAPIServer    `yaml:"servers"`
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation  `yaml:"get"`
+}
+
+type openAPIOperation struct {
+	OperationID string             `yaml:"operationId"`
+	Summary     string             `yaml:"summary"`
+	Servers     []openAPIServer    `yaml:"servers"`
+	Parameters  []openAPIParameter `yaml:"parameters"`
+	Responses   map[string]any     `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Ref      string    `yaml:"$ref"`
+	Name     string    `yaml:"name"`
+	In       string    `yaml:"in"`
+	Required bool      `yaml:"required"`
+	Example  yaml.Node `yaml:"example"`
+	Examples map[string]struct {
+		Value yaml.Node `yaml:"value"`
+	} `yaml:"examples"`
+	Schema struct {
+		Example  yaml.Node   `yaml:"example"`
+		Examples []yaml.Node `yaml:"examples"`
+		Default  yaml.Node   `yaml:"default"`
+		Enum     []yaml.Node `yaml:"enum"`
+	} `yaml:"schema"`
+}
+
+// OpenAPI reads a target for every GET operation of an OpenAPI 3 document,
+// in JSON or YAML, filling in path, query and header parameters from their
+// examples. Operations whose required parameters have no example are
+// skipped. baseURL, when set, replaces the servers of the document.
+func OpenAPI(data []byte, baseURL string) (Result, error) {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Result{}, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		if doc.Swagger != "" {
+			return Result{}, fmt.Errorf("swagger %s documents are not supported, convert them to OpenAPI 3 first", doc.Swagger)
+		}
+		return Result{}, fmt.Errorf("not an OpenAPI 3 document")
+	}
+	if doc.Paths.Kind != yaml.MappingNode {
+		return Result{}, fmt.Errorf("OpenAPI document has no paths")
+	}
+
+	var result Result
+	for i := 0; i+1 < len(doc.Paths.Content); i += 2 {
+		path := doc.Paths.Content[i].Value
+		var item openAPIPathItem
+		if err := doc.Paths.Content[i+1].Decode(&item); err != nil {
+			return Result{}, fmt.Errorf("paths.%s: %w", path, err)
+		}
+		operation := item.Get
+		if operation == nil {
+			continue
+		}
+
+		server := baseURL
+		if server == "" {
+			server = serverURL(operation.Servers, item.Servers, doc.Servers)
+		}
+		if !strings.HasPrefix(server, "http://") && !strings.HasPrefix(server, "https://") {
+			return Result{}, fmt.Errorf("GET %s: no absolute server URL, set one with --base-url", path)
+		}
+
+		target, err := openAPITarget(strings.TrimSuffix(server, "/"), path, operation, item.Parameters, doc.Components.Parameters)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("GET %s: %v", path, err))
+			continue
+		}
+		result.Targets = append(result.Targets, target)
+	}
+	result.Targets = finish(result.Targets)
+	return result, nil
+}
+
+func openAPITarget(server, path string, operation *openAPIOperation, pathParameters []openAPIParameter, components map[string]openAPIParameter) (config.Target, error) {
+	var parameters []openAPIParameter
+	for _, parameter := range slices.Concat(pathParameters, operation.Parameters) {
+		if parameter.Ref != "" {
+			name, found := strings.CutPrefix(parameter.Ref, "#/components/parameters/")
+			resolved, exists := components[name]
+			if !found || !exists {
+				return config.Target{}, fmt.Errorf("unresolved parameter reference %s", parameter.Ref)
+			}
+			parameter = resolved
+		}
+		// Operation parameters override the path ones of the same name.
+		parameters = slices.DeleteFunc(parameters, func(p openAPIParameter) bool {
+			return p.Name == parameter.Name && p.In == parameter.In
+		})
+		parameters = append(parameters, parameter)
+	}
+
+	target := config.Target{}
+	var query []string
+	for _, parameter := range parameters {
+		value, ok := parameter.example()
+		if !ok {
+			if parameter.Required || parameter.In == "path" {
+				return config.Target{}, fmt.Errorf("no example for %s parameter %s", parameter.In, parameter.Name)
+			}
+			continue
+		}
+		switch parameter.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+parameter.Name+"}", url.PathEscape(value))
+		case "query":
+			query = append(query, url.QueryEscape(parameter.Name)+"="+url.QueryEscape(value))
+		case "header":
+			target.Headers = setHeader(target.Headers, parameter.Name+": "+value, true)
+		}
+	}
+
+	target.URL = server + path
+	if len(query) > 0 {
+		target.URL += "?" + strings.Join(query, "&")
+	}
+	target.Name = operation.Summary
+	if target.Name == "" {
+		target.Name = operation.OperationID
+	}
+	if target.Name == "" {
+		target.Name = "GET " + path
+	}
+	expectStatus(&target, expectedStatus(operation.Responses))
+	return target, nil
+}
+
+// example returns the value a parameter is documented with, if any.
+func (p openAPIParameter) example() (string, bool) {
+	candidates := []yaml.Node{p.Example}
+	for _, name := range sortedKeys(p.Examples) {
+		candidates = append(candidates, p.Examples[name].Value)
+	}
+	candidates = append(candidates, p.Schema.Example)
+	candidates = append(candidates, p.Schema.Examples...)
+	candidates = append(candidates, p.Schema.Default)
+	if len(p.Schema.Enum) > 0 {
+		candidates = append(candidates, p.Schema.Enum[0])
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Kind == yaml.ScalarNode && candidate.ShortTag() != "!!null" {
+			return candidate.Value, true
+		}
+	}
+	return "", false
+}
+
+// serverURL returns the first server URL of the most specific servers
+// list, with its variables set to their defaults.
+func serverURL(lists ...[]openAPIServer) string {
+	for _, servers := range lists {
+		if len(servers) == 0 {
+			continue
+		}
+		server := servers[0].URL
+		for name, variable := range servers[0].Variables {
+			server = strings.ReplaceAll(server, "{"+name+"}", variable.Default)
+		}
+		return server
+	}
+	return ""
+}
+
+// expectedStatus returns the status a successful call of an operation
+// answers with: a 2xx status if any is documented, and otherwise the
+// lowest documented one. It is 0 when responses document none.
+func expectedStatus(responses map[string]any) int {
+	lowest := 0
+	for code := range responses {
+		if strings.EqualFold(code, "2XX") {
+			return 200
+		}
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			continue
+		}
+		if status >= 200 && status < 300 {
+			return status
+		}
+		if lowest == 0 || status < lowest {
+			lowest = status
+		}
+	}
+	return lowest
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
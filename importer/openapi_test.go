@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Owloops/updo/config"
+)
+
+const _openAPIDocument = `openapi: 3.0.3
+info:
+  title: Pets
+  version: "1"
+servers:
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: api
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+        default: 20
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - $ref: '#/components/parameters/Limit'
+        - name: tag
+          in: query
+          schema:
+            type: string
+        - name: X-Request-Source
+          in: header
+          example: updo
+      responses:
+        "200":
+          description: OK
+    post:
+      responses:
+        "201":
+          description: Created
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        examples:
+          first:
+            value: 42
+    get:
+      operationId: showPet
+      responses:
+        200:
+          description: OK
+  /owners/{ownerId}:
+    get:
+      parameters:
+        - name: ownerId
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+  /legacy:
+    get:
+      responses:
+        "301":
+          description: Moved
+`
+
+func TestOpenAPI(t *testing.T) {
+	got, err := OpenAPI([]byte(_openAPIDocument), "")
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+
+	yes, no := true, false
+	want := []config.Target{
+		{URL: "https://api.example.com/v1/pets?limit=20", Name: "List pets", Headers: []string{"X-Request-Source: updo"}},
+		{URL: "https://api.example.com/v1/pets/42", Name: "showPet"},
+		{URL: "https://api.example.com/v1/legacy", Name: "GET /legacy", AcceptRedirects: &yes, FollowRedirects: &no},
+	}
+	if !reflect.DeepEqual(got.Targets, want) {
+		t.Errorf("OpenAPI() =\n%+v\nwant\n%+v", got.Targets, want)
+	}
+	wantSkipped := []string{"GET /owners/{ownerId}: no example for path parameter ownerId"}
+	if !reflect.DeepEqual(got.Skipped, wantSkipped) {
+		t.Errorf("Expected skipped %v, got %v", wantSkipped, got.Skipped)
+	}
+
+	got, err = OpenAPI([]byte(_openAPIDocument), "http://localhost:8080/")
+	if err != nil {
+		t.Fatalf("OpenAPI failed: %v", err)
+	}
+	if got.Targets[1].URL != "http://localhost:8080/pets/42" {
+		t.Errorf("Expected --base-url to replace the servers, got %s", got.Targets[1].URL)
+	}
+
+	json := `{"openapi": "3.1.0", "paths": {"/health": {"get": {"responses": {"200": {}}}}}}`
+	if _, err := OpenAPI([]byte(json), ""); err == nil || !strings.Contains(err.Error(), "--base-url") {
+		t.Errorf("Expected an error asking for --base-url, got %v", err)
+	}
+	if _, err := OpenAPI([]byte(`{"swagger": "2.0"}`), ""); err == nil {
+		t.Error("Expected an error for a Swagger 2.0 document")
+	}
+}
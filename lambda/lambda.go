@@ -11,6 +11,7 @@ import (
 	"maps"
 
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
@@ -43,6 +44,7 @@ type CheckRequest struct {
 	Headers         []string `json:"headers"`
 	Body            string   `json:"body"`
 	Timeout         int      `json:"timeout"`
+	TimeoutMs       int64    `json:"timeout_ms,omitempty"`
 	FollowRedirects bool     `json:"follow_redirects"`
 	AcceptRedirects bool     `json:"accept_redirects"`
 	SkipSSL         bool     `json:"skip_ssl"`
@@ -71,6 +73,7 @@ type HttpTraceInfoSimple struct {
 	WaitMs             float64 `json:"wait_ms"`
 	DNSLookupMs        float64 `json:"dns_lookup_ms"`
 	TCPConnectionMs    float64 `json:"tcp_connection_ms"`
+	TLSHandshakeMs     float64 `json:"tls_handshake_ms,omitempty"`
 	TimeToFirstByteMs  float64 `json:"time_to_first_byte_ms"`
 	DownloadDurationMs float64 `json:"download_duration_ms"`
 }
@@ -85,7 +88,10 @@ func handleRequest(ctx context.Context, req CheckRequest) (CheckResponse, error)
 	}
 
 	timeout := time.Duration(req.Timeout) * time.Second
-	if req.Timeout <= 0 {
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	if timeout <= 0 {
 		timeout = _defaultTimeout
 	}
 	if timeout > _maxTimeout {
@@ -143,6 +149,7 @@ func handleRequest(ctx context.Context, req CheckRequest) (CheckResponse, error)
 			WaitMs:             float64(result.TraceInfo.Wait / time.Millisecond),
 			DNSLookupMs:        float64(result.TraceInfo.DNSLookup / time.Millisecond),
 			TCPConnectionMs:    float64(result.TraceInfo.TCPConnection / time.Millisecond),
+			TLSHandshakeMs:     float64(result.TraceInfo.TLSHandshake / time.Millisecond),
 			TimeToFirstByteMs:  float64(result.TraceInfo.TimeToFirstByte / time.Millisecond),
 			DownloadDurationMs: float64(result.TraceInfo.DownloadDuration / time.Millisecond),
 		}
@@ -162,7 +169,7 @@ func isHTTPS(url string) bool {
 func checkSSLExpiry(url string, resp *CheckResponse) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("Warning: SSL check failed for %s: %v", url, r)
+			log.Printf("Warning: SSL check failed for %s: %v", redact.URL(url), r)
 		}
 	}()
 	sslDays := net.GetSSLCertExpiry(url)
@@ -51,6 +51,16 @@ func TestHandleRequest(t *testing.T) {
 			expectError:   false,
 			expectSuccess: true,
 		},
+		{
+			name: "millisecond timeout",
+			request: CheckRequest{
+				URL:       "http://httpbin.org/status/200",
+				Timeout:   1,
+				TimeoutMs: 5000,
+			},
+			expectError:   false,
+			expectSuccess: true,
+		},
 		{
 			name: "timeout capping",
 			request: CheckRequest{
@@ -0,0 +1,221 @@
+package logship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Owloops/updo/utils"
+)
+
+const (
+	_defaultBatchSize     = 500
+	_defaultFlushInterval = 5 * time.Second
+	_maxBufferedRecords   = 10000
+	_maxRetries           = 3
+	_retryDelay           = time.Second
+	_httpTimeout          = 10 * time.Second
+)
+
+// statusError is an unexpected response from a log store. Only overload
+// and server errors are worth retrying.
+type statusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.statusCode, e.message)
+}
+
+func (e *statusError) recoverable() bool {
+	return e.statusCode >= http.StatusInternalServerError ||
+		e.statusCode == http.StatusTooManyRequests ||
+		e.statusCode == http.StatusRequestTimeout
+}
+
+// batchShipper collects records and hands them to send once batchSize are
+// buffered or every interval, retrying failed batches with backoff. If the
+// store stays unreachable the oldest records are dropped beyond
+// _maxBufferedRecords.
+type batchShipper struct {
+	name      string
+	batchSize int
+	interval  time.Duration
+	send      func(ctx context.Context, records []utils.Record) error
+
+	mu      sync.Mutex
+	records []utils.Record
+	dropped int
+	// failing is only touched by the flushing goroutine.
+	failing bool
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	flushChan chan struct{}
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newBatchShipper(name string, batchSize int, interval time.Duration, send func(context.Context, []utils.Record) error) *batchShipper {
+	if batchSize <= 0 {
+		batchSize = _defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = _defaultFlushInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &batchShipper{
+		name:      name,
+		batchSize: batchSize,
+		interval:  interval,
+		send:      send,
+		ctx:       ctx,
+		cancel:    cancel,
+		flushChan: make(chan struct{}, 1),
+		stopChan:  make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+	return s
+}
+
+func (s *batchShipper) Ship(record utils.Record) {
+	s.mu.Lock()
+	s.records = append(s.records, record)
+	if overflow := len(s.records) - _maxBufferedRecords; overflow > 0 {
+		s.records = s.records[overflow:]
+		s.dropped += overflow
+	}
+	full := len(s.records) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *batchShipper) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.flush()
+	s.cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lost := len(s.records) + s.dropped; lost > 0 {
+		log.Printf("[ERROR] %d log records could not be shipped to %s", lost, s.name)
+	}
+}
+
+func (s *batchShipper) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushChan:
+			s.flush()
+		}
+	}
+}
+
+// flush sends the buffered records batch by batch. A batch that cannot be
+// delivered after retrying is put back to be tried again on the next
+// flush, unless the store refused it.
+func (s *batchShipper) flush() {
+	for {
+		s.mu.Lock()
+		n := min(len(s.records), s.batchSize)
+		batch := slices.Clone(s.records[:n])
+		s.records = s.records[n:]
+		s.mu.Unlock()
+
+		if n == 0 {
+			return
+		}
+
+		err := s.sendWithRetry(batch)
+		var refused *statusError
+		switch {
+		case err == nil:
+			if s.failing {
+				s.failing = false
+				log.Printf("Log shipping to %s recovered", s.name)
+			}
+		case errors.As(err, &refused) && !refused.recoverable():
+			log.Printf("[ERROR] %s refused log records: %v", s.name, err)
+		default:
+			if !s.failing {
+				s.failing = true
+				log.Printf("[ERROR] Failed to ship logs to %s, will retry: %v", s.name, err)
+			}
+			s.requeue(batch)
+			return
+		}
+	}
+}
+
+func (s *batchShipper) requeue(batch []utils.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(batch, s.records...)
+	if overflow := len(s.records) - _maxBufferedRecords; overflow > 0 {
+		s.records = s.records[overflow:]
+		s.dropped += overflow
+	}
+}
+
+func (s *batchShipper) sendWithRetry(batch []utils.Record) error {
+	delay := _retryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.send(s.ctx, batch)
+		var status *statusError
+		if err == nil || attempt >= _maxRetries || (errors.As(err, &status) && !status.recoverable()) {
+			return err
+		}
+
+		select {
+		case <-s.stopChan:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &statusError{statusCode: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,123 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+)
+
+const (
+	_bulkPath            = "/_bulk"
+	_defaultIndex        = "updo"
+	_bulkResponseMaxSize = 1 << 20
+)
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// NewElasticsearchShipper indexes records through the bulk API of
+// Elasticsearch or OpenSearch, one document per record.
+func NewElasticsearchShipper(cfg config.ElasticsearchOutput) (Shipper, error) {
+	bulkURL, err := url.Parse(cfg.URL)
+	if err != nil || bulkURL.Host == "" {
+		return nil, fmt.Errorf("invalid Elasticsearch URL %q", cfg.URL)
+	}
+	bulkURL.Path = strings.TrimSuffix(bulkURL.Path, "/") + _bulkPath
+
+	index := cfg.Index
+	if index == "" {
+		index = _defaultIndex
+	}
+	action, err := json.Marshal(map[string]map[string]string{"index": {"_index": index}})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: _httpTimeout}
+
+	send := func(ctx context.Context, records []utils.Record) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, bulkURL.String(), bytes.NewReader(encodeBulk(action, records)))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		switch {
+		case cfg.APIKey != "":
+			req.Header.Set("Authorization", "ApiKey "+cfg.APIKey)
+		case cfg.Username != "":
+			req.SetBasicAuth(cfg.Username, cfg.Password)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("HTTP request failed: %w", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, _bulkResponseMaxSize))
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &statusError{statusCode: resp.StatusCode, message: strings.TrimSpace(string(body[:min(len(body), 512)]))}
+		}
+		return bulkItemsError(body)
+	}
+
+	return newBatchShipper("Elasticsearch", cfg.BatchSize, cfg.FlushInterval, send), nil
+}
+
+// encodeBulk builds the NDJSON body, an action line followed by the record
+// for every document.
+func encodeBulk(action []byte, records []utils.Record) []byte {
+	var body bytes.Buffer
+	for _, record := range records {
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(record.Line)
+		body.WriteByte('\n')
+	}
+	return body.Bytes()
+}
+
+// bulkItemsError reports documents the bulk API rejected even though the
+// request as a whole succeeded. Resending them would fail the same way, so
+// the error is not recoverable.
+func bulkItemsError(body []byte) error {
+	var response bulkResponse
+	if err := json.Unmarshal(body, &response); err != nil || !response.Errors {
+		return nil
+	}
+
+	failed := 0
+	var first string
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				if failed == 0 {
+					first = fmt.Sprintf("%s: %s", result.Error.Type, result.Error.Reason)
+				}
+				failed++
+			}
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return &statusError{statusCode: http.StatusBadRequest, message: fmt.Sprintf("%d documents rejected, first: %s", failed, first)}
+}
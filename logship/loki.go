@@ -0,0 +1,126 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+)
+
+const (
+	_lokiPushPath = "/loki/api/v1/push"
+	_lokiJob      = "updo"
+)
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+// NewLokiShipper pushes records to the Grafana Loki push API, with one
+// stream per record type, target URL, region and custom label set.
+func NewLokiShipper(cfg config.LokiOutput) (Shipper, error) {
+	pushURL, err := url.Parse(cfg.URL)
+	if err != nil || pushURL.Host == "" {
+		return nil, fmt.Errorf("invalid Loki URL %q", cfg.URL)
+	}
+	if !strings.HasSuffix(pushURL.Path, _lokiPushPath) {
+		pushURL.Path = strings.TrimSuffix(pushURL.Path, "/") + _lokiPushPath
+	}
+
+	client := &http.Client{Timeout: _httpTimeout}
+
+	send := func(ctx context.Context, records []utils.Record) error {
+		body, err := json.Marshal(encodeLokiPush(records))
+		if err != nil {
+			return fmt.Errorf("failed to marshal push request: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, pushURL.String(), bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if cfg.TenantID != "" {
+			req.Header.Set("X-Scope-OrgID", cfg.TenantID)
+		}
+		if cfg.Username != "" {
+			req.SetBasicAuth(cfg.Username, cfg.Password)
+		}
+
+		return do(client, req)
+	}
+
+	return newBatchShipper("Loki", cfg.BatchSize, cfg.FlushInterval, send), nil
+}
+
+func encodeLokiPush(records []utils.Record) lokiPushRequest {
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b utils.Record) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+
+	streams := make(map[string]*lokiStream)
+	for _, record := range records {
+		labels := streamLabels(record)
+		key := streamKey(labels)
+
+		stream, exists := streams[key]
+		if !exists {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+		}
+		stream.Values = append(stream.Values, [2]string{
+			strconv.FormatInt(record.Timestamp.UnixNano(), 10),
+			string(record.Line),
+		})
+	}
+
+	request := lokiPushRequest{Streams: make([]lokiStream, 0, len(streams))}
+	for _, key := range slices.Sorted(maps.Keys(streams)) {
+		request.Streams = append(request.Streams, *streams[key])
+	}
+	return request
+}
+
+// streamLabels keeps the stream small: custom target labels plus what
+// identifies the record source. Everything else stays in the JSON line.
+func streamLabels(record utils.Record) map[string]string {
+	labels := maps.Clone(record.Labels)
+	if labels == nil {
+		labels = make(map[string]string, 4)
+	}
+	labels["job"] = _lokiJob
+	labels["type"] = record.Type
+	if record.URL != "" {
+		labels["url"] = record.URL
+	}
+	if record.Region != "" {
+		labels["region"] = record.Region
+	}
+	return labels
+}
+
+func streamKey(labels map[string]string) string {
+	var key strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		key.WriteString(name)
+		key.WriteByte('=')
+		key.WriteString(labels[name])
+		key.WriteByte(0)
+	}
+	return key.String()
+}
@@ -0,0 +1,75 @@
+package logship
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+)
+
+// Shipper forwards log records to a log store. Implementations must be
+// safe for concurrent use.
+type Shipper interface {
+	Ship(record utils.Record)
+	// Stop sends anything still buffered and releases the shipper.
+	Stop()
+}
+
+var _shippers []Shipper
+
+// Setup starts a shipper for every output configured under [logs] and
+// hooks them up to the records printed by the utils.Log* functions.
+func Setup(cfg config.Logs) error {
+	if password := os.Getenv("UPDO_LOKI_PASSWORD"); password != "" {
+		cfg.Loki.Password = password
+	}
+	if password := os.Getenv("UPDO_ELASTICSEARCH_PASSWORD"); password != "" {
+		cfg.Elasticsearch.Password = password
+	}
+	if apiKey := os.Getenv("UPDO_ELASTICSEARCH_API_KEY"); apiKey != "" {
+		cfg.Elasticsearch.APIKey = apiKey
+	}
+
+	if cfg.Loki.URL != "" {
+		shipper, err := NewLokiShipper(cfg.Loki)
+		if err != nil {
+			return fmt.Errorf("loki: %w", err)
+		}
+		_shippers = append(_shippers, shipper)
+	}
+
+	if cfg.Elasticsearch.URL != "" {
+		shipper, err := NewElasticsearchShipper(cfg.Elasticsearch)
+		if err != nil {
+			Stop()
+			return fmt.Errorf("elasticsearch: %w", err)
+		}
+		_shippers = append(_shippers, shipper)
+	}
+
+	if len(_shippers) > 0 {
+		utils.SetRecordHook(ship)
+	}
+	return nil
+}
+
+// Stop detaches the shippers and flushes them.
+func Stop() {
+	utils.SetRecordHook(nil)
+	for _, shipper := range _shippers {
+		shipper.Stop()
+	}
+	_shippers = nil
+}
+
+// Enabled reports whether any shipper is running.
+func Enabled() bool {
+	return len(_shippers) > 0
+}
+
+func ship(record utils.Record) {
+	for _, shipper := range _shippers {
+		shipper.Ship(record)
+	}
+}
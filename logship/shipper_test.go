@@ -0,0 +1,176 @@
+package logship
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/utils"
+)
+
+func testRecords() []utils.Record {
+	ts := time.Unix(1700000000, 0)
+	return []utils.Record{
+		{Type: "check", Timestamp: ts.Add(time.Second), URL: "https://example.com", Region: "us-east-1", Labels: map[string]string{"team": "payments"}, Line: []byte(`{"type":"check","sequence_num":2}`)},
+		{Type: "check", Timestamp: ts, URL: "https://example.com", Region: "us-east-1", Labels: map[string]string{"team": "payments"}, Line: []byte(`{"type":"check","sequence_num":1}`)},
+		{Type: "check", Timestamp: ts, URL: "https://example.com", Line: []byte(`{"type":"check","sequence_num":1}`)},
+	}
+}
+
+func TestEncodeLokiPush(t *testing.T) {
+	request := encodeLokiPush(testRecords())
+	if len(request.Streams) != 2 {
+		t.Fatalf("Expected 2 streams, got %d", len(request.Streams))
+	}
+
+	want := map[string]string{"job": "updo", "type": "check", "url": "https://example.com", "region": "us-east-1", "team": "payments"}
+	var regional lokiStream
+	for _, stream := range request.Streams {
+		if stream.Stream["region"] != "" {
+			regional = stream
+		}
+	}
+	if !maps.Equal(regional.Stream, want) {
+		t.Errorf("Stream labels = %v, want %v", regional.Stream, want)
+	}
+	if len(regional.Values) != 2 || regional.Values[0][0] != "1700000000000000000" || !strings.Contains(regional.Values[1][1], `"sequence_num":2`) {
+		t.Errorf("Expected values in timestamp order, got %v", regional.Values)
+	}
+}
+
+func TestLokiShipper(t *testing.T) {
+	var mu sync.Mutex
+	var received lokiPushRequest
+	var tenant, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		tenant, path = r.Header.Get("X-Scope-OrgID"), r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	shipper, err := NewLokiShipper(config.LokiOutput{URL: server.URL, TenantID: "ops"})
+	if err != nil {
+		t.Fatalf("NewLokiShipper failed: %v", err)
+	}
+	for _, record := range testRecords() {
+		shipper.Ship(record)
+	}
+	shipper.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if path != _lokiPushPath || tenant != "ops" {
+		t.Errorf("Unexpected request to %q with tenant %q", path, tenant)
+	}
+	if len(received.Streams) != 2 {
+		t.Errorf("Expected 2 streams, got %d", len(received.Streams))
+	}
+}
+
+func TestElasticsearchShipper(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != _bulkPath || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected bulk request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")...)
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	shipper, err := NewElasticsearchShipper(config.ElasticsearchOutput{URL: server.URL, Index: "checks", APIKey: "secret", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("NewElasticsearchShipper failed: %v", err)
+	}
+	records := testRecords()
+	for _, record := range records {
+		shipper.Ship(record)
+	}
+	shipper.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "ApiKey secret" {
+		t.Errorf("Unexpected Authorization header %q", auth)
+	}
+	if len(lines) != 2*len(records) {
+		t.Fatalf("Expected %d bulk lines, got %d", 2*len(records), len(lines))
+	}
+	if lines[0] != `{"index":{"_index":"checks"}}` || lines[1] != string(records[0].Line) {
+		t.Errorf("Unexpected bulk body %v", lines[:2])
+	}
+}
+
+func TestBulkItemsError(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"accepted", `{"errors":false,"items":[{"index":{"status":201}}]}`, false},
+		{"rejected document", `{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`, true},
+		{"unparseable", `not json`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bulkItemsError([]byte(tt.body)); (err != nil) != tt.wantErr {
+				t.Errorf("bulkItemsError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBatchShipper_RetriesAndRequeues(t *testing.T) {
+	var attempts atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	shipper, err := NewLokiShipper(config.LokiOutput{URL: server.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewLokiShipper failed: %v", err)
+	}
+	batch := shipper.(*batchShipper)
+	for _, record := range testRecords() {
+		batch.Ship(record)
+	}
+
+	// Stopping cuts retries short, so the records stay buffered.
+	close(batch.stopChan)
+	batch.wg.Wait()
+	batch.flush()
+	if attempts.Load() != 1 || len(batch.records) != len(testRecords()) {
+		t.Fatalf("Expected one attempt and the records requeued, got %d attempts and %d records", attempts.Load(), len(batch.records))
+	}
+
+	status.Store(http.StatusBadRequest)
+	batch.flush()
+	if len(batch.records) != 0 {
+		t.Errorf("Refused records should be discarded, %d left", len(batch.records))
+	}
+	batch.cancel()
+}
@@ -5,6 +5,9 @@ import (
 	"os"
 
 	"github.com/Owloops/updo/cmd/aws"
+	updoconfig "github.com/Owloops/updo/cmd/config"
+	"github.com/Owloops/updo/cmd/exporter"
+	"github.com/Owloops/updo/cmd/importer"
 	"github.com/Owloops/updo/cmd/monitor"
 	"github.com/Owloops/updo/cmd/root"
 	"github.com/spf13/cobra"
@@ -24,6 +27,9 @@ func main() {
 
 	root.RootCmd.AddCommand(monitor.MonitorCmd)
 	root.RootCmd.AddCommand(aws.AWSCmd)
+	root.RootCmd.AddCommand(updoconfig.ConfigCmd)
+	root.RootCmd.AddCommand(importer.ImportCmd)
+	root.RootCmd.AddCommand(exporter.ExportCmd)
 
 	root.RootCmd.Run = func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 && cmd.CalledAs() == "updo" {
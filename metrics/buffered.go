@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+var _pathSegmentPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// sample is a single value of one of the series built by the Convert*
+// functions, flattened for sinks that do not speak remote write.
+type sample struct {
+	name      string
+	labels    map[string]string
+	value     float64
+	timestamp time.Time
+}
+
+// bufferedSink collects samples and hands them to write in batches every
+// interval, so each backend only has to implement the wire format.
+type bufferedSink struct {
+	name     string
+	interval time.Duration
+	write    func([]sample) error
+	close    func() error
+
+	mu       sync.Mutex
+	samples  []sample
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newBufferedSink(name string, interval time.Duration, write func([]sample) error, close func() error) *bufferedSink {
+	if interval <= 0 {
+		interval = _defaultPushInterval
+	}
+
+	s := &bufferedSink{
+		name:     name,
+		interval: interval,
+		write:    write,
+		close:    close,
+		stopChan: make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+	return s
+}
+
+func (s *bufferedSink) AddCheck(target config.Target, result net.WebsiteCheckResult, region string) {
+	s.add(ConvertCheckToTimeSeries(target, result, region, time.Now())...)
+}
+
+func (s *bufferedSink) AddSSLExpiry(target config.Target, daysUntilExpiry int) {
+	s.add(ConvertSSLExpiryToTimeSeries(target, daysUntilExpiry, time.Now()))
+}
+
+func (s *bufferedSink) AddApdex(target config.Target, score float64, region string) {
+	s.add(ConvertApdexToTimeSeries(target, score, region, time.Now()))
+}
+
+func (s *bufferedSink) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+	s.flush()
+
+	if s.close != nil {
+		if err := s.close(); err != nil {
+			logf(err, "Error closing %s metrics sink", s.name)
+		}
+	}
+}
+
+func (s *bufferedSink) add(series ...*prompb.TimeSeries) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ts := range series {
+		if ts == nil {
+			continue
+		}
+		s.samples = append(s.samples, toSamples(ts)...)
+	}
+}
+
+func (s *bufferedSink) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *bufferedSink) flush() {
+	s.mu.Lock()
+	samples := s.samples
+	s.samples = nil
+	s.mu.Unlock()
+
+	if len(samples) == 0 {
+		return
+	}
+
+	if err := s.write(samples); err != nil {
+		logf(err, "Error sending metrics to %s", s.name)
+	}
+}
+
+func toSamples(ts *prompb.TimeSeries) []sample {
+	var name string
+	labels := make(map[string]string, len(ts.Labels))
+	for _, label := range ts.Labels {
+		if label.Name == _nameLbl {
+			name = label.Value
+			continue
+		}
+		labels[label.Name] = label.Value
+	}
+
+	samples := make([]sample, 0, len(ts.Samples))
+	for _, s := range ts.Samples {
+		samples = append(samples, sample{
+			name:      name,
+			labels:    labels,
+			value:     s.Value,
+			timestamp: time.UnixMilli(s.Timestamp),
+		})
+	}
+	return samples
+}
+
+// dottedPath names a sample for backends without labels, such as
+// prefix.target_up.<name>.<region>. The URL label is left out since the
+// target name already identifies it.
+func dottedPath(prefix string, s sample) string {
+	segments := []string{strings.TrimSuffix(prefix, "."), strings.TrimPrefix(s.name, _defaultMetricPrefix)}
+
+	keys := make([]string, 0, len(s.labels))
+	for key := range s.labels {
+		if key != "url" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		segments = append(segments, _pathSegmentPattern.ReplaceAllString(s.labels[key], "_"))
+	}
+
+	return strings.Join(slices.DeleteFunc(segments, func(segment string) bool { return segment == "" }), ".")
+}
@@ -3,10 +3,13 @@ package metrics
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
@@ -17,66 +20,173 @@ import (
 )
 
 const (
-	_maxRetries  = 3
-	_retryDelay  = 1 * time.Second
-	_httpTimeout = 10 * time.Second
+	_retryDelay    = 1 * time.Second
+	_maxRetryDelay = 30 * time.Second
+	_httpTimeout   = 10 * time.Second
+
+	_contentTypeV1        = "application/x-protobuf"
+	_contentTypeV2        = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+	_versionHeader        = "X-Prometheus-Remote-Write-Version"
+	_samplesWrittenHeader = "X-Prometheus-Remote-Write-Samples-Written"
 )
 
+var errProtocolRejected = errors.New("receiver does not support remote write 2.0")
+
+// statusError is a non-2xx response from the receiver.
+type statusError struct {
+	statusCode int
+	message    string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// recoverable reports whether sending the same batch again may succeed.
+// Other client errors mean the receiver will never accept it.
+func (e *statusError) recoverable() bool {
+	return e.statusCode >= 500 || e.statusCode == http.StatusTooManyRequests || e.statusCode == http.StatusRequestTimeout
+}
+
+// WriteClient pushes samples with Prometheus remote write. Every flush is
+// appended to a write-ahead log first and one sender per shard replays it,
+// so samples buffered during an outage are delivered once the receiver is
+// back instead of being dropped.
 type WriteClient struct {
-	config     Config
-	httpClient *http.Client
-	mu         sync.RWMutex
-	samples    []*prompb.TimeSeries
-	ctx        context.Context
-	cancel     context.CancelFunc
-	stopChan   chan struct{}
-	wg         sync.WaitGroup
+	config      Config
+	httpClient  *http.Client
+	mu          sync.RWMutex
+	samples     []*prompb.TimeSeries
+	counters    map[string]float64
+	histograms  map[string]*nativeHistogram
+	wal         *writeAheadLog
+	startTime   time.Time
+	useV1       atomic.Bool
+	failing     atomic.Bool
+	failures    atomic.Int64
+	lastDropped atomic.Int64
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
 }
 
-func NewWriteClient(cfg Config) *WriteClient {
+func NewWriteClient(cfg Config) (*WriteClient, error) {
+	if cfg.Shards <= 0 {
+		cfg.Shards = _defaultShards
+	}
+	if cfg.WALMaxSize <= 0 {
+		cfg.WALMaxSize = _defaultWALMaxSize
+	}
+
+	wal, err := openWAL(cfg.WALDir, cfg.Shards, cfg.WALMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WriteClient{
+	client := &WriteClient{
 		config: cfg,
 		httpClient: &http.Client{
 			Timeout: _httpTimeout,
 		},
-		samples:  make([]*prompb.TimeSeries, 0),
-		ctx:      ctx,
-		cancel:   cancel,
-		stopChan: make(chan struct{}),
+		samples:    make([]*prompb.TimeSeries, 0),
+		counters:   make(map[string]float64),
+		histograms: make(map[string]*nativeHistogram),
+		wal:        wal,
+		startTime:  time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+		stopChan:   make(chan struct{}),
 	}
+	client.useV1.Store(cfg.Protocol == RemoteWriteV1)
+	return client, nil
 }
 
 func (c *WriteClient) Start() {
-	c.wg.Add(1)
+	if pending := c.wal.pending.Load(); pending > 0 {
+		logf(nil, "Resending %d metrics samples left in %s", pending, c.config.WALDir)
+	}
+
+	c.wg.Add(1 + len(c.wal.shards))
 	go c.pushLoop()
+	for _, shard := range c.wal.shards {
+		go c.sendLoop(shard)
+	}
 }
 
+// Stop flushes the buffered samples and makes a last attempt to deliver
+// the log. Whatever is left stays on disk for the next run when a WAL
+// directory is configured.
 func (c *WriteClient) Stop() {
 	c.flushSamples()
 	close(c.stopChan)
-	c.cancel()
 	c.wg.Wait()
+
+	c.drain()
+	c.cancel()
+
+	if pending := c.wal.pending.Load(); pending > 0 {
+		if c.config.WALDir != "" {
+			logf(nil, "Kept %d undelivered metrics samples in %s", pending, c.config.WALDir)
+		} else {
+			logf(errors.New("remote write receiver unreachable"), "Discarded %d undelivered metrics samples", pending)
+		}
+	}
 }
 
+// AddCheck buffers the series of a check stamped with the time the check
+// ran. Series ending in _total are accumulated so they are sent as real
+// counters, and the response time is also recorded in a native histogram.
 func (c *WriteClient) AddCheck(target config.Target, result net.WebsiteCheckResult, region string) {
+	checkTime := result.LastCheckTime
+	if checkTime.IsZero() {
+		checkTime = time.Now()
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	timeSeries := ConvertCheckToTimeSeries(target, result, region, time.Time{})
-	c.samples = append(c.samples, timeSeries...)
+	for _, ts := range ConvertCheckToTimeSeries(target, result, region, checkTime) {
+		if name := seriesName(ts.Labels); strings.HasSuffix(name, _counterSuffix) {
+			key := labelsKey(ts.Labels)
+			for _, sample := range ts.Samples {
+				c.counters[key] += sample.Value
+				sample.Value = c.counters[key]
+			}
+		}
+		c.samples = append(c.samples, ts)
+	}
+
+	if result.ResponseTime > 0 {
+		labels := MapSeries(_latencyHistogramName, MapTargetLabels(target, result, region))
+		key := labelsKey(labels)
+		histogram, exists := c.histograms[key]
+		if !exists {
+			histogram = newNativeHistogram(labels)
+			c.histograms[key] = histogram
+		}
+		histogram.observe(result.ResponseTime.Seconds(), checkTime.UnixMilli())
+	}
 }
 
 func (c *WriteClient) AddSSLExpiry(target config.Target, daysUntilExpiry int) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if ts := ConvertSSLExpiryToTimeSeries(target, daysUntilExpiry, time.Time{}); ts != nil {
+	if ts := ConvertSSLExpiryToTimeSeries(target, daysUntilExpiry, time.Now()); ts != nil {
 		c.samples = append(c.samples, ts)
 	}
 }
 
+func (c *WriteClient) AddApdex(target config.Target, score float64, region string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.samples = append(c.samples, ConvertApdexToTimeSeries(target, score, region, time.Now()))
+}
+
 func (c *WriteClient) pushLoop() {
 	defer c.wg.Done()
 
@@ -100,54 +210,181 @@ func (c *WriteClient) flushSamples() {
 	samples := make([]*prompb.TimeSeries, len(c.samples))
 	copy(samples, c.samples)
 	c.samples = c.samples[:0]
+
+	var histograms []histogramSeries
+	for _, histogram := range c.histograms {
+		if histogram.updated {
+			histograms = append(histograms, histogramSeries{labels: histogram.labels, histogram: histogram.snapshot()})
+			histogram.updated = false
+		}
+	}
 	c.mu.Unlock()
 
-	if len(samples) == 0 {
-		return
+	samples = append(c.normalizeTimestamps(samples), c.selfMetrics()...)
+	if err := c.wal.append(samples); err != nil {
+		logf(err, "Error buffering metrics for Prometheus")
+	}
+
+	if dropped, previous := c.wal.dropped.Load(), c.lastDropped.Load(); dropped > previous && c.lastDropped.CompareAndSwap(previous, dropped) {
+		logf(fmt.Errorf("pending samples exceeded %d bytes", c.wal.maxSize), "Dropped %d oldest metrics samples", dropped-previous)
 	}
 
-	if err := c.sendSamples(samples); err != nil {
-		fmt.Printf("Error sending metrics to Prometheus: %v\n", err)
+	// Histograms are cumulative, so one lost during an outage is made up
+	// for by the next and they bypass the log.
+	if len(histograms) > 0 && !c.useV1.Load() {
+		if err := c.sendSamples(nil, histograms); err != nil {
+			c.reportFailure(err)
+		}
 	}
 }
 
-func (c *WriteClient) sendSamples(samples []*prompb.TimeSeries) error {
-	samples = c.normalizeTimestamps(samples)
+// selfMetrics describes the state of the log itself.
+func (c *WriteClient) selfMetrics() []*prompb.TimeSeries {
+	timestamp := time.Now().UnixMilli()
+	values := map[string]float64{
+		"remote_write_pending_samples":       float64(c.wal.pending.Load()),
+		"remote_write_failures_total":        float64(c.failures.Load()),
+		"remote_write_dropped_samples_total": float64(c.wal.dropped.Load()),
+	}
 
-	writeReq := &prompb.WriteRequest{
-		Timeseries: samples,
+	series := make([]*prompb.TimeSeries, 0, len(values))
+	for name, value := range values {
+		series = append(series, &prompb.TimeSeries{
+			Labels:  MapSeries(name, nil),
+			Samples: []*prompb.Sample{{Timestamp: timestamp, Value: value}},
+		})
 	}
+	return series
+}
 
-	data, err := proto.Marshal(writeReq)
+// sendLoop replays the segments of one shard oldest first, backing off
+// while the receiver is unavailable.
+func (c *WriteClient) sendLoop(shard *walShard) {
+	defer c.wg.Done()
+
+	backoff := _retryDelay
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		default:
+		}
+
+		segment := shard.oldest()
+		if segment == nil {
+			select {
+			case <-c.stopChan:
+				return
+			case <-shard.notify:
+			}
+			continue
+		}
+
+		if err := c.sendSegment(shard, segment); err != nil {
+			select {
+			case <-c.stopChan:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, _maxRetryDelay)
+			continue
+		}
+		backoff = _retryDelay
+	}
+}
+
+// drain sends what is left in the log until the first failure.
+func (c *WriteClient) drain() {
+	for _, shard := range c.wal.shards {
+		for segment := shard.oldest(); segment != nil; segment = shard.oldest() {
+			if err := c.sendSegment(shard, segment); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendSegment returns an error only if the segment should be retried.
+// Segments the receiver refuses for good are dropped.
+func (c *WriteClient) sendSegment(shard *walShard, segment *walSegment) error {
+	series, err := segment.read()
 	if err != nil {
-		return fmt.Errorf("failed to marshal protobuf: %w", err)
+		c.wal.drop(shard, segment)
+		logf(err, "Dropped unreadable metrics segment")
+		return nil
 	}
 
-	compressed := snappy.Encode(nil, data)
+	err = c.sendSamples(series, nil)
+	var statusErr *statusError
+	switch {
+	case err == nil:
+		c.wal.remove(shard, segment)
+		if c.failing.CompareAndSwap(true, false) {
+			logf(nil, "Remote write recovered, resending %d pending samples", c.wal.pending.Load())
+		}
+		return nil
+	case errors.As(err, &statusErr) && !statusErr.recoverable():
+		c.wal.drop(shard, segment)
+		logf(err, "Prometheus rejected %d samples", segment.samples)
+		return nil
+	default:
+		c.reportFailure(err)
+		return err
+	}
+}
 
-	for attempt := range _maxRetries {
-		if err := c.doRequest(compressed); err != nil {
-			if attempt < _maxRetries-1 {
-				time.Sleep(_retryDelay * time.Duration(attempt+1))
-				continue
-			}
-			return fmt.Errorf("failed to send after %d attempts: %w", _maxRetries, err)
+// reportFailure counts a failed push and logs it when the receiver starts
+// failing, rather than on every retry.
+func (c *WriteClient) reportFailure(err error) {
+	c.failures.Add(1)
+	if c.failing.CompareAndSwap(false, true) {
+		logf(err, "Remote write failed, buffering %d samples", c.wal.pending.Load())
+	}
+}
+
+// sendSamples writes a batch with remote write 2.0 unless the receiver has
+// already rejected it, in which case the batch goes out as 1.0 and the
+// client stays on 1.0. Native histograms are only sent with 2.0.
+func (c *WriteClient) sendSamples(samples []*prompb.TimeSeries, histograms []histogramSeries) error {
+	if !c.useV1.Load() {
+		err := c.send(encodeV2Request(samples, histograms, c.startTime.UnixMilli()), RemoteWriteV2)
+		if !errors.Is(err, errProtocolRejected) {
+			return err
+		}
+		if c.useV1.CompareAndSwap(false, true) {
+			logf(err, "Prometheus rejected remote write 2.0, falling back to 1.0")
 		}
+	}
+
+	if len(samples) == 0 {
 		return nil
 	}
+	return c.send(&prompb.WriteRequest{Timeseries: samples}, RemoteWriteV1)
+}
 
-	return nil
+func (c *WriteClient) send(message proto.Message, protocol string) error {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+
+	return c.doRequest(snappy.Encode(nil, data), protocol)
 }
 
-func (c *WriteClient) doRequest(data []byte) error {
+func (c *WriteClient) doRequest(data []byte, protocol string) error {
 	req, err := http.NewRequestWithContext(c.ctx, "POST", c.config.ServerURL, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-protobuf")
 	req.Header.Set("Content-Encoding", "snappy")
-	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if protocol == RemoteWriteV2 {
+		req.Header.Set("Content-Type", _contentTypeV2)
+		req.Header.Set(_versionHeader, "2.0.0")
+	} else {
+		req.Header.Set("Content-Type", _contentTypeV1)
+		req.Header.Set(_versionHeader, "0.1.0")
+	}
 
 	if c.config.Username != "" && c.config.Password != "" {
 		req.SetBasicAuth(c.config.Username, c.config.Password)
@@ -166,17 +403,30 @@ func (c *WriteClient) doRequest(data []byte) error {
 		_ = resp.Body.Close()
 	}()
 
+	if protocol == RemoteWriteV2 && resp.StatusCode == http.StatusUnsupportedMediaType {
+		return fmt.Errorf("%w: server responded with status %d", errProtocolRejected, resp.StatusCode)
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, readErr := io.ReadAll(resp.Body)
 		if readErr == nil && len(body) > 0 {
-			return fmt.Errorf("server responded with status %d: %s - %s", resp.StatusCode, resp.Status, string(body))
+			return &statusError{resp.StatusCode, fmt.Sprintf("server responded with status %d: %s - %s", resp.StatusCode, resp.Status, string(body))}
 		}
-		return fmt.Errorf("server responded with status %d: %s", resp.StatusCode, resp.Status)
+		return &statusError{resp.StatusCode, fmt.Sprintf("server responded with status %d: %s", resp.StatusCode, resp.Status)}
+	}
+
+	// A 1.0 receiver decodes a 2.0 body as an empty request and accepts it,
+	// but only 2.0 receivers report how many samples they wrote.
+	if protocol == RemoteWriteV2 && resp.Header.Get(_samplesWrittenHeader) == "" {
+		return fmt.Errorf("%w: response has no %s header", errProtocolRejected, _samplesWrittenHeader)
 	}
 
 	return nil
 }
 
+// normalizeTimestamps drops empty series and samples and stamps samples
+// without a timestamp with the flush time. Samples keep the time of the
+// check that produced them.
 func (c *WriteClient) normalizeTimestamps(samples []*prompb.TimeSeries) []*prompb.TimeSeries {
 	batchTimestamp := time.Now().Truncate(time.Millisecond).UnixMilli()
 
@@ -189,7 +439,9 @@ func (c *WriteClient) normalizeTimestamps(samples []*prompb.TimeSeries) []*promp
 		validSamples := ts.Samples[:0]
 		for _, sample := range ts.Samples {
 			if sample != nil {
-				sample.Timestamp = batchTimestamp
+				if sample.Timestamp <= 0 {
+					sample.Timestamp = batchTimestamp
+				}
 				validSamples = append(validSamples, sample)
 			}
 		}
@@ -203,28 +455,13 @@ func (c *WriteClient) normalizeTimestamps(samples []*prompb.TimeSeries) []*promp
 	return validSeries
 }
 
-var _globalClient *WriteClient
-
-func InitRemoteWrite(cfg Config) {
-	_globalClient = NewWriteClient(cfg)
-	_globalClient.Start()
-}
-
-func StopRemoteWrite() {
-	if _globalClient != nil {
-		_globalClient.Stop()
-		_globalClient = nil
-	}
-}
-
-func RecordCheck(target config.Target, result net.WebsiteCheckResult, region string) {
-	if _globalClient != nil {
-		_globalClient.AddCheck(target, result, region)
-	}
-}
-
-func RecordSSLExpiry(target config.Target, daysUntilExpiry int) {
-	if _globalClient != nil {
-		_globalClient.AddSSLExpiry(target, daysUntilExpiry)
+func labelsKey(labels []*prompb.Label) string {
+	var builder strings.Builder
+	for _, label := range labels {
+		builder.WriteString(label.Name)
+		builder.WriteByte(0)
+		builder.WriteString(label.Value)
+		builder.WriteByte(0)
 	}
+	return builder.String()
 }
@@ -1,8 +1,10 @@
 package metrics
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -11,6 +13,8 @@ import (
 	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
 	"github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/net"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -18,9 +22,19 @@ const (
 	testPass = "pass"
 )
 
+func newTestWriteClient(t *testing.T, cfg Config) *WriteClient {
+	t.Helper()
+
+	client, err := NewWriteClient(cfg)
+	if err != nil {
+		t.Fatalf("NewWriteClient failed: %v", err)
+	}
+	return client
+}
+
 func TestWriteClient(t *testing.T) {
 	cfg := NewConfig()
-	client := NewWriteClient(cfg)
+	client := newTestWriteClient(t, cfg)
 
 	if client == nil || client.config.ServerURL != cfg.ServerURL ||
 		client.samples == nil || client.httpClient == nil {
@@ -28,7 +42,8 @@ func TestWriteClient(t *testing.T) {
 	}
 
 	target := config.Target{Name: "test", URL: "https://example.com"}
-	result := net.WebsiteCheckResult{URL: target.URL, IsUp: true, StatusCode: 200}
+	checkTime := time.Now().Add(-time.Minute)
+	result := net.WebsiteCheckResult{URL: target.URL, IsUp: true, StatusCode: 200, LastCheckTime: checkTime}
 
 	initialCount := len(client.samples)
 	client.AddCheck(target, result, "us-east-1")
@@ -37,18 +52,45 @@ func TestWriteClient(t *testing.T) {
 		t.Error("AddCheck failed to add samples")
 	}
 
-	zeroTimestamp := time.Time{}.UnixMilli()
 	for _, series := range client.samples {
 		for _, sample := range series.Samples {
-			if sample.Timestamp != zeroTimestamp {
-				t.Errorf("Expected placeholder timestamp %d, got %d", zeroTimestamp, sample.Timestamp)
+			if sample.Timestamp != checkTime.UnixMilli() {
+				t.Errorf("Expected check timestamp %d, got %d", checkTime.UnixMilli(), sample.Timestamp)
 			}
 		}
 	}
 }
 
+func TestWriteClient_AccumulatesCounters(t *testing.T) {
+	client := newTestWriteClient(t, NewConfig())
+	target := config.Target{Name: "test", URL: "https://example.com"}
+
+	for range 3 {
+		client.AddCheck(target, net.WebsiteCheckResult{IsUp: true, StatusCode: 200, ResponseTime: 100 * time.Millisecond}, "")
+	}
+
+	var values []float64
+	for _, series := range client.samples {
+		if seriesName(series.Labels) == "updo_http_status_code_total" {
+			values = append(values, series.Samples[0].Value)
+		}
+	}
+	if len(values) != 3 || values[0] != 1 || values[2] != 3 {
+		t.Errorf("Expected status code counter to count 1..3, got %v", values)
+	}
+
+	if len(client.histograms) != 1 {
+		t.Fatalf("Expected one latency histogram, got %d", len(client.histograms))
+	}
+	for _, histogram := range client.histograms {
+		if histogram.count != 3 || !histogram.updated {
+			t.Errorf("Expected 3 observations in the latency histogram, got %d", histogram.count)
+		}
+	}
+}
+
 func TestSSLExpiry(t *testing.T) {
-	client := NewWriteClient(NewConfig())
+	client := newTestWriteClient(t, NewConfig())
 	target := config.Target{Name: "ssl-test", URL: "https://example.com"}
 
 	tests := []struct {
@@ -69,7 +111,7 @@ func TestSSLExpiry(t *testing.T) {
 }
 
 func TestNormalizeTimestamps(t *testing.T) {
-	client := NewWriteClient(NewConfig())
+	client := newTestWriteClient(t, NewConfig())
 	samples := []*prompb.TimeSeries{
 		{
 			Labels:  []*prompb.Label{{Name: "__name__", Value: "test1"}},
@@ -77,7 +119,7 @@ func TestNormalizeTimestamps(t *testing.T) {
 		},
 		{
 			Labels:  []*prompb.Label{{Name: "__name__", Value: "test2"}},
-			Samples: []*prompb.Sample{{Timestamp: 0, Value: 3.0}},
+			Samples: []*prompb.Sample{{Timestamp: 1700000000000, Value: 3.0}},
 		},
 		nil,
 		{Labels: []*prompb.Label{{Name: "__name__", Value: "empty"}}, Samples: []*prompb.Sample{}},
@@ -95,23 +137,23 @@ func TestNormalizeTimestamps(t *testing.T) {
 		t.Errorf("Expected 3 valid series, got %d", len(normalized))
 	}
 
-	var batchTime int64
 	for i, series := range normalized {
 		for j, sample := range series.Samples {
+			if i == 1 {
+				if sample.Timestamp != 1700000000000 {
+					t.Errorf("Series %d sample %d: existing timestamp overwritten with %d", i, j, sample.Timestamp)
+				}
+				continue
+			}
 			if sample.Timestamp < before || sample.Timestamp > after {
 				t.Errorf("Series %d sample %d: timestamp out of range", i, j)
 			}
-			if batchTime == 0 {
-				batchTime = sample.Timestamp
-			} else if sample.Timestamp != batchTime {
-				t.Errorf("Inconsistent timestamps: expected %d, got %d", batchTime, sample.Timestamp)
-			}
 		}
 	}
 }
 
 func TestConcurrentAccess(t *testing.T) {
-	client := NewWriteClient(NewConfig())
+	client := newTestWriteClient(t, NewConfig())
 	target := config.Target{Name: "concurrent", URL: "https://example.com"}
 	result := net.WebsiteCheckResult{URL: target.URL, IsUp: true}
 
@@ -136,17 +178,22 @@ func TestConcurrentAccess(t *testing.T) {
 
 func TestHTTPRequests(t *testing.T) {
 	tests := []struct {
-		name   string
-		status int
-		body   string
-		auth   bool
-		header bool
-		expect bool
+		name     string
+		status   int
+		body     string
+		auth     bool
+		header   bool
+		protocol string
+		written  bool
+		expect   bool
 	}{
-		{"success", 200, "", false, false, true},
-		{"error", 400, "out of order", false, false, false},
-		{"auth", 200, "", true, false, true},
-		{"headers", 200, "", false, true, true},
+		{"success", 200, "", false, false, RemoteWriteV1, false, true},
+		{"error", 400, "out of order", false, false, RemoteWriteV1, false, false},
+		{"auth", 200, "", true, false, RemoteWriteV1, false, true},
+		{"headers", 200, "", false, true, RemoteWriteV1, false, true},
+		{"v2", 204, "", false, false, RemoteWriteV2, true, true},
+		{"v2 without written stats", 204, "", false, false, RemoteWriteV2, false, false},
+		{"v2 unsupported", 415, "", false, false, RemoteWriteV2, false, false},
 	}
 
 	for _, tt := range tests {
@@ -160,6 +207,12 @@ func TestHTTPRequests(t *testing.T) {
 				if tt.header && r.Header.Get("X-Test") != "value" {
 					t.Error("Header missing")
 				}
+				if tt.protocol == RemoteWriteV2 && r.Header.Get("Content-Type") != _contentTypeV2 {
+					t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+				}
+				if tt.written {
+					w.Header().Set(_samplesWrittenHeader, "1")
+				}
 				w.WriteHeader(tt.status)
 				_, _ = w.Write([]byte(tt.body))
 			}))
@@ -174,8 +227,8 @@ func TestHTTPRequests(t *testing.T) {
 				cfg.Headers = map[string]string{"X-Test": "value"}
 			}
 
-			client := NewWriteClient(cfg)
-			err := client.doRequest([]byte("data"))
+			client := newTestWriteClient(t, cfg)
+			err := client.doRequest([]byte("data"), tt.protocol)
 			success := err == nil
 
 			if success != tt.expect {
@@ -187,3 +240,56 @@ func TestHTTPRequests(t *testing.T) {
 		})
 	}
 }
+
+func TestSendSamples_FallsBackToV1(t *testing.T) {
+	var contentTypes []string
+	var received []*prompb.TimeSeries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		if r.Header.Get("Content-Type") == _contentTypeV2 {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		compressed, _ := io.ReadAll(r.Body)
+		var request prompb.WriteRequest
+		data, err := snappy.Decode(nil, compressed)
+		if err != nil || proto.Unmarshal(data, &request) != nil {
+			t.Errorf("Failed to decode remote write 1.0 body: %v", err)
+		}
+		received = append(received, request.Timeseries...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := NewConfig()
+	cfg.ServerURL = server.URL
+	client := newTestWriteClient(t, cfg)
+
+	checkTime := time.UnixMilli(1700000000000)
+	client.AddCheck(config.Target{Name: "test", URL: "https://example.com"},
+		net.WebsiteCheckResult{IsUp: true, LastCheckTime: checkTime}, "")
+	client.flushSamples()
+	client.drain()
+
+	if len(contentTypes) < 2 || contentTypes[0] != _contentTypeV2 || contentTypes[1] != _contentTypeV1 {
+		t.Fatalf("Expected a 2.0 attempt followed by 1.0, got %v", contentTypes)
+	}
+	if slices.Contains(contentTypes[1:], _contentTypeV2) {
+		t.Error("Client should stay on remote write 1.0 after a rejection")
+	}
+	if timestamps := sentTimestamps(received, "updo_target_up"); len(timestamps) != 1 || timestamps[0] != checkTime.UnixMilli() {
+		t.Errorf("Unexpected series resent over 1.0: %v", received)
+	}
+}
+
+func sentTimestamps(series []*prompb.TimeSeries, name string) []int64 {
+	var timestamps []int64
+	for _, ts := range series {
+		if seriesName(ts.Labels) == name {
+			for _, sample := range ts.Samples {
+				timestamps = append(timestamps, sample.Timestamp)
+			}
+		}
+	}
+	return timestamps
+}
@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,6 +12,15 @@ const (
 	_defaultTimeout      = 5 * time.Second
 	_defaultPushInterval = 5 * time.Second
 	_defaultMetricPrefix = "updo_"
+	_defaultShards       = 4
+	_defaultWALMaxSize   = 64 << 20
+)
+
+// Remote write protocol versions. Version 2.0 is tried first and the client
+// falls back to 1.0 when the receiver rejects it.
+const (
+	RemoteWriteV1 = "1.0"
+	RemoteWriteV2 = "2.0"
 )
 
 type Config struct {
@@ -17,6 +29,12 @@ type Config struct {
 	PushInterval time.Duration
 	Username     string
 	Password     string // #nosec G117 -- credentials for metrics push auth, never serialized
+	Protocol     string
+	// WALDir keeps samples that could not be sent yet on disk so they
+	// survive restarts. Without it they are only buffered in memory.
+	WALDir     string
+	WALMaxSize int64
+	Shards     int
 }
 
 func NewConfig() Config {
@@ -24,5 +42,61 @@ func NewConfig() Config {
 		ServerURL:    _defaultServerURL,
 		PushInterval: _defaultPushInterval,
 		Headers:      make(map[string]string),
+		Protocol:     RemoteWriteV2,
+		WALMaxSize:   _defaultWALMaxSize,
+		Shards:       _defaultShards,
 	}
 }
+
+const (
+	OTLPProtocolHTTP = "http/protobuf"
+	OTLPProtocolGRPC = "grpc"
+)
+
+type OTLPConfig struct {
+	// Endpoint is either a URL such as http://localhost:4318, whose scheme
+	// decides whether TLS is used, or a bare host:port.
+	Endpoint     string
+	Protocol     string
+	Headers      map[string]string
+	Insecure     bool
+	PushInterval time.Duration
+}
+
+// NewOTLPConfig builds an OTLP configuration from the given flag values,
+// falling back to the UPDO_OTLP_* environment variables.
+func NewOTLPConfig(endpoint, protocol string) OTLPConfig {
+	cfg := OTLPConfig{
+		Endpoint:     endpoint,
+		Protocol:     protocol,
+		PushInterval: _defaultPushInterval,
+		Headers:      make(map[string]string),
+	}
+
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = os.Getenv("UPDO_OTLP_ENDPOINT")
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = os.Getenv("UPDO_OTLP_PROTOCOL")
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = OTLPProtocolHTTP
+	}
+	if headers := os.Getenv("UPDO_OTLP_HEADERS"); headers != "" {
+		for pair := range strings.SplitSeq(headers, ",") {
+			if key, value, found := strings.Cut(pair, "="); found {
+				cfg.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if insecure, err := strconv.ParseBool(os.Getenv("UPDO_OTLP_INSECURE")); err == nil {
+		cfg.Insecure = insecure
+	}
+	if pushInterval := os.Getenv("UPDO_OTLP_PUSH_INTERVAL"); pushInterval != "" {
+		if duration, err := time.ParseDuration(pushInterval); err == nil {
+			cfg.PushInterval = duration
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+const (
+	_exposeContentType = "text/plain; version=0.0.4; charset=utf-8"
+	_counterSuffix     = "_total"
+)
+
+var _metricHelp = map[string]string{
+	"updo_target_up":                  "Target availability (1 = up, 0 = down)",
+	"updo_response_time_seconds":      "Total response time in seconds",
+	"updo_check_duration_seconds":     "Distribution of total response times",
+	"updo_http_status_code_total":     "HTTP status codes received",
+	"updo_wait_seconds":               "Time waiting before DNS lookup",
+	"updo_dns_lookup_seconds":         "DNS resolution time",
+	"updo_tcp_connection_seconds":     "TCP connection establishment time",
+	"updo_time_to_first_byte_seconds": "Server processing time (TTFB)",
+	"updo_download_duration_seconds":  "Content transfer time",
+	"updo_assertion_passed":           "Text assertion result (1 = passed, 0 = failed)",
+	"updo_ssl_cert_expiry_days":       "Days until SSL certificate expires",
+	"updo_apdex_score":                "Apdex score since monitoring started",
+	"updo_checks_total":               "Checks performed",
+	"updo_check_failures_total":       "Checks that found the target down",
+
+	"updo_remote_write_pending_samples":       "Samples waiting to be sent with remote write",
+	"updo_remote_write_failures_total":        "Failed remote write pushes",
+	"updo_remote_write_dropped_samples_total": "Samples dropped because the buffer was full or the receiver refused them",
+}
+
+var _labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type exposedSample struct {
+	name   string
+	labels string
+	value  float64
+}
+
+// Exporter keeps the latest value of every series derived from check
+// results and serves them in the Prometheus text exposition format.
+// Gauges are replaced by each new observation of the same target key while
+// series whose names end in _total accumulate.
+type Exporter struct {
+	mu       sync.RWMutex
+	gauges   map[string]map[string]exposedSample
+	counters map[string]map[string]exposedSample
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{
+		gauges:   make(map[string]map[string]exposedSample),
+		counters: make(map[string]map[string]exposedSample),
+	}
+}
+
+func (e *Exporter) AddCheck(target config.Target, result net.WebsiteCheckResult, region string) {
+	series := ConvertCheckToTimeSeries(target, result, region, time.Time{})
+
+	labels := MapTargetLabels(target, result, region)
+	series = append(series, &prompb.TimeSeries{
+		Labels:  MapSeries("checks_total", labels),
+		Samples: []*prompb.Sample{{Value: 1}},
+	})
+	if !result.IsUp {
+		series = append(series, &prompb.TimeSeries{
+			Labels:  MapSeries("check_failures_total", labels),
+			Samples: []*prompb.Sample{{Value: 1}},
+		})
+	}
+
+	e.observe(exposeGroup("check", target, region), series...)
+}
+
+func (e *Exporter) AddSSLExpiry(target config.Target, daysUntilExpiry int) {
+	if ts := ConvertSSLExpiryToTimeSeries(target, daysUntilExpiry, time.Time{}); ts != nil {
+		e.observe(exposeGroup("ssl", target, ""), ts)
+	}
+}
+
+func (e *Exporter) AddApdex(target config.Target, score float64, region string) {
+	e.observe(exposeGroup("apdex", target, region), ConvertApdexToTimeSeries(target, score, region, time.Time{}))
+}
+
+func (e *Exporter) observe(group string, series ...*prompb.TimeSeries) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	gauges := make(map[string]exposedSample, len(series))
+	counters := e.counters[group]
+	for _, ts := range series {
+		if ts == nil || len(ts.Samples) == 0 {
+			continue
+		}
+
+		sample := toExposedSample(ts)
+		key := sample.name + sample.labels
+
+		if strings.HasSuffix(sample.name, _counterSuffix) {
+			if counters == nil {
+				counters = make(map[string]exposedSample)
+				e.counters[group] = counters
+			}
+			if existing, exists := counters[key]; exists {
+				sample.value += existing.value
+			}
+			counters[key] = sample
+			continue
+		}
+		gauges[key] = sample
+	}
+
+	e.gauges[group] = gauges
+}
+
+// Retain drops the series of every target and region not among targets,
+// such as those removed by a config reload, so scrapes stop reporting
+// them. Targets are checked from their own regions, else from regions,
+// else locally.
+func (e *Exporter) Retain(targets []config.Target, regions []string) {
+	kept := make(map[string]bool, 3*len(targets))
+	for _, target := range targets {
+		targetRegions := target.Regions
+		if len(targetRegions) == 0 {
+			targetRegions = regions
+		}
+		if len(targetRegions) == 0 {
+			targetRegions = []string{""}
+		}
+		for _, region := range targetRegions {
+			kept[exposeGroup("check", target, region)] = true
+			kept[exposeGroup("apdex", target, region)] = true
+		}
+		kept[exposeGroup("ssl", target, "")] = true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for group := range e.gauges {
+		if !kept[group] {
+			delete(e.gauges, group)
+		}
+	}
+	for group := range e.counters {
+		if !kept[group] {
+			delete(e.counters, group)
+		}
+	}
+}
+
+// WriteTo renders all series grouped into metric families, sorted by name
+// and labels so scrapes are stable.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.RLock()
+	var samples []exposedSample
+	for _, group := range e.gauges {
+		for _, sample := range group {
+			samples = append(samples, sample)
+		}
+	}
+	for _, group := range e.counters {
+		for _, sample := range group {
+			samples = append(samples, sample)
+		}
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].name != samples[j].name {
+			return samples[i].name < samples[j].name
+		}
+		return samples[i].labels < samples[j].labels
+	})
+
+	var builder strings.Builder
+	for i, sample := range samples {
+		if i == 0 || samples[i-1].name != sample.name {
+			if help, exists := _metricHelp[sample.name]; exists {
+				fmt.Fprintf(&builder, "# HELP %s %s\n", sample.name, help)
+			}
+			fmt.Fprintf(&builder, "# TYPE %s %s\n", sample.name, metricType(sample.name))
+		}
+		fmt.Fprintf(&builder, "%s%s %s\n", sample.name, sample.labels, strconv.FormatFloat(sample.value, 'g', -1, 64))
+	}
+
+	n, err := io.WriteString(w, builder.String())
+	return int64(n), err
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", _exposeContentType)
+	_, _ = e.WriteTo(w)
+}
+
+func toExposedSample(ts *prompb.TimeSeries) exposedSample {
+	sample := exposedSample{value: ts.Samples[len(ts.Samples)-1].Value}
+
+	var labels []string
+	for _, label := range ts.Labels {
+		if label.Name == _nameLbl {
+			sample.name = label.Value
+			continue
+		}
+		labels = append(labels, label.Name+`="`+_labelValueEscaper.Replace(label.Value)+`"`)
+	}
+	if len(labels) > 0 {
+		sample.labels = "{" + strings.Join(labels, ",") + "}"
+	}
+
+	return sample
+}
+
+func metricType(name string) string {
+	if strings.HasSuffix(name, _counterSuffix) {
+		return "counter"
+	}
+	return "gauge"
+}
+
+func exposeGroup(kind string, target config.Target, region string) string {
+	return strings.Join([]string{kind, target.Name, target.URL, region}, "\x00")
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+func TestExporter_AddCheck(t *testing.T) {
+	target := config.Target{Name: "service", URL: "https://example.com"}
+	exporter := NewExporter()
+
+	exporter.AddCheck(target, net.WebsiteCheckResult{
+		IsUp:         true,
+		StatusCode:   200,
+		ResponseTime: 150 * time.Millisecond,
+		TraceInfo:    &net.HttpTraceInfo{DNSLookup: 10 * time.Millisecond},
+	}, "us-east-1")
+	exporter.AddCheck(target, net.WebsiteCheckResult{IsUp: false, StatusCode: 503}, "us-east-1")
+	exporter.AddSSLExpiry(target, 42)
+
+	var builder strings.Builder
+	if _, err := exporter.WriteTo(&builder); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	output := builder.String()
+
+	labels := `{name="service",region="us-east-1",url="https://example.com"}`
+	expected := []string{
+		"# TYPE updo_target_up gauge",
+		"updo_target_up" + labels + " 0",
+		"# TYPE updo_checks_total counter",
+		"updo_checks_total" + labels + " 2",
+		"updo_check_failures_total" + labels + " 1",
+		`updo_http_status_code_total{name="service",region="us-east-1",status_code="200",url="https://example.com"} 1`,
+		`updo_http_status_code_total{name="service",region="us-east-1",status_code="503",url="https://example.com"} 1`,
+		`updo_ssl_cert_expiry_days{name="service",url="https://example.com"} 42`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("Expected output to contain %q\n%s", want, output)
+		}
+	}
+
+	stale := []string{"updo_response_time_seconds", "updo_dns_lookup_seconds"}
+	for _, name := range stale {
+		if strings.Contains(output, name) {
+			t.Errorf("Gauge %s from the previous check should have been replaced", name)
+		}
+	}
+}
+
+func TestExporter_EscapesLabelValues(t *testing.T) {
+	exporter := NewExporter()
+	exporter.AddCheck(config.Target{Name: "quote\"back\\slash\nline", URL: "https://example.com"},
+		net.WebsiteCheckResult{IsUp: true}, "")
+
+	var builder strings.Builder
+	if _, err := exporter.WriteTo(&builder); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+
+	want := `name="quote\"back\\slash\nline"`
+	if !strings.Contains(builder.String(), want) {
+		t.Errorf("Expected escaped label %s in\n%s", want, builder.String())
+	}
+}
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	exporter := NewExporter()
+	exporter.AddCheck(config.Target{Name: "service", URL: "https://example.com"},
+		net.WebsiteCheckResult{IsUp: true, StatusCode: 200}, "")
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Unexpected content type %q", contentType)
+	}
+	if !strings.Contains(recorder.Body.String(), `updo_target_up{name="service",url="https://example.com"} 1`) {
+		t.Errorf("Unexpected body:\n%s", recorder.Body.String())
+	}
+}
+
+func TestExporter_Retain(t *testing.T) {
+	api := config.Target{Name: "api", URL: "https://api.example.com"}
+	web := config.Target{Name: "web", URL: "https://web.example.com", Regions: []string{"us-east-1", "eu-west-1"}}
+	exporter := NewExporter()
+
+	exporter.AddCheck(api, net.WebsiteCheckResult{IsUp: true, StatusCode: 200}, "")
+	exporter.AddApdex(api, 1, "")
+	exporter.AddSSLExpiry(api, 30)
+	exporter.AddCheck(web, net.WebsiteCheckResult{IsUp: true, StatusCode: 200}, "us-east-1")
+	exporter.AddCheck(web, net.WebsiteCheckResult{IsUp: true, StatusCode: 200}, "eu-west-1")
+
+	web.Regions = []string{"us-east-1"}
+	exporter.Retain([]config.Target{web}, nil)
+
+	var builder strings.Builder
+	if _, err := exporter.WriteTo(&builder); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	output := builder.String()
+
+	if want := `updo_checks_total{name="web",region="us-east-1",url="https://web.example.com"} 1`; !strings.Contains(output, want+"\n") {
+		t.Errorf("Expected output to contain %q\n%s", want, output)
+	}
+	for _, removed := range []string{`name="api"`, `region="eu-west-1"`} {
+		if strings.Contains(output, removed) {
+			t.Errorf("Series with %s should have been dropped\n%s", removed, output)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/Owloops/updo/config"
+)
+
+const _defaultGraphitePrefix = "updo"
+
+// graphiteConn is a plaintext protocol connection that is dialled lazily
+// and dropped on write errors so the next flush reconnects.
+type graphiteConn struct {
+	address string
+	mu      sync.Mutex
+	conn    net.Conn
+}
+
+func (g *graphiteConn) write(data []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		conn, err := net.DialTimeout("tcp", g.address, _dialTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Graphite at %s: %w", g.address, err)
+		}
+		g.conn = conn
+	}
+
+	if _, err := g.conn.Write(data); err != nil {
+		_ = g.conn.Close()
+		g.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (g *graphiteConn) close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
+
+// NewGraphiteSink sends samples to Carbon over TCP using the plaintext
+// protocol.
+func NewGraphiteSink(cfg config.GraphiteSink) (Sink, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("graphite sink requires an address")
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = _defaultGraphitePrefix
+	}
+
+	conn := &graphiteConn{address: cfg.Address}
+	write := func(samples []sample) error {
+		return conn.write(encodeGraphite(prefix, samples))
+	}
+
+	return newBufferedSink(config.SinkGraphite, cfg.FlushInterval, write, conn.close), nil
+}
+
+func encodeGraphite(prefix string, samples []sample) []byte {
+	var buf bytes.Buffer
+	for _, s := range samples {
+		fmt.Fprintf(&buf, "%s %s %d\n", dottedPath(prefix, s), strconv.FormatFloat(s.value, 'f', -1, 64), s.timestamp.Unix())
+	}
+	return buf.Bytes()
+}
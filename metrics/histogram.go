@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"maps"
+	"math"
+	"slices"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	writev2 "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go/io/prometheus/write/v2"
+)
+
+const (
+	// _histogramSchema 3 splits every power of two into 8 buckets, which
+	// keeps the relative error of a latency quantile around 4%.
+	_histogramSchema        = 3
+	_histogramZeroThreshold = 1e-9
+	_latencyHistogramName   = "check_duration_seconds"
+)
+
+// nativeHistogram accumulates observations into the exponential buckets of
+// a Prometheus native histogram. Counts are cumulative since the client
+// started, like a histogram exposed on a scrape endpoint.
+type nativeHistogram struct {
+	labels    []*prompb.Label
+	count     uint64
+	zeroCount uint64
+	sum       float64
+	buckets   map[int32]uint64
+	timestamp int64
+	updated   bool
+}
+
+func newNativeHistogram(labels []*prompb.Label) *nativeHistogram {
+	return &nativeHistogram{
+		labels:  labels,
+		buckets: make(map[int32]uint64),
+	}
+}
+
+func (h *nativeHistogram) observe(value float64, timestamp int64) {
+	h.count++
+	h.sum += value
+	h.timestamp = timestamp
+	h.updated = true
+
+	if value <= _histogramZeroThreshold {
+		h.zeroCount++
+		return
+	}
+	h.buckets[bucketIndex(value, _histogramSchema)]++
+}
+
+// bucketIndex returns the index of the bucket (base^(i-1), base^i] holding
+// value, where base is 2^(2^-schema).
+func bucketIndex(value float64, schema int32) int32 {
+	return int32(math.Ceil(math.Log2(value) * math.Exp2(float64(schema))))
+}
+
+// snapshot encodes the current state as a remote write 2.0 histogram with
+// the populated buckets as spans and delta-encoded counts.
+func (h *nativeHistogram) snapshot() *writev2.Histogram {
+	histogram := &writev2.Histogram{
+		Count:         &writev2.Histogram_CountInt{CountInt: h.count},
+		Sum:           h.sum,
+		Schema:        _histogramSchema,
+		ZeroThreshold: _histogramZeroThreshold,
+		ZeroCount:     &writev2.Histogram_ZeroCountInt{ZeroCountInt: h.zeroCount},
+		Timestamp:     h.timestamp,
+	}
+
+	var previousIndex int32
+	var previousCount int64
+	for i, index := range slices.Sorted(maps.Keys(h.buckets)) {
+		switch {
+		case i == 0:
+			histogram.PositiveSpans = append(histogram.PositiveSpans, &writev2.BucketSpan{Offset: index, Length: 1})
+		case index == previousIndex+1:
+			histogram.PositiveSpans[len(histogram.PositiveSpans)-1].Length++
+		default:
+			histogram.PositiveSpans = append(histogram.PositiveSpans, &writev2.BucketSpan{Offset: index - previousIndex - 1, Length: 1})
+		}
+
+		count := int64(h.buckets[index]) // #nosec G115 -- bucket counts stay far below math.MaxInt64
+		histogram.PositiveDeltas = append(histogram.PositiveDeltas, count-previousCount)
+		previousIndex, previousCount = index, count
+	}
+
+	return histogram
+}
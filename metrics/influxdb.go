@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Owloops/updo/config"
+)
+
+const _influxWritePath = "/api/v2/write"
+
+var (
+	_influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	_influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// NewInfluxDBSink writes samples to an InfluxDB v2 bucket using line
+// protocol, one measurement per metric with its labels as tags.
+func NewInfluxDBSink(cfg config.InfluxDBSink) (Sink, error) {
+	if cfg.URL == "" || cfg.Org == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("influxdb sink requires url, org and bucket")
+	}
+
+	writeURL, err := url.Parse(strings.TrimSuffix(cfg.URL, "/") + _influxWritePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid InfluxDB URL %q: %w", cfg.URL, err)
+	}
+	query := writeURL.Query()
+	query.Set("org", cfg.Org)
+	query.Set("bucket", cfg.Bucket)
+	query.Set("precision", "ms")
+	writeURL.RawQuery = query.Encode()
+
+	client := &http.Client{Timeout: _httpTimeout}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	write := func(samples []sample) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, writeURL.String(), bytes.NewReader(encodeLineProtocol(samples)))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		if cfg.Token != "" {
+			req.Header.Set("Authorization", "Token "+cfg.Token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("HTTP request failed: %w", err)
+		}
+		defer func() {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("server responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return nil
+	}
+
+	closeFn := func() error {
+		cancel()
+		return nil
+	}
+
+	return newBufferedSink(config.SinkInfluxDB, cfg.FlushInterval, write, closeFn), nil
+}
+
+func encodeLineProtocol(samples []sample) []byte {
+	var buf bytes.Buffer
+	for _, s := range samples {
+		buf.WriteString(_influxMeasurementEscaper.Replace(s.name))
+
+		keys := make([]string, 0, len(s.labels))
+		for key := range s.labels {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&buf, ",%s=%s", _influxTagEscaper.Replace(key), _influxTagEscaper.Replace(s.labels[key]))
+		}
+
+		fmt.Fprintf(&buf, " value=%s %d\n", strconv.FormatFloat(s.value, 'g', -1, 64), s.timestamp.UnixMilli())
+	}
+	return buf.Bytes()
+}
@@ -10,6 +10,7 @@ import (
 	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
 	"github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 )
 
 const _nameLbl = "__name__"
@@ -20,14 +21,24 @@ type TimeSeries struct {
 }
 
 func MapTargetLabels(target config.Target, result net.WebsiteCheckResult, region string) map[string]string {
-	labels := make(map[string]string)
-	labels["name"] = target.Name
-	labels["url"] = target.URL
+	labels := targetLabels(target)
 	labels["region"] = region
 	return labels
 }
 
+// targetLabels returns the custom labels of target along with its name and
+// URL, which take precedence over custom labels of the same name.
+func targetLabels(target config.Target) map[string]string {
+	labels := make(map[string]string, len(target.Labels)+3)
+	maps.Copy(labels, target.Labels)
+	labels["name"] = target.Name
+	labels["url"] = redact.URL(target.URL)
+	return labels
+}
+
+// MapSeries builds the label set of a series, applying the relabel rules.
 func MapSeries(name string, labels map[string]string) []*prompb.Label {
+	labels = relabel(labels)
 	pbLabels := make([]*prompb.Label, 0, len(labels)+1)
 
 	pbLabels = append(pbLabels, &prompb.Label{
@@ -149,12 +160,8 @@ func ConvertSSLExpiryToTimeSeries(target config.Target, daysUntilExpiry int, tim
 		return nil
 	}
 
-	labels := make(map[string]string)
-	labels["name"] = target.Name
-	labels["url"] = target.URL
-
 	return &prompb.TimeSeries{
-		Labels: MapSeries("ssl_cert_expiry_days", labels),
+		Labels: MapSeries("ssl_cert_expiry_days", targetLabels(target)),
 		Samples: []*prompb.Sample{
 			{
 				Timestamp: timestamp.UnixMilli(),
@@ -163,3 +170,15 @@ func ConvertSSLExpiryToTimeSeries(target config.Target, daysUntilExpiry int, tim
 		},
 	}
 }
+
+func ConvertApdexToTimeSeries(target config.Target, score float64, region string, timestamp time.Time) *prompb.TimeSeries {
+	return &prompb.TimeSeries{
+		Labels: MapSeries("apdex_score", MapTargetLabels(target, net.WebsiteCheckResult{}, region)),
+		Samples: []*prompb.Sample{
+			{
+				Timestamp: timestamp.UnixMilli(),
+				Value:     score,
+			},
+		},
+	}
+}
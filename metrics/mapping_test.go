@@ -170,3 +170,24 @@ func TestConvertSSLExpiryToTimeSeries(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertApdexToTimeSeries(t *testing.T) {
+	target := config.Target{Name: "apdex-test", URL: "https://example.com"}
+
+	result := ConvertApdexToTimeSeries(target, 0.875, "us-east-1", time.Now())
+
+	if len(result.Samples) != 1 || result.Samples[0].Value != 0.875 {
+		t.Errorf("Expected a single sample with value 0.875, got %v", result.Samples)
+	}
+
+	labels := make(map[string]string)
+	for _, label := range result.Labels {
+		labels[label.Name] = label.Value
+	}
+	if labels["__name__"] != "updo_apdex_score" {
+		t.Errorf("Expected metric name updo_apdex_score, got %q", labels["__name__"])
+	}
+	if labels["region"] != "us-east-1" {
+		t.Errorf("Expected region label us-east-1, got %q", labels["region"])
+	}
+}
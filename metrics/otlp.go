@@ -0,0 +1,389 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_otlpServiceName  = "updo"
+	_otlpScopeName    = "github.com/Owloops/updo"
+	_otlpMetricsPath  = "/v1/metrics"
+	_otlpTracesPath   = "/v1/traces"
+	_otlpInitTimeout  = 10 * time.Second
+	_otlpFlushTimeout = 5 * time.Second
+
+	_targetNameKey = attribute.Key("updo.target.name")
+	_checkPhaseKey = attribute.Key("updo.check.phase")
+)
+
+// OTLPExporter records check results as OpenTelemetry metrics and as one
+// client span per check with a child span for every measured phase.
+type OTLPExporter struct {
+	tracer trace.Tracer
+
+	requestDuration metric.Float64Histogram
+	phaseDuration   metric.Float64Histogram
+	checks          metric.Int64Counter
+	failures        metric.Int64Counter
+	targetUp        metric.Int64Gauge
+	sslExpiry       metric.Int64Gauge
+	apdexScore      metric.Float64Gauge
+
+	shutdown func(context.Context) error
+}
+
+func NewOTLPExporter(meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (*OTLPExporter, error) {
+	meter := meterProvider.Meter(_otlpScopeName)
+	e := &OTLPExporter{tracer: tracerProvider.Tracer(_otlpScopeName)}
+
+	var err, instErr error
+	e.requestDuration, instErr = meter.Float64Histogram("http.client.request.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of HTTP client requests"))
+	err = errors.Join(err, instErr)
+	e.phaseDuration, instErr = meter.Float64Histogram("updo.check.phase.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of each phase of a check"))
+	err = errors.Join(err, instErr)
+	e.checks, instErr = meter.Int64Counter("updo.checks",
+		metric.WithUnit("{check}"), metric.WithDescription("Checks performed"))
+	err = errors.Join(err, instErr)
+	e.failures, instErr = meter.Int64Counter("updo.check.failures",
+		metric.WithUnit("{check}"), metric.WithDescription("Checks that found the target down"))
+	err = errors.Join(err, instErr)
+	e.targetUp, instErr = meter.Int64Gauge("updo.target.up",
+		metric.WithDescription("Target availability (1 = up, 0 = down)"))
+	err = errors.Join(err, instErr)
+	e.sslExpiry, instErr = meter.Int64Gauge("updo.ssl.cert.expiry",
+		metric.WithUnit("d"), metric.WithDescription("Days until SSL certificate expires"))
+	err = errors.Join(err, instErr)
+	e.apdexScore, instErr = meter.Float64Gauge("updo.apdex.score",
+		metric.WithDescription("Apdex score since monitoring started"))
+	err = errors.Join(err, instErr)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP instruments: %w", err)
+	}
+	return e, nil
+}
+
+func (e *OTLPExporter) AddCheck(target config.Target, result net.WebsiteCheckResult, region string) {
+	ctx := context.Background()
+	attrs := checkAttributes(target, result, region)
+	attrSet := metric.WithAttributes(attrs...)
+
+	e.checks.Add(ctx, 1, attrSet)
+	up := int64(1)
+	if !result.IsUp {
+		up = 0
+		e.failures.Add(ctx, 1, attrSet)
+	}
+	e.targetUp.Record(ctx, up, attrSet)
+	if result.ResponseTime > 0 {
+		e.requestDuration.Record(ctx, result.ResponseTime.Seconds(), attrSet)
+	}
+
+	end := result.LastCheckTime.Add(result.ResponseTime)
+	if result.LastCheckTime.IsZero() {
+		end = time.Now()
+	}
+	start := end.Add(-result.ResponseTime)
+
+	ctx, span := e.tracer.Start(ctx, spanName(result),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if result.ResolvedIP != "" {
+		span.SetAttributes(semconv.NetworkPeerAddress(result.ResolvedIP))
+	}
+	if !result.IsUp {
+		span.SetStatus(codes.Error, checkError(result))
+	}
+
+	for _, phase := range checkPhases(result, start) {
+		phaseAttrs := slices.Concat(attrs, []attribute.KeyValue{_checkPhaseKey.String(phase.name)})
+		e.phaseDuration.Record(ctx, phase.duration().Seconds(), metric.WithAttributes(phaseAttrs...))
+
+		_, child := e.tracer.Start(ctx, phase.name,
+			trace.WithTimestamp(phase.start),
+			trace.WithAttributes(_checkPhaseKey.String(phase.name)),
+		)
+		child.End(trace.WithTimestamp(phase.end))
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
+
+func (e *OTLPExporter) AddSSLExpiry(target config.Target, daysUntilExpiry int) {
+	if daysUntilExpiry < 0 {
+		return
+	}
+	attrs := append([]attribute.KeyValue{_targetNameKey.String(target.Name), semconv.URLFull(redact.URL(target.URL))}, labelAttributes(target)...)
+	e.sslExpiry.Record(context.Background(), int64(daysUntilExpiry), metric.WithAttributes(attrs...))
+}
+
+func (e *OTLPExporter) AddApdex(target config.Target, score float64, region string) {
+	attrs := []attribute.KeyValue{_targetNameKey.String(target.Name), semconv.URLFull(redact.URL(target.URL))}
+	if region != "" {
+		attrs = append(attrs, semconv.CloudRegion(region))
+	}
+	attrs = append(attrs, labelAttributes(target)...)
+	e.apdexScore.Record(context.Background(), score, metric.WithAttributes(attrs...))
+}
+
+// Shutdown flushes pending metrics and spans to the collector.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	if e.shutdown == nil {
+		return nil
+	}
+	return e.shutdown(ctx)
+}
+
+type checkPhase struct {
+	name       string
+	start, end time.Time
+}
+
+func (p checkPhase) duration() time.Duration {
+	return p.end.Sub(p.start)
+}
+
+// checkPhases lays the measured phases out back to back from start. Phases
+// that did not happen, such as DNS for IP targets or TLS for plain HTTP,
+// are skipped along with values the trace hooks could not measure.
+func checkPhases(result net.WebsiteCheckResult, start time.Time) []checkPhase {
+	info := result.TraceInfo
+	if info == nil {
+		return nil
+	}
+
+	valid := func(d time.Duration) bool {
+		return d > 0 && d <= result.ResponseTime
+	}
+
+	cursor := start
+	if valid(info.Wait) {
+		cursor = cursor.Add(info.Wait)
+	}
+
+	connect := info.TCPConnection
+	tls := info.TLSHandshake
+	if valid(tls) && tls <= connect {
+		connect -= tls
+	} else {
+		tls = 0
+	}
+
+	var phases []checkPhase
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"dns", info.DNSLookup},
+		{"connect", connect},
+		{"tls", tls},
+		{"ttfb", info.TimeToFirstByte},
+		{"download", info.DownloadDuration},
+	} {
+		if !valid(phase.duration) {
+			continue
+		}
+		phases = append(phases, checkPhase{name: phase.name, start: cursor, end: cursor.Add(phase.duration)})
+		cursor = cursor.Add(phase.duration)
+	}
+
+	return phases
+}
+
+func checkAttributes(target config.Target, result net.WebsiteCheckResult, region string) []attribute.KeyValue {
+	method := result.Method
+	if method == "" {
+		method = "GET"
+	}
+
+	attrs := []attribute.KeyValue{
+		_targetNameKey.String(target.Name),
+		semconv.HTTPRequestMethodKey.String(method),
+		semconv.URLFull(redact.URL(target.URL)),
+	}
+	if parsed, err := url.Parse(target.URL); err == nil && parsed.Hostname() != "" {
+		attrs = append(attrs, semconv.ServerAddress(parsed.Hostname()))
+	}
+	if result.StatusCode > 0 {
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(result.StatusCode))
+	}
+	if !result.IsUp {
+		attrs = append(attrs, semconv.ErrorTypeKey.String(errorType(result)))
+	}
+	if region != "" {
+		attrs = append(attrs, semconv.CloudRegion(region))
+	}
+	return append(attrs, labelAttributes(target)...)
+}
+
+// labelAttributes turns the custom labels of target into attributes. The
+// relabel rules apply to them, the semantic convention attributes are kept.
+func labelAttributes(target config.Target) []attribute.KeyValue {
+	labels := relabel(target.Labels)
+	attrs := make([]attribute.KeyValue, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		attrs = append(attrs, attribute.String(name, labels[name]))
+	}
+	return attrs
+}
+
+func spanName(result net.WebsiteCheckResult) string {
+	if result.Method == "" {
+		return "GET"
+	}
+	return result.Method
+}
+
+func errorType(result net.WebsiteCheckResult) string {
+	if result.StatusCode > 0 {
+		return strconv.Itoa(result.StatusCode)
+	}
+	return semconv.ErrorTypeOther.Value.AsString()
+}
+
+func checkError(result net.WebsiteCheckResult) string {
+	if result.StatusCode > 0 {
+		return fmt.Sprintf("Status code: %d", result.StatusCode)
+	}
+	return "Request failed"
+}
+
+// NewOTLPExporterFromConfig connects metric and trace exporters for the
+// configured protocol and endpoint.
+func NewOTLPExporterFromConfig(cfg OTLPConfig) (*OTLPExporter, error) {
+	endpoint, basePath, insecure, err := parseOTLPEndpoint(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	insecure = insecure || cfg.Insecure
+
+	ctx, cancel := context.WithTimeout(context.Background(), _otlpInitTimeout)
+	defer cancel()
+
+	var metricExporter sdkmetric.Exporter
+	var traceExporter sdktrace.SpanExporter
+
+	switch cfg.Protocol {
+	case OTLPProtocolHTTP:
+		metricOpts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(endpoint),
+			otlpmetrichttp.WithURLPath(basePath + _otlpMetricsPath),
+			otlpmetrichttp.WithHeaders(cfg.Headers),
+		}
+		traceOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint),
+			otlptracehttp.WithURLPath(basePath + _otlpTracesPath),
+			otlptracehttp.WithHeaders(cfg.Headers),
+		}
+		if insecure {
+			metricOpts = append(metricOpts, otlpmetrichttp.WithInsecure())
+			traceOpts = append(traceOpts, otlptracehttp.WithInsecure())
+		}
+		if metricExporter, err = otlpmetrichttp.New(ctx, metricOpts...); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		if traceExporter, err = otlptracehttp.New(ctx, traceOpts...); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+
+	case OTLPProtocolGRPC:
+		metricOpts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(endpoint),
+			otlpmetricgrpc.WithHeaders(cfg.Headers),
+		}
+		traceOpts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithHeaders(cfg.Headers),
+		}
+		if insecure {
+			metricOpts = append(metricOpts, otlpmetricgrpc.WithInsecure())
+			traceOpts = append(traceOpts, otlptracegrpc.WithInsecure())
+		}
+		if metricExporter, err = otlpmetricgrpc.New(ctx, metricOpts...); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+		}
+		if traceExporter, err = otlptracegrpc.New(ctx, traceOpts...); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (use %s or %s)", cfg.Protocol, OTLPProtocolHTTP, OTLPProtocolGRPC)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(_otlpServiceName))
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter, sdkmetric.WithInterval(cfg.PushInterval))),
+	)
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(traceExporter),
+	)
+
+	exporter, err := NewOTLPExporter(meterProvider, tracerProvider)
+	if err != nil {
+		return nil, err
+	}
+	exporter.shutdown = func(ctx context.Context) error {
+		return errors.Join(meterProvider.Shutdown(ctx), tracerProvider.Shutdown(ctx))
+	}
+	return exporter, nil
+}
+
+func parseOTLPEndpoint(endpoint string) (hostPort, basePath string, insecure bool, err error) {
+	if !strings.Contains(endpoint, "://") {
+		return endpoint, "", false, nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+	switch parsed.Scheme {
+	case "http":
+		insecure = true
+	case "https":
+	default:
+		return "", "", false, fmt.Errorf("invalid OTLP endpoint %q: scheme must be http or https", endpoint)
+	}
+
+	return parsed.Host, strings.TrimSuffix(parsed.Path, "/"), insecure, nil
+}
+
+// Stop flushes pending metrics and spans, giving up after a few seconds
+// if the collector is unreachable.
+func (e *OTLPExporter) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), _otlpFlushTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		logf(err, "Error flushing OTLP telemetry")
+	}
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestOTLPExporter(t *testing.T) (*OTLPExporter, *sdkmetric.ManualReader, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	recorder := tracetest.NewSpanRecorder()
+	exporter, err := NewOTLPExporter(
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	)
+	if err != nil {
+		t.Fatalf("NewOTLPExporter failed: %v", err)
+	}
+	return exporter, reader, recorder
+}
+
+func TestOTLPExporter_AddCheck(t *testing.T) {
+	exporter, reader, recorder := newTestOTLPExporter(t)
+	target := config.Target{Name: "service", URL: "https://example.com/health"}
+	start := time.Now()
+
+	exporter.AddCheck(target, net.WebsiteCheckResult{
+		IsUp:          true,
+		StatusCode:    200,
+		Method:        "GET",
+		ResponseTime:  100 * time.Millisecond,
+		LastCheckTime: start,
+		TraceInfo: &net.HttpTraceInfo{
+			DNSLookup:        10 * time.Millisecond,
+			TCPConnection:    30 * time.Millisecond,
+			TLSHandshake:     20 * time.Millisecond,
+			TimeToFirstByte:  50 * time.Millisecond,
+			DownloadDuration: 10 * time.Millisecond,
+		},
+	}, "us-east-1")
+	exporter.AddCheck(target, net.WebsiteCheckResult{IsUp: false, StatusCode: 503}, "us-east-1")
+
+	spans := recorder.Ended()
+	var parents, children []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Parent().IsValid() {
+			children = append(children, span)
+		} else {
+			parents = append(parents, span)
+		}
+	}
+
+	if len(parents) != 2 {
+		t.Fatalf("Expected one span per check, got %d", len(parents))
+	}
+	if parents[0].Name() != "GET" || !parents[0].StartTime().Equal(start) {
+		t.Errorf("Unexpected check span %q starting at %v", parents[0].Name(), parents[0].StartTime())
+	}
+	if parents[1].Status().Code != codes.Error {
+		t.Errorf("Failed check should have error status, got %v", parents[1].Status())
+	}
+
+	wantPhases := map[string]time.Duration{
+		"dns":      10 * time.Millisecond,
+		"connect":  10 * time.Millisecond,
+		"tls":      20 * time.Millisecond,
+		"ttfb":     50 * time.Millisecond,
+		"download": 10 * time.Millisecond,
+	}
+	if len(children) != len(wantPhases) {
+		t.Fatalf("Expected %d phase spans, got %d", len(wantPhases), len(children))
+	}
+	for _, child := range children {
+		want, exists := wantPhases[child.Name()]
+		if !exists {
+			t.Errorf("Unexpected phase span %q", child.Name())
+			continue
+		}
+		if got := child.EndTime().Sub(child.StartTime()); got != want {
+			t.Errorf("Phase %s lasted %v, want %v", child.Name(), got, want)
+		}
+		if child.Parent().SpanID() != parents[0].SpanContext().SpanID() {
+			t.Errorf("Phase %s is not a child of the check span", child.Name())
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	sums := make(map[string]int64)
+	found := make(map[string]bool)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			found[m.Name] = true
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, point := range sum.DataPoints {
+					sums[m.Name] += point.Value
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{"http.client.request.duration", "updo.check.phase.duration", "updo.target.up"} {
+		if !found[name] {
+			t.Errorf("Expected metric %s to be recorded", name)
+		}
+	}
+	if sums["updo.checks"] != 2 || sums["updo.check.failures"] != 1 {
+		t.Errorf("checks/failures = %d/%d, want 2/1", sums["updo.checks"], sums["updo.check.failures"])
+	}
+}
+
+func TestCheckPhases_SkipsUnmeasured(t *testing.T) {
+	start := time.Now()
+	phases := checkPhases(net.WebsiteCheckResult{
+		ResponseTime: 50 * time.Millisecond,
+		TraceInfo: &net.HttpTraceInfo{
+			Wait:             -time.Hour,
+			TCPConnection:    time.Hour,
+			TimeToFirstByte:  40 * time.Millisecond,
+			DownloadDuration: 5 * time.Millisecond,
+		},
+	}, start)
+
+	if len(phases) != 2 || phases[0].name != "ttfb" || phases[1].name != "download" {
+		t.Fatalf("Expected only ttfb and download phases, got %+v", phases)
+	}
+	if !phases[0].start.Equal(start) || !phases[1].start.Equal(phases[0].end) {
+		t.Error("Phases should be laid out back to back from the check start")
+	}
+}
+
+func TestParseOTLPEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint     string
+		wantHost     string
+		wantPath     string
+		wantInsecure bool
+		wantErr      bool
+	}{
+		{endpoint: "localhost:4317", wantHost: "localhost:4317"},
+		{endpoint: "http://localhost:4318", wantHost: "localhost:4318", wantInsecure: true},
+		{endpoint: "https://otel.example.com/otlp/", wantHost: "otel.example.com", wantPath: "/otlp"},
+		{endpoint: "ftp://otel.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			host, path, insecure, err := parseOTLPEndpoint(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOTLPEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if host != tt.wantHost || path != tt.wantPath || insecure != tt.wantInsecure {
+				t.Errorf("parseOTLPEndpoint() = %q, %q, %v, want %q, %q, %v",
+					host, path, insecure, tt.wantHost, tt.wantPath, tt.wantInsecure)
+			}
+		})
+	}
+}
+
+func TestNewOTLPConfig(t *testing.T) {
+	t.Setenv("UPDO_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("UPDO_OTLP_HEADERS", "X-Api-Key=secret, X-Tenant = team")
+	t.Setenv("UPDO_OTLP_PUSH_INTERVAL", "30s")
+
+	cfg := NewOTLPConfig("", "")
+	if cfg.Endpoint != "http://collector:4318" || cfg.Protocol != OTLPProtocolHTTP {
+		t.Errorf("Unexpected endpoint/protocol %q/%q", cfg.Endpoint, cfg.Protocol)
+	}
+	if cfg.Headers["X-Api-Key"] != "secret" || cfg.Headers["X-Tenant"] != "team" {
+		t.Errorf("Unexpected headers %v", cfg.Headers)
+	}
+	if cfg.PushInterval != 30*time.Second {
+		t.Errorf("PushInterval = %v, want 30s", cfg.PushInterval)
+	}
+
+	if cfg := NewOTLPConfig("localhost:4317", OTLPProtocolGRPC); cfg.Endpoint != "localhost:4317" || cfg.Protocol != OTLPProtocolGRPC {
+		t.Errorf("Flags should take precedence over the environment, got %q/%q", cfg.Endpoint, cfg.Protocol)
+	}
+}
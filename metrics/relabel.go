@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"strconv"
+
+	"github.com/Owloops/updo/config"
+)
+
+var _relabelRules []config.RelabelRule
+
+// SetRelabelRules validates rules and applies them to every series built
+// afterwards. It is meant to be called once before checks start.
+func SetRelabelRules(rules []config.RelabelRule) error {
+	for i, rule := range rules {
+		if rule.Label == "" || rule.Label == _nameLbl {
+			return fmt.Errorf("relabel rule %d: invalid label %q", i+1, rule.Label)
+		}
+		switch rule.Action {
+		case config.RelabelDrop, config.RelabelHash:
+		case config.RelabelRename:
+			if err := config.ValidateLabels(map[string]string{rule.To: ""}); err != nil {
+				return fmt.Errorf("relabel rule %d: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("relabel rule %d: unknown action %q", i+1, rule.Action)
+		}
+	}
+
+	_relabelRules = rules
+	return nil
+}
+
+// relabel returns labels rewritten by the configured rules. The input map
+// is left untouched since callers share it between series.
+func relabel(labels map[string]string) map[string]string {
+	if len(_relabelRules) == 0 {
+		return labels
+	}
+
+	relabeled := maps.Clone(labels)
+	for _, rule := range _relabelRules {
+		value, exists := relabeled[rule.Label]
+		if !exists {
+			continue
+		}
+		switch rule.Action {
+		case config.RelabelDrop:
+			delete(relabeled, rule.Label)
+		case config.RelabelRename:
+			delete(relabeled, rule.Label)
+			relabeled[rule.To] = value
+		case config.RelabelHash:
+			relabeled[rule.Label] = hashLabelValue(value)
+		}
+	}
+	return relabeled
+}
+
+func hashLabelValue(value string) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(value))
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+func TestRelabel(t *testing.T) {
+	target := config.Target{
+		Name:   "checkout",
+		URL:    "https://example.com/cart?session=1",
+		Labels: map[string]string{"team": "payments", "name": "ignored"},
+	}
+
+	tests := []struct {
+		name  string
+		rules []config.RelabelRule
+		want  map[string]string
+	}{
+		{
+			name: "no rules",
+			want: map[string]string{"name": "checkout", "url": target.URL, "region": "eu-west-1", "team": "payments"},
+		},
+		{
+			name: "drop, rename and hash",
+			rules: []config.RelabelRule{
+				{Action: config.RelabelDrop, Label: "url"},
+				{Action: config.RelabelRename, Label: "team", To: "owner"},
+				{Action: config.RelabelHash, Label: "region"},
+				{Action: config.RelabelDrop, Label: "missing"},
+			},
+			want: map[string]string{"name": "checkout", "region": hashLabelValue("eu-west-1"), "owner": "payments"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetRelabelRules(tt.rules); err != nil {
+				t.Fatalf("SetRelabelRules failed: %v", err)
+			}
+			t.Cleanup(func() { _relabelRules = nil })
+
+			labels := MapTargetLabels(target, net.WebsiteCheckResult{}, "eu-west-1")
+			got := make(map[string]string)
+			for _, label := range MapSeries("target_up", labels) {
+				if label.Name != _nameLbl {
+					got[label.Name] = label.Value
+				}
+			}
+
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("MapSeries labels = %v, want %v", got, tt.want)
+			}
+			if labels["url"] != target.URL {
+				t.Error("Relabeling must not modify the input labels")
+			}
+		})
+	}
+}
+
+func TestSetRelabelRules_Validation(t *testing.T) {
+	t.Cleanup(func() { _relabelRules = nil })
+
+	tests := []struct {
+		rule    config.RelabelRule
+		wantErr bool
+	}{
+		{config.RelabelRule{Action: config.RelabelDrop, Label: "url"}, false},
+		{config.RelabelRule{Action: config.RelabelRename, Label: "team", To: "owner"}, false},
+		{config.RelabelRule{Action: config.RelabelRename, Label: "team", To: "bad-name"}, true},
+		{config.RelabelRule{Action: config.RelabelHash, Label: _nameLbl}, true},
+		{config.RelabelRule{Action: "replace", Label: "url"}, true},
+		{config.RelabelRule{Action: config.RelabelDrop}, true},
+	}
+
+	for _, tt := range tests {
+		if err := SetRelabelRules([]config.RelabelRule{tt.rule}); (err != nil) != tt.wantErr {
+			t.Errorf("SetRelabelRules(%+v) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
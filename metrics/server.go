@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	_metricsPath       = "/metrics"
+	_readHeaderTimeout = 5 * time.Second
+	_shutdownTimeout   = 5 * time.Second
+)
+
+// ScrapeServer serves an Exporter over HTTP for Prometheus to scrape.
+type ScrapeServer struct {
+	*Exporter
+	server *http.Server
+}
+
+// NewScrapeServer starts serving at addr. The listener is opened before
+// returning so address errors are reported to the caller.
+func NewScrapeServer(addr string) (*ScrapeServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	exporter := NewExporter()
+	mux := http.NewServeMux()
+	mux.Handle(_metricsPath, exporter)
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: _readHeaderTimeout,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logf(err, "Error serving metrics")
+		}
+	}()
+
+	return &ScrapeServer{Exporter: exporter, server: server}, nil
+}
+
+func (s *ScrapeServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), _shutdownTimeout)
+	defer cancel()
+	_ = s.server.Shutdown(ctx)
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Owloops/updo/config"
+)
+
+type SetupOptions struct {
+	PrometheusURL string
+	MetricsListen string
+	OTLPEndpoint  string
+	OTLPProtocol  string
+	Config        config.Metrics
+}
+
+// Setup registers a sink for every output enabled by flags, environment
+// variables or the [metrics] config section. If one fails to start, the
+// sinks already registered are stopped again.
+func Setup(opts SetupOptions) error {
+	for _, name := range opts.Config.Sinks {
+		switch name {
+		case config.SinkPrometheus, config.SinkInfluxDB, config.SinkStatsD, config.SinkGraphite:
+		default:
+			return fmt.Errorf("unknown metrics sink %q", name)
+		}
+	}
+
+	if err := SetRelabelRules(opts.Config.Relabel); err != nil {
+		return err
+	}
+
+	if err := setupSinks(opts); err != nil {
+		StopSinks()
+		return err
+	}
+	return nil
+}
+
+func setupSinks(opts SetupOptions) error {
+	cfg := opts.Config
+
+	prometheusURL := opts.PrometheusURL
+	if prometheusURL == "" && cfg.SinkEnabled(config.SinkPrometheus, cfg.Prometheus.URL != "") {
+		prometheusURL = cfg.Prometheus.URL
+	}
+	if remoteWrite, ok := NewConfigFromEnv(prometheusURL); ok {
+		if cfg.Prometheus.FlushInterval > 0 && os.Getenv("UPDO_PROMETHEUS_PUSH_INTERVAL") == "" {
+			remoteWrite.PushInterval = cfg.Prometheus.FlushInterval
+		}
+		if cfg.Prometheus.Protocol != "" && os.Getenv("UPDO_PROMETHEUS_RW_PROTOCOL") == "" {
+			remoteWrite.Protocol = cfg.Prometheus.Protocol
+		}
+		if remoteWrite.Protocol != RemoteWriteV1 && remoteWrite.Protocol != RemoteWriteV2 {
+			return fmt.Errorf("unknown remote write protocol %q, expected %q or %q", remoteWrite.Protocol, RemoteWriteV2, RemoteWriteV1)
+		}
+		if cfg.Prometheus.WALDir != "" && os.Getenv("UPDO_PROMETHEUS_WAL_DIR") == "" {
+			remoteWrite.WALDir = cfg.Prometheus.WALDir
+		}
+		if cfg.Prometheus.WALMaxSizeMB > 0 {
+			remoteWrite.WALMaxSize = int64(cfg.Prometheus.WALMaxSizeMB) << 20
+		}
+		if cfg.Prometheus.Shards > 0 {
+			remoteWrite.Shards = cfg.Prometheus.Shards
+		}
+		client, err := NewWriteClient(remoteWrite)
+		if err != nil {
+			return err
+		}
+		client.Start()
+		RegisterSink(client)
+	}
+
+	if opts.MetricsListen != "" {
+		server, err := NewScrapeServer(opts.MetricsListen)
+		if err != nil {
+			return err
+		}
+		RegisterSink(server)
+	}
+
+	if otlpConfig := NewOTLPConfig(opts.OTLPEndpoint, opts.OTLPProtocol); otlpConfig.Endpoint != "" {
+		exporter, err := NewOTLPExporterFromConfig(otlpConfig)
+		if err != nil {
+			return err
+		}
+		RegisterSink(exporter)
+	}
+
+	if cfg.SinkEnabled(config.SinkInfluxDB, cfg.InfluxDB.URL != "") {
+		influx := cfg.InfluxDB
+		if token := os.Getenv("UPDO_INFLUXDB_TOKEN"); token != "" {
+			influx.Token = token
+		}
+		sink, err := NewInfluxDBSink(influx)
+		if err != nil {
+			return err
+		}
+		RegisterSink(sink)
+	}
+
+	if cfg.SinkEnabled(config.SinkStatsD, cfg.StatsD.Address != "") {
+		sink, err := NewStatsDSink(cfg.StatsD)
+		if err != nil {
+			return err
+		}
+		RegisterSink(sink)
+	}
+
+	if cfg.SinkEnabled(config.SinkGraphite, cfg.Graphite.Address != "") {
+		sink, err := NewGraphiteSink(cfg.Graphite)
+		if err != nil {
+			return err
+		}
+		RegisterSink(sink)
+	}
+
+	return nil
+}
+
+// NewConfigFromEnv builds the remote write configuration for serverURL,
+// falling back to UPDO_PROMETHEUS_RW_SERVER_URL, and applies the
+// UPDO_PROMETHEUS_* credentials. It reports false if no URL is set.
+func NewConfigFromEnv(serverURL string) (Config, bool) {
+	if serverURL == "" {
+		serverURL = os.Getenv("UPDO_PROMETHEUS_RW_SERVER_URL")
+	}
+	if serverURL == "" {
+		return Config{}, false
+	}
+
+	metricsConfig := NewConfig()
+	metricsConfig.ServerURL = serverURL
+
+	if username := os.Getenv("UPDO_PROMETHEUS_USERNAME"); username != "" {
+		metricsConfig.Username = username
+	}
+	if password := os.Getenv("UPDO_PROMETHEUS_PASSWORD"); password != "" {
+		metricsConfig.Password = password
+	}
+	if bearerToken := os.Getenv("UPDO_PROMETHEUS_BEARER_TOKEN"); bearerToken != "" {
+		metricsConfig.Headers["Authorization"] = "Bearer " + bearerToken
+	}
+	if authHeader := os.Getenv("UPDO_PROMETHEUS_AUTH_HEADER"); authHeader != "" {
+		parts := strings.SplitN(authHeader, ":", 2)
+		if len(parts) == 2 {
+			metricsConfig.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if pushInterval := os.Getenv("UPDO_PROMETHEUS_PUSH_INTERVAL"); pushInterval != "" {
+		if duration, err := time.ParseDuration(pushInterval); err == nil {
+			metricsConfig.PushInterval = duration
+		}
+	}
+	if protocol := os.Getenv("UPDO_PROMETHEUS_RW_PROTOCOL"); protocol != "" {
+		metricsConfig.Protocol = protocol
+	}
+	if walDir := os.Getenv("UPDO_PROMETHEUS_WAL_DIR"); walDir != "" {
+		metricsConfig.WALDir = walDir
+	}
+
+	return metricsConfig, true
+}
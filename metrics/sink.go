@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+// Sink receives check results and exports them to a metrics backend.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	AddCheck(target config.Target, result net.WebsiteCheckResult, region string)
+	AddSSLExpiry(target config.Target, daysUntilExpiry int)
+	AddApdex(target config.Target, score float64, region string)
+	// Stop flushes anything still buffered and releases the sink.
+	Stop()
+}
+
+var _sinks []Sink
+
+var (
+	_logMu      sync.RWMutex
+	_logHandler func(message string, err error)
+)
+
+// SetLogHandler routes messages from sinks exporting in the background,
+// such as failed pushes, to handler instead of stdout. err is nil for
+// informational messages. A nil handler restores printing.
+func SetLogHandler(handler func(message string, err error)) {
+	_logMu.Lock()
+	defer _logMu.Unlock()
+	_logHandler = handler
+}
+
+func logf(err error, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	_logMu.RLock()
+	handler := _logHandler
+	_logMu.RUnlock()
+
+	switch {
+	case handler != nil:
+		handler(message, err)
+	case err != nil:
+		fmt.Printf("%s: %v\n", message, err)
+	default:
+		fmt.Println(message)
+	}
+}
+
+// RegisterSink adds a sink that every Record* call is forwarded to.
+func RegisterSink(sink Sink) {
+	_sinks = append(_sinks, sink)
+}
+
+func StopSinks() {
+	for _, sink := range _sinks {
+		sink.Stop()
+	}
+	_sinks = nil
+}
+
+// Enabled reports whether any sink is registered.
+func Enabled() bool {
+	return len(_sinks) > 0
+}
+
+func RecordCheck(target config.Target, result net.WebsiteCheckResult, region string) {
+	for _, sink := range _sinks {
+		sink.AddCheck(target, result, region)
+	}
+}
+
+func RecordApdex(target config.Target, score float64, region string) {
+	for _, sink := range _sinks {
+		sink.AddApdex(target, score, region)
+	}
+}
+
+func RecordSSLExpiry(target config.Target, daysUntilExpiry int) {
+	for _, sink := range _sinks {
+		sink.AddSSLExpiry(target, daysUntilExpiry)
+	}
+}
+
+// RetainTargets lets sinks that keep series between checks, such as the
+// scrape server, drop those of targets no longer monitored. See
+// Exporter.Retain.
+func RetainTargets(targets []config.Target, regions []string) {
+	for _, sink := range _sinks {
+		if retainer, ok := sink.(interface {
+			Retain(targets []config.Target, regions []string)
+		}); ok {
+			retainer.Retain(targets, regions)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+func testSamples() []sample {
+	ts := time.UnixMilli(1700000000123)
+	return []sample{
+		{name: "updo_target_up", labels: map[string]string{"name": "My API", "url": "https://example.com", "region": "us-east-1"}, value: 1, timestamp: ts},
+		{name: "updo_response_time_seconds", labels: map[string]string{"name": "My API", "url": "https://example.com"}, value: 0.25, timestamp: ts},
+		{name: "updo_http_status_code_total", labels: map[string]string{"name": "My API", "status_code": "200"}, value: 1, timestamp: ts},
+	}
+}
+
+func TestEncodeLineProtocol(t *testing.T) {
+	got := string(encodeLineProtocol(testSamples()))
+	want := `updo_target_up,name=My\ API,region=us-east-1,url=https://example.com value=1 1700000000123
+updo_response_time_seconds,name=My\ API,url=https://example.com value=0.25 1700000000123
+updo_http_status_code_total,name=My\ API,status_code=200 value=1 1700000000123
+`
+	if got != want {
+		t.Errorf("encodeLineProtocol() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncodeStatsD(t *testing.T) {
+	packets := encodeStatsD("updo", testSamples())
+	if len(packets) != 1 {
+		t.Fatalf("Expected a single packet, got %d", len(packets))
+	}
+
+	want := "updo.target_up.My_API.us-east-1:1|g\nupdo.response_time_seconds.My_API:250|ms\nupdo.http_status_code_total.My_API.200:1|c"
+	if got := string(packets[0]); got != want {
+		t.Errorf("encodeStatsD() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncodeStatsD_SplitsPackets(t *testing.T) {
+	var samples []sample
+	for range 100 {
+		samples = append(samples, testSamples()...)
+	}
+
+	packets := encodeStatsD("updo", samples)
+	if len(packets) < 2 {
+		t.Fatalf("Expected samples to be split over several packets, got %d", len(packets))
+	}
+	lines := 0
+	for _, packet := range packets {
+		if len(packet) > _statsDMaxPacket {
+			t.Errorf("Packet of %d bytes exceeds %d", len(packet), _statsDMaxPacket)
+		}
+		lines += strings.Count(string(packet), "\n") + 1
+	}
+	if lines != len(samples) {
+		t.Errorf("Packets carry %d lines, want %d", lines, len(samples))
+	}
+}
+
+func TestEncodeGraphite(t *testing.T) {
+	got := string(encodeGraphite("monitoring.updo.", testSamples()[:1]))
+	want := "monitoring.updo.target_up.My_API.us-east-1 1 1700000000\n"
+	if got != want {
+		t.Errorf("encodeGraphite() = %q, want %q", got, want)
+	}
+}
+
+func TestInfluxDBSink(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewInfluxDBSink(config.InfluxDBSink{
+		URL:           server.URL,
+		Org:           "ops",
+		Bucket:        "updo",
+		Token:         "secret",
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewInfluxDBSink failed: %v", err)
+	}
+
+	sink.AddCheck(config.Target{Name: "api", URL: "https://example.com"}, net.WebsiteCheckResult{IsUp: true, StatusCode: 200}, "")
+	sink.Stop()
+
+	if gotPath != "/api/v2/write?bucket=updo&org=ops&precision=ms" {
+		t.Errorf("Unexpected write path %q", gotPath)
+	}
+	if gotAuth != "Token secret" {
+		t.Errorf("Authorization = %q, want Token secret", gotAuth)
+	}
+	if !strings.Contains(gotBody, "updo_target_up,name=api,url=https://example.com value=1 ") {
+		t.Errorf("Unexpected body:\n%s", gotBody)
+	}
+}
+
+func TestNewInfluxDBSink_RequiresBucket(t *testing.T) {
+	if _, err := NewInfluxDBSink(config.InfluxDBSink{URL: "http://localhost:8086", Org: "ops"}); err == nil {
+		t.Error("Expected an error without a bucket")
+	}
+}
+
+func TestSetup_UnknownSink(t *testing.T) {
+	err := Setup(SetupOptions{Config: config.Metrics{Sinks: []string{"datadog"}}})
+	if err == nil || !strings.Contains(err.Error(), "datadog") {
+		t.Errorf("Expected an unknown sink error, got %v", err)
+	}
+	if Enabled() {
+		t.Error("No sink should be registered after a failed setup")
+	}
+}
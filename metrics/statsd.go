@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Owloops/updo/config"
+)
+
+const (
+	_defaultStatsDPrefix = "updo"
+	// _statsDMaxPacket keeps datagrams under a typical Ethernet MTU.
+	_statsDMaxPacket = 1432
+	_dialTimeout     = 5 * time.Second
+)
+
+// NewStatsDSink sends samples over UDP: *_total series as counters,
+// *_seconds series as timers in milliseconds and everything else as gauges.
+func NewStatsDSink(cfg config.StatsDSink) (Sink, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("statsd sink requires an address")
+	}
+
+	conn, err := net.DialTimeout("udp", cfg.Address, _dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", cfg.Address, err)
+	}
+
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = _defaultStatsDPrefix
+	}
+
+	write := func(samples []sample) error {
+		for _, packet := range encodeStatsD(prefix, samples) {
+			if _, err := conn.Write(packet); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return newBufferedSink(config.SinkStatsD, cfg.FlushInterval, write, conn.Close), nil
+}
+
+func encodeStatsD(prefix string, samples []sample) [][]byte {
+	var packets [][]byte
+	var packet bytes.Buffer
+
+	for _, s := range samples {
+		value, metricType := s.value, "g"
+		switch {
+		case strings.HasSuffix(s.name, _counterSuffix):
+			metricType = "c"
+		case strings.HasSuffix(s.name, "_seconds"):
+			value, metricType = s.value*1000, "ms"
+		}
+
+		line := fmt.Sprintf("%s:%s|%s", dottedPath(prefix, s), strconv.FormatFloat(value, 'f', -1, 64), metricType)
+		if packet.Len() > 0 && packet.Len()+1+len(line) > _statsDMaxPacket {
+			packets = append(packets, bytes.Clone(packet.Bytes()))
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+
+	if packet.Len() > 0 {
+		packets = append(packets, packet.Bytes())
+	}
+	return packets
+}
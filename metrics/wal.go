@@ -0,0 +1,298 @@
+package metrics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	_walSegmentSuffix = ".seg"
+	_walShardPrefix   = "shard-"
+)
+
+// walSegment is one batch of series waiting to be sent. On disk it is a
+// snappy compressed WriteRequest named after its sequence number and oldest
+// sample, so sorting names replays batches in the order they were written
+// and every series in timestamp order.
+type walSegment struct {
+	name    string
+	path    string
+	data    []byte
+	samples int
+	size    int64
+}
+
+func (s *walSegment) read() ([]*prompb.TimeSeries, error) {
+	data := s.data
+	if s.path != "" {
+		var err error
+		if data, err = os.ReadFile(s.path); err != nil {
+			return nil, err
+		}
+	}
+
+	decoded, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt segment %s: %w", s.name, err)
+	}
+	var request prompb.WriteRequest
+	if err := proto.Unmarshal(decoded, &request); err != nil {
+		return nil, fmt.Errorf("corrupt segment %s: %w", s.name, err)
+	}
+	return request.Timeseries, nil
+}
+
+// walShard is the queue of one sender. A series always lands in the same
+// shard so its samples are sent in order even with several senders.
+type walShard struct {
+	dir      string
+	mu       sync.Mutex
+	segments []*walSegment
+	notify   chan struct{}
+}
+
+func (s *walShard) oldest() *walSegment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.segments) == 0 {
+		return nil
+	}
+	return s.segments[0]
+}
+
+func (s *walShard) insert(segment *walSegment) {
+	s.mu.Lock()
+	index, _ := slices.BinarySearchFunc(s.segments, segment, func(a, b *walSegment) int {
+		return strings.Compare(a.name, b.name)
+	})
+	s.segments = slices.Insert(s.segments, index, segment)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *walShard) remove(segment *walSegment) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := slices.Index(s.segments, segment)
+	if index < 0 {
+		return false
+	}
+	s.segments = slices.Delete(s.segments, index, index+1)
+	if segment.path != "" {
+		_ = os.Remove(segment.path)
+	}
+	return true
+}
+
+// writeAheadLog keeps series until the receiver has accepted them. With a
+// directory the segments survive restarts, otherwise they are kept in
+// memory. Once maxSize bytes are pending the oldest segments are dropped.
+type writeAheadLog struct {
+	shards   []*walShard
+	maxSize  int64
+	size     atomic.Int64
+	pending  atomic.Int64
+	dropped  atomic.Int64
+	sequence atomic.Uint64
+}
+
+func openWAL(dir string, shards int, maxSize int64) (*writeAheadLog, error) {
+	wal := &writeAheadLog{
+		shards:  make([]*walShard, shards),
+		maxSize: maxSize,
+	}
+
+	for i := range wal.shards {
+		shard := &walShard{notify: make(chan struct{}, 1)}
+		if dir != "" {
+			shard.dir = filepath.Join(dir, fmt.Sprintf("%s%d", _walShardPrefix, i))
+			if err := os.MkdirAll(shard.dir, 0o750); err != nil {
+				return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+			}
+			if err := wal.load(shard); err != nil {
+				return nil, err
+			}
+		}
+		wal.shards[i] = shard
+	}
+
+	return wal, nil
+}
+
+func (w *writeAheadLog) load(shard *walShard) error {
+	entries, err := os.ReadDir(shard.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read WAL directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(shard.dir, entry.Name())
+		if !strings.HasSuffix(entry.Name(), _walSegmentSuffix) {
+			// Left behind by a write that did not complete.
+			_ = os.Remove(path)
+			continue
+		}
+
+		segment := &walSegment{name: entry.Name(), path: path}
+		series, err := segment.read()
+		if err != nil {
+			_ = os.Remove(path)
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			segment.size = info.Size()
+		}
+		segment.samples = countSamples(series)
+
+		var sequence uint64
+		if _, err := fmt.Sscanf(entry.Name(), "%d-", &sequence); err == nil && sequence > w.sequence.Load() {
+			w.sequence.Store(sequence)
+		}
+
+		shard.segments = append(shard.segments, segment)
+		w.size.Add(segment.size)
+		w.pending.Add(int64(segment.samples))
+	}
+
+	slices.SortFunc(shard.segments, func(a, b *walSegment) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return nil
+}
+
+// append splits series across the shards and stores one segment per
+// shard, with series ordered by their first sample.
+func (w *writeAheadLog) append(series []*prompb.TimeSeries) error {
+	batches := make([][]*prompb.TimeSeries, len(w.shards))
+	for _, ts := range series {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(labelsKey(ts.Labels)))
+		index := hash.Sum32() % uint32(len(w.shards)) // #nosec G115 -- shard count is small
+		batches[index] = append(batches[index], ts)
+	}
+
+	var errs []error
+	for i, batch := range batches {
+		if len(batch) == 0 {
+			continue
+		}
+		if err := w.write(w.shards[i], batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	w.trim()
+	return errors.Join(errs...)
+}
+
+func (w *writeAheadLog) write(shard *walShard, series []*prompb.TimeSeries) error {
+	slices.SortStableFunc(series, func(a, b *prompb.TimeSeries) int {
+		return cmp.Compare(a.Samples[0].Timestamp, b.Samples[0].Timestamp)
+	})
+
+	data, err := proto.Marshal(&prompb.WriteRequest{Timeseries: series})
+	if err != nil {
+		return fmt.Errorf("failed to marshal protobuf: %w", err)
+	}
+
+	segment := &walSegment{
+		name:    fmt.Sprintf("%020d-%020d%s", w.sequence.Add(1), series[0].Samples[0].Timestamp, _walSegmentSuffix),
+		data:    snappy.Encode(nil, data),
+		samples: countSamples(series),
+	}
+	segment.size = int64(len(segment.data))
+
+	if shard.dir != "" {
+		segment.path = filepath.Join(shard.dir, segment.name)
+		if err := writeSegmentFile(segment.path, segment.data); err != nil {
+			return err
+		}
+		segment.data = nil
+	}
+
+	w.size.Add(segment.size)
+	w.pending.Add(int64(segment.samples))
+	shard.insert(segment)
+	return nil
+}
+
+func writeSegmentFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create WAL segment: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write WAL segment: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write WAL segment: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// remove deletes a segment the receiver accepted.
+func (w *writeAheadLog) remove(shard *walShard, segment *walSegment) {
+	if shard.remove(segment) {
+		w.size.Add(-segment.size)
+		w.pending.Add(-int64(segment.samples))
+	}
+}
+
+// drop discards a segment that will never be delivered.
+func (w *writeAheadLog) drop(shard *walShard, segment *walSegment) {
+	if shard.remove(segment) {
+		w.size.Add(-segment.size)
+		w.pending.Add(-int64(segment.samples))
+		w.dropped.Add(int64(segment.samples))
+	}
+}
+
+// trim drops the oldest segments across all shards until the log fits in
+// maxSize again.
+func (w *writeAheadLog) trim() {
+	for w.maxSize > 0 && w.size.Load() > w.maxSize {
+		var oldestShard *walShard
+		var oldest *walSegment
+		for _, shard := range w.shards {
+			if segment := shard.oldest(); segment != nil && (oldest == nil || segment.name < oldest.name) {
+				oldestShard, oldest = shard, segment
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		w.drop(oldestShard, oldest)
+	}
+}
+
+func countSamples(series []*prompb.TimeSeries) int {
+	count := 0
+	for _, ts := range series {
+		count += len(ts.Samples)
+	}
+	return count
+}
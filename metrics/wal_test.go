@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/proto"
+)
+
+func testSeries(name string, timestamp int64) *prompb.TimeSeries {
+	return &prompb.TimeSeries{
+		Labels:  MapSeries("target_up", map[string]string{"name": name}),
+		Samples: []*prompb.Sample{{Timestamp: timestamp, Value: 1}},
+	}
+}
+
+func TestWriteAheadLog_PersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+
+	wal, err := openWAL(dir, 2, 0)
+	if err != nil {
+		t.Fatalf("openWAL failed: %v", err)
+	}
+	if err := wal.append([]*prompb.TimeSeries{testSeries("a", 2000), testSeries("b", 1000)}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := wal.append([]*prompb.TimeSeries{testSeries("a", 3000)}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	leftover := filepath.Join(dir, _walShardPrefix+"0", "partial.seg.tmp-1")
+	if err := os.WriteFile(leftover, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := openWAL(dir, 2, 0)
+	if err != nil {
+		t.Fatalf("openWAL failed: %v", err)
+	}
+	if pending := reopened.pending.Load(); pending != 3 {
+		t.Errorf("Expected 3 pending samples after reopening, got %d", pending)
+	}
+	if reopened.sequence.Load() != wal.sequence.Load() {
+		t.Errorf("Sequence should continue from %d, got %d", wal.sequence.Load(), reopened.sequence.Load())
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Error("Incomplete segment should have been removed")
+	}
+
+	var timestamps []int64
+	for _, shard := range reopened.shards {
+		shard.mu.Lock()
+		segments := slices.Clone(shard.segments)
+		shard.mu.Unlock()
+
+		for _, segment := range segments {
+			series, err := segment.read()
+			if err != nil {
+				t.Fatalf("read failed: %v", err)
+			}
+			timestamps = append(timestamps, sentTimestamps(series, "updo_target_up")...)
+		}
+	}
+	slices.Sort(timestamps)
+	if !slices.Equal(timestamps, []int64{1000, 2000, 3000}) {
+		t.Errorf("Unexpected replayed timestamps %v", timestamps)
+	}
+}
+
+func TestWriteAheadLog_TrimsOldest(t *testing.T) {
+	wal, err := openWAL("", 1, 1)
+	if err != nil {
+		t.Fatalf("openWAL failed: %v", err)
+	}
+
+	for i := range 3 {
+		if err := wal.append([]*prompb.TimeSeries{testSeries("a", int64(1000*(i+1)))}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	if wal.size.Load() > 0 || wal.pending.Load() != 0 || wal.dropped.Load() != 3 {
+		t.Errorf("Expected every segment to be dropped, got size=%d pending=%d dropped=%d",
+			wal.size.Load(), wal.pending.Load(), wal.dropped.Load())
+	}
+}
+
+func TestWriteAheadLog_ShardsBySeries(t *testing.T) {
+	wal, err := openWAL("", 4, 0)
+	if err != nil {
+		t.Fatalf("openWAL failed: %v", err)
+	}
+
+	for i := range 3 {
+		series := make([]*prompb.TimeSeries, 0, 8)
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			series = append(series, testSeries(name, int64(1000*(i+1))))
+		}
+		if err := wal.append(series); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	shardOf := make(map[string]int)
+	for i, shard := range wal.shards {
+		for _, segment := range shard.segments {
+			series, _ := segment.read()
+			for _, ts := range series {
+				key := labelsKey(ts.Labels)
+				if previous, exists := shardOf[key]; exists && previous != i {
+					t.Errorf("Series %s found in shards %d and %d", key, previous, i)
+				}
+				shardOf[key] = i
+			}
+		}
+	}
+	if len(shardOf) != 8 {
+		t.Errorf("Expected 8 series, got %d", len(shardOf))
+	}
+}
+
+func TestWriteClient_ResendsAfterOutage(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+
+	var mu sync.Mutex
+	var received []*prompb.TimeSeries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		compressed, _ := io.ReadAll(r.Body)
+		var request prompb.WriteRequest
+		data, err := snappy.Decode(nil, compressed)
+		if err != nil || proto.Unmarshal(data, &request) != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		mu.Lock()
+		received = append(received, request.Timeseries...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var messages []string
+	SetLogHandler(func(message string, err error) {
+		messages = append(messages, message)
+	})
+	defer SetLogHandler(nil)
+
+	cfg := NewConfig()
+	cfg.ServerURL = server.URL
+	cfg.Protocol = RemoteWriteV1
+	cfg.WALDir = t.TempDir()
+	client := newTestWriteClient(t, cfg)
+
+	target := config.Target{Name: "test", URL: "https://example.com"}
+	start := time.UnixMilli(1700000000000)
+	for i := range 3 {
+		client.AddCheck(target, net.WebsiteCheckResult{IsUp: true, LastCheckTime: start.Add(time.Duration(i) * time.Minute)}, "")
+		client.flushSamples()
+	}
+
+	client.drain()
+	if client.wal.pending.Load() == 0 || client.failures.Load() == 0 {
+		t.Fatalf("Samples should stay pending while the receiver is down, pending=%d failures=%d",
+			client.wal.pending.Load(), client.failures.Load())
+	}
+
+	down.Store(false)
+	client.drain()
+
+	if pending := client.wal.pending.Load(); pending != 0 {
+		t.Errorf("Expected the log to be empty after recovery, %d samples pending", pending)
+	}
+	want := []int64{start.UnixMilli(), start.Add(time.Minute).UnixMilli(), start.Add(2 * time.Minute).UnixMilli()}
+	if got := sentTimestamps(received, "updo_target_up"); !slices.Equal(got, want) {
+		t.Errorf("Expected samples resent in timestamp order %v, got %v", want, got)
+	}
+	for _, prefix := range []string{"Remote write failed", "Remote write recovered"} {
+		if !slices.ContainsFunc(messages, func(message string) bool { return strings.HasPrefix(message, prefix) }) {
+			t.Errorf("Expected %q to be logged, got %v", prefix, messages)
+		}
+	}
+}
+
+func TestWriteClient_DropsRefusedSegments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of bounds", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	SetLogHandler(func(string, error) {})
+	defer SetLogHandler(nil)
+
+	cfg := NewConfig()
+	cfg.ServerURL = server.URL
+	cfg.Protocol = RemoteWriteV1
+	client := newTestWriteClient(t, cfg)
+
+	client.AddCheck(config.Target{Name: "test", URL: "https://example.com"}, net.WebsiteCheckResult{IsUp: true}, "")
+	client.flushSamples()
+	client.drain()
+
+	if client.wal.pending.Load() != 0 || client.wal.dropped.Load() == 0 {
+		t.Errorf("Refused samples should be dropped, pending=%d dropped=%d",
+			client.wal.pending.Load(), client.wal.dropped.Load())
+	}
+}
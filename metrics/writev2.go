@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"strings"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	writev2 "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go/io/prometheus/write/v2"
+)
+
+// symbolTable interns label names, label values, help texts and units so
+// each string is sent once per request. The empty string is always symbol 0.
+type symbolTable struct {
+	symbols []string
+	refs    map[string]uint32
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{
+		symbols: []string{""},
+		refs:    map[string]uint32{"": 0},
+	}
+}
+
+func (t *symbolTable) ref(symbol string) uint32 {
+	if ref, exists := t.refs[symbol]; exists {
+		return ref
+	}
+	ref := uint32(len(t.symbols)) // #nosec G115 -- a request never holds 2^32 symbols
+	t.symbols = append(t.symbols, symbol)
+	t.refs[symbol] = ref
+	return ref
+}
+
+func (t *symbolTable) labelRefs(labels []*prompb.Label) []uint32 {
+	refs := make([]uint32, 0, len(labels)*2)
+	for _, label := range labels {
+		refs = append(refs, t.ref(label.Name), t.ref(label.Value))
+	}
+	return refs
+}
+
+type histogramSeries struct {
+	labels    []*prompb.Label
+	histogram *writev2.Histogram
+}
+
+// encodeV2Request converts the buffered series into a remote write 2.0
+// request. Counters and histograms carry createdAt as their created
+// timestamp, since both accumulate from the moment the client started.
+func encodeV2Request(series []*prompb.TimeSeries, histograms []histogramSeries, createdAt int64) *writev2.Request {
+	symbols := newSymbolTable()
+	request := &writev2.Request{
+		Timeseries: make([]*writev2.TimeSeries, 0, len(series)+len(histograms)),
+	}
+
+	for _, ts := range series {
+		name := seriesName(ts.Labels)
+		v2 := &writev2.TimeSeries{
+			LabelsRefs: symbols.labelRefs(ts.Labels),
+			Samples:    make([]*writev2.Sample, 0, len(ts.Samples)),
+		}
+		for _, sample := range ts.Samples {
+			v2.Samples = append(v2.Samples, &writev2.Sample{Value: sample.Value, Timestamp: sample.Timestamp})
+		}
+
+		metricType := writev2.Metadata_METRIC_TYPE_GAUGE
+		if strings.HasSuffix(name, _counterSuffix) {
+			metricType = writev2.Metadata_METRIC_TYPE_COUNTER
+			v2.CreatedTimestamp = createdAt
+		}
+		v2.Metadata = seriesMetadata(symbols, name, metricType)
+
+		request.Timeseries = append(request.Timeseries, v2)
+	}
+
+	for _, hs := range histograms {
+		request.Timeseries = append(request.Timeseries, &writev2.TimeSeries{
+			LabelsRefs:       symbols.labelRefs(hs.labels),
+			Histograms:       []*writev2.Histogram{hs.histogram},
+			Metadata:         seriesMetadata(symbols, seriesName(hs.labels), writev2.Metadata_METRIC_TYPE_HISTOGRAM),
+			CreatedTimestamp: createdAt,
+		})
+	}
+
+	request.Symbols = symbols.symbols
+	return request
+}
+
+func seriesMetadata(symbols *symbolTable, name string, metricType writev2.Metadata_MetricType) *writev2.Metadata {
+	return &writev2.Metadata{
+		Type:    metricType,
+		HelpRef: symbols.ref(_metricHelp[name]),
+		UnitRef: symbols.ref(metricUnit(name)),
+	}
+}
+
+func seriesName(labels []*prompb.Label) string {
+	for _, label := range labels {
+		if label.Name == _nameLbl {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+func metricUnit(name string) string {
+	name = strings.TrimSuffix(name, _counterSuffix)
+	for _, unit := range []string{"seconds", "days"} {
+		if strings.HasSuffix(name, "_"+unit) {
+			return unit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"slices"
+	"testing"
+
+	prompb "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go"
+	writev2 "buf.build/gen/go/prometheus/prometheus/protocolbuffers/go/io/prometheus/write/v2"
+)
+
+func TestEncodeV2Request(t *testing.T) {
+	labels := map[string]string{"name": "service", "url": "https://example.com"}
+	series := []*prompb.TimeSeries{
+		{Labels: MapSeries("target_up", labels), Samples: []*prompb.Sample{{Value: 1, Timestamp: 1000}}},
+		{Labels: MapSeries("http_status_code_total", labels), Samples: []*prompb.Sample{{Value: 4, Timestamp: 2000}}},
+	}
+	histogram := newNativeHistogram(MapSeries(_latencyHistogramName, labels))
+	histogram.observe(0.1, 3000)
+	histograms := []histogramSeries{{labels: histogram.labels, histogram: histogram.snapshot()}}
+
+	request := encodeV2Request(series, histograms, 500)
+
+	if len(request.Symbols) == 0 || request.Symbols[0] != "" {
+		t.Fatalf("Symbol table must start with the empty string, got %v", request.Symbols)
+	}
+	if occurrences := countSymbol(request.Symbols, "https://example.com"); occurrences != 1 {
+		t.Errorf("Expected label value to be interned once, found %d times", occurrences)
+	}
+	if len(request.Timeseries) != 3 {
+		t.Fatalf("Expected 3 series, got %d", len(request.Timeseries))
+	}
+
+	tests := []struct {
+		name    string
+		kind    writev2.Metadata_MetricType
+		unit    string
+		created int64
+	}{
+		{"updo_target_up", writev2.Metadata_METRIC_TYPE_GAUGE, "", 0},
+		{"updo_http_status_code_total", writev2.Metadata_METRIC_TYPE_COUNTER, "", 500},
+		{"updo_check_duration_seconds", writev2.Metadata_METRIC_TYPE_HISTOGRAM, "seconds", 500},
+	}
+	for i, tt := range tests {
+		ts := request.Timeseries[i]
+		resolved := make(map[string]string)
+		for j := 0; j+1 < len(ts.LabelsRefs); j += 2 {
+			resolved[request.Symbols[ts.LabelsRefs[j]]] = request.Symbols[ts.LabelsRefs[j+1]]
+		}
+
+		if resolved[_nameLbl] != tt.name || resolved["name"] != "service" {
+			t.Errorf("Series %d: unexpected labels %v", i, resolved)
+		}
+		if ts.Metadata.Type != tt.kind || request.Symbols[ts.Metadata.UnitRef] != tt.unit {
+			t.Errorf("%s: metadata type %v unit %q, want %v %q", tt.name, ts.Metadata.Type,
+				request.Symbols[ts.Metadata.UnitRef], tt.kind, tt.unit)
+		}
+		if request.Symbols[ts.Metadata.HelpRef] != _metricHelp[tt.name] {
+			t.Errorf("%s: unexpected help %q", tt.name, request.Symbols[ts.Metadata.HelpRef])
+		}
+		if ts.CreatedTimestamp != tt.created {
+			t.Errorf("%s: created timestamp %d, want %d", tt.name, ts.CreatedTimestamp, tt.created)
+		}
+	}
+
+	if sample := request.Timeseries[1].Samples[0]; sample.Value != 4 || sample.Timestamp != 2000 {
+		t.Errorf("Unexpected counter sample %v", sample)
+	}
+}
+
+func TestNativeHistogram_Snapshot(t *testing.T) {
+	histogram := newNativeHistogram(nil)
+	for _, value := range []float64{1, 1, 1.05, 2, 0} {
+		histogram.observe(value, 1000)
+	}
+
+	snapshot := histogram.snapshot()
+
+	if count := snapshot.Count.(*writev2.Histogram_CountInt).CountInt; count != 5 {
+		t.Errorf("Count = %d, want 5", count)
+	}
+	if zeroCount := snapshot.ZeroCount.(*writev2.Histogram_ZeroCountInt).ZeroCountInt; zeroCount != 1 {
+		t.Errorf("ZeroCount = %d, want 1", zeroCount)
+	}
+	if snapshot.Sum != 5.05 || snapshot.Schema != _histogramSchema || snapshot.Timestamp != 1000 {
+		t.Errorf("Unexpected sum/schema/timestamp %v/%d/%d", snapshot.Sum, snapshot.Schema, snapshot.Timestamp)
+	}
+
+	// 1 falls in bucket 0, 1.05 in bucket 1 and 2 in bucket 8.
+	wantSpans := []*writev2.BucketSpan{{Offset: 0, Length: 2}, {Offset: 6, Length: 1}}
+	if len(snapshot.PositiveSpans) != len(wantSpans) {
+		t.Fatalf("Expected %d spans, got %v", len(wantSpans), snapshot.PositiveSpans)
+	}
+	for i, span := range snapshot.PositiveSpans {
+		if span.Offset != wantSpans[i].Offset || span.Length != wantSpans[i].Length {
+			t.Errorf("Span %d = %d/%d, want %d/%d", i, span.Offset, span.Length, wantSpans[i].Offset, wantSpans[i].Length)
+		}
+	}
+	if want := []int64{2, -1, 0}; !slices.Equal(snapshot.PositiveDeltas, want) {
+		t.Errorf("PositiveDeltas = %v, want %v", snapshot.PositiveDeltas, want)
+	}
+}
+
+func countSymbol(symbols []string, symbol string) int {
+	count := 0
+	for _, s := range symbols {
+		if s == symbol {
+			count++
+		}
+	}
+	return count
+}
@@ -23,13 +23,15 @@ import (
 const (
 	_hoursPerDay    = 24
 	_defaultTimeout = 5 * time.Second
-	_userAgent      = "updo/1.0"
 	_httpsPort      = ":443"
 
 	// DefaultBodySizeLimit is the recommended response body cap (1 MiB) for
 	// probe responses. Callers should set NetworkConfig.BodySizeLimit to this
 	// value unless they have a reason to cap smaller or disable capping.
 	DefaultBodySizeLimit int64 = 1 << 20
+
+	// UserAgent is sent with checks whose headers do not set one.
+	UserAgent = "updo/1.0"
 )
 
 type WebsiteCheckResult struct {
@@ -37,6 +39,7 @@ type WebsiteCheckResult struct {
 	ResolvedIP        string
 	IsUp              bool
 	StatusCode        int
+	HTTPVersion       string
 	ResponseTime      time.Duration
 	TraceInfo         *HttpTraceInfo
 	AssertionPassed   bool
@@ -55,6 +58,9 @@ type HttpTraceInfo struct {
 	TCPConnection    time.Duration
 	TimeToFirstByte  time.Duration
 	DownloadDuration time.Duration
+	// TLSHandshake is included in TCPConnection, which lasts until the
+	// connection is ready to send the request.
+	TLSHandshake time.Duration
 }
 
 type NetworkConfig struct {
@@ -98,7 +104,7 @@ type HTTPResponse struct {
 func CheckWebsite(urlStr string, config NetworkConfig) WebsiteCheckResult {
 	method := "GET"
 	if config.Method != "" {
-		method = config.Method
+		method = strings.ToUpper(config.Method)
 	}
 
 	options := HTTPRequestOptions{
@@ -119,6 +125,7 @@ func CheckWebsite(urlStr string, config NetworkConfig) WebsiteCheckResult {
 		URL:               urlStr,
 		ResolvedIP:        httpResp.ResolvedIP,
 		StatusCode:        httpResp.StatusCode,
+		HTTPVersion:       httpResp.HTTPVersion,
 		ResponseTime:      httpResp.ResponseTime,
 		TraceInfo:         httpResp.TraceInfo,
 		LastCheckTime:     httpResp.LastCheckTime,
@@ -285,10 +292,12 @@ func makeHTTPRequest(urlStr string, options HTTPRequestOptions, config NetworkCo
 		}
 	}
 
-	var start, connect, dnsStart, dnsDone, gotFirstByte time.Time
+	var start, connect, dnsStart, dnsDone, tlsStart, tlsDone, gotFirstByte time.Time
 	trace := &httptrace.ClientTrace{
 		DNSStart:             func(_ httptrace.DNSStartInfo) { dnsStart = time.Now() },
 		DNSDone:              func(_ httptrace.DNSDoneInfo) { dnsDone = time.Now() },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(_ tls.ConnectionState, _ error) { tlsDone = time.Now() },
 		GotConn:              func(_ httptrace.GotConnInfo) { connect = time.Now() },
 		GotFirstResponseByte: func() { gotFirstByte = time.Now() },
 	}
@@ -329,8 +338,8 @@ func makeHTTPRequest(urlStr string, options HTTPRequestOptions, config NetworkCo
 	}
 
 	if req.Header.Get("User-Agent") == "" {
-		req.Header.Set("User-Agent", _userAgent)
-		result.RequestHeaders.Set("User-Agent", _userAgent)
+		req.Header.Set("User-Agent", UserAgent)
+		result.RequestHeaders.Set("User-Agent", UserAgent)
 	}
 
 	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
@@ -379,6 +388,7 @@ func makeHTTPRequest(urlStr string, options HTTPRequestOptions, config NetworkCo
 		Wait:             dnsStart.Sub(start),
 		DNSLookup:        dnsDone.Sub(dnsStart),
 		TCPConnection:    connect.Sub(dnsDone),
+		TLSHandshake:     tlsDone.Sub(tlsStart),
 		TimeToFirstByte:  gotFirstByte.Sub(connect),
 		DownloadDuration: time.Since(gotFirstByte),
 	}
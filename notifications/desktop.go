@@ -3,6 +3,7 @@ package notifications
 import (
 	"fmt"
 
+	"github.com/Owloops/updo/redact"
 	"github.com/gen2brain/beeep"
 )
 
@@ -14,7 +15,7 @@ func alert(message string) error {
 func HandleAlerts(isUp bool, alertSent *bool, targetName string, targetURL string) error {
 	displayName := targetName
 	if displayName == "" {
-		displayName = targetURL
+		displayName = redact.URL(targetURL)
 	}
 
 	if !isUp && !*alertSent {
@@ -7,8 +7,9 @@ import (
 )
 
 const (
-	_discordColorRed   = 15158332
-	_discordColorGreen = 3066993
+	_discordColorRed    = 15158332
+	_discordColorGreen  = 3066993
+	_discordColorOrange = 15105570
 )
 
 type discordMessage struct {
@@ -35,9 +36,13 @@ type DiscordFormatter struct{}
 func (f *DiscordFormatter) Format(payload WebhookPayload) ([]byte, error) {
 	symbol := _symbolDown
 	color := _discordColorRed
-	if payload.Event == _eventTargetUp {
+	switch payload.Event {
+	case _eventTargetUp:
 		symbol = _symbolUp
 		color = _discordColorGreen
+	case _eventLatencyAnomaly:
+		symbol = _symbolAnomaly
+		color = _discordColorOrange
 	}
 
 	content := fmt.Sprintf("%s %s", symbol, payload.Event)
@@ -65,6 +70,14 @@ func (f *DiscordFormatter) Format(payload WebhookPayload) ([]byte, error) {
 		Inline: true,
 	})
 
+	if payload.BaselineMs > 0 {
+		fields = append(fields, discordField{
+			Name:   "Baseline",
+			Value:  fmt.Sprintf("%dms", payload.BaselineMs),
+			Inline: true,
+		})
+	}
+
 	msg := discordMessage{
 		Content: content,
 		Embeds: []discordEmbed{
@@ -12,6 +12,10 @@ const (
 	_colorGood       = "good"
 	_symbolDown      = "✘"
 	_symbolUp        = "✔"
+
+	_eventLatencyAnomaly = "latency_anomaly"
+	_colorWarning        = "warning"
+	_symbolAnomaly       = "⚠"
 )
 
 type slackMessage struct {
@@ -35,9 +39,13 @@ type SlackFormatter struct{}
 func (f *SlackFormatter) Format(payload WebhookPayload) ([]byte, error) {
 	symbol := _symbolDown
 	color := _colorDanger
-	if payload.Event == _eventTargetUp {
+	switch payload.Event {
+	case _eventTargetUp:
 		symbol = _symbolUp
 		color = _colorGood
+	case _eventLatencyAnomaly:
+		symbol = _symbolAnomaly
+		color = _colorWarning
 	}
 
 	text := fmt.Sprintf("%s %s: %s", symbol, payload.Event, payload.Target)
@@ -70,6 +78,14 @@ func (f *SlackFormatter) Format(payload WebhookPayload) ([]byte, error) {
 		Short: true,
 	})
 
+	if payload.BaselineMs > 0 {
+		fields = append(fields, slackField{
+			Title: "Baseline",
+			Value: fmt.Sprintf("%dms", payload.BaselineMs),
+			Short: true,
+		})
+	}
+
 	fields = append(fields, slackField{
 		Title: "Timestamp",
 		Value: payload.Timestamp.Format("2006-01-02 15:04:05 UTC"),
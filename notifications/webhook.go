@@ -2,12 +2,15 @@ package notifications
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Owloops/updo/httputil"
+	"github.com/Owloops/updo/redact"
 )
 
 const (
@@ -22,6 +25,7 @@ type WebhookPayload struct {
 	ResponseTimeMs int64     `json:"response_time_ms"`
 	Error          string    `json:"error,omitempty"`
 	StatusCode     int       `json:"status_code,omitempty"`
+	BaselineMs     int64     `json:"baseline_response_time_ms,omitempty"`
 }
 
 func SendWebhook(webhookURL string, headers map[string]string, payload WebhookPayload) error {
@@ -47,6 +51,11 @@ func SendWebhook(webhookURL string, headers map[string]string, payload WebhookPa
 
 	resp, err := client.Do(req)
 	if err != nil {
+		// Leave out the webhook URL, its path is often the secret.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
 		return fmt.Errorf("failed to send webhook: %w", err)
 	}
 	defer func() {
@@ -65,7 +74,7 @@ func SendWebhook(webhookURL string, headers map[string]string, payload WebhookPa
 func HandleWebhookAlert(webhookURL string, headers []string, isUp bool, alertSent *bool, targetName string, targetURL string, responseTime time.Duration, statusCode int, errorMsg string) error {
 	displayName := targetName
 	if displayName == "" {
-		displayName = targetURL
+		displayName = redact.URL(targetURL)
 	}
 
 	var event string
@@ -88,11 +97,11 @@ func HandleWebhookAlert(webhookURL string, headers []string, isUp bool, alertSen
 	payload := WebhookPayload{
 		Event:          event,
 		Target:         displayName,
-		URL:            targetURL,
+		URL:            redact.URL(targetURL),
 		Timestamp:      time.Now().UTC(),
 		ResponseTimeMs: responseTime.Milliseconds(),
 		StatusCode:     statusCode,
-		Error:          errorMsg,
+		Error:          redact.Text(errorMsg),
 	}
 
 	headerMap := httputil.ParseHeaders(headers)
@@ -102,3 +111,30 @@ func HandleWebhookAlert(webhookURL string, headers []string, isUp bool, alertSen
 	}
 	return nil
 }
+
+// HandleAnomalyWebhook reports a latency anomaly. Callers decide when an
+// anomaly is worth sending, typically only at its onset.
+func HandleAnomalyWebhook(webhookURL string, headers []string, targetName string, targetURL string, responseTime time.Duration, baseline time.Duration) error {
+	if webhookURL == "" {
+		return nil
+	}
+
+	displayName := targetName
+	if displayName == "" {
+		displayName = redact.URL(targetURL)
+	}
+
+	payload := WebhookPayload{
+		Event:          _eventLatencyAnomaly,
+		Target:         displayName,
+		URL:            redact.URL(targetURL),
+		Timestamp:      time.Now().UTC(),
+		ResponseTimeMs: responseTime.Milliseconds(),
+		BaselineMs:     baseline.Milliseconds(),
+	}
+
+	if err := SendWebhook(webhookURL, httputil.ParseHeaders(headers), payload); err != nil {
+		return fmt.Errorf("failed to send anomaly webhook for %s: %w", displayName, err)
+	}
+	return nil
+}
@@ -248,3 +248,31 @@ func TestHandleWebhookAlertEmptyURL(t *testing.T) {
 		t.Error("Alert state should still be updated even without webhook URL")
 	}
 }
+
+func TestHandleAnomalyWebhook(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&receivedPayload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	err := HandleAnomalyWebhook(server.URL, nil, "Test Site", "https://example.com", 600*time.Millisecond, 80*time.Millisecond)
+	if err != nil {
+		t.Fatalf("HandleAnomalyWebhook failed: %v", err)
+	}
+
+	if receivedPayload.Event != "latency_anomaly" {
+		t.Errorf("Expected event latency_anomaly, got %s", receivedPayload.Event)
+	}
+	if receivedPayload.ResponseTimeMs != 600 || receivedPayload.BaselineMs != 80 {
+		t.Errorf("Expected 600ms vs 80ms baseline, got %dms vs %dms", receivedPayload.ResponseTimeMs, receivedPayload.BaselineMs)
+	}
+
+	if err := HandleAnomalyWebhook("", nil, "Test Site", "https://example.com", time.Second, time.Millisecond); err != nil {
+		t.Errorf("Expected no error for empty webhook URL, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package redact
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// DefaultHeaders and DefaultQueryParams are always redacted unless the
+// defaults are disabled.
+var (
+	DefaultHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"}
+	DefaultQueryParams = []string{"access_token", "api_key", "apikey", "client_secret", "key", "password", "secret", "sig", "signature", "token"}
+)
+
+var _urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// Redactor masks secrets in headers, URLs and bodies before they are
+// rendered or exported. Results keep the original values for assertions.
+type Redactor struct {
+	headers     []string
+	queryParams []string
+	body        []*regexp.Regexp
+}
+
+var (
+	_mu      sync.RWMutex
+	_current = &Redactor{
+		headers:     canonicalHeaders(DefaultHeaders),
+		queryParams: lowerAll(DefaultQueryParams),
+	}
+)
+
+// New builds a Redactor for the given header names, query parameter names
+// and body regular expressions, in addition to the defaults unless
+// withoutDefaults is set.
+func New(headers, queryParams, bodyPatterns []string, withoutDefaults bool) (*Redactor, error) {
+	if !withoutDefaults {
+		headers = append(slices.Clone(DefaultHeaders), headers...)
+		queryParams = append(slices.Clone(DefaultQueryParams), queryParams...)
+	}
+
+	r := &Redactor{
+		headers:     canonicalHeaders(headers),
+		queryParams: lowerAll(queryParams),
+	}
+	for _, pattern := range bodyPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.body = append(r.body, re)
+	}
+	return r, nil
+}
+
+// Set makes r the redactor used by the package level functions.
+func Set(r *Redactor) {
+	_mu.Lock()
+	defer _mu.Unlock()
+	_current = r
+}
+
+// Current returns the redactor in use.
+func Current() *Redactor {
+	_mu.RLock()
+	defer _mu.RUnlock()
+	return _current
+}
+
+func Headers(headers map[string][]string) map[string][]string { return Current().Headers(headers) }
+func URL(raw string) string                                   { return Current().URL(raw) }
+func Body(body string) string                                 { return Current().Body(body) }
+func Text(text string) string                                 { return Current().Text(text) }
+
+// Headers returns a copy of headers with sensitive values masked.
+func (r *Redactor) Headers(headers map[string][]string) map[string][]string {
+	if len(headers) == 0 || len(r.headers) == 0 {
+		return headers
+	}
+
+	redacted := maps.Clone(headers)
+	for name := range redacted {
+		if r.SensitiveHeader(name) {
+			redacted[name] = []string{Mask}
+		}
+	}
+	return redacted
+}
+
+// SensitiveHeader reports whether the header's value must be masked.
+func (r *Redactor) SensitiveHeader(name string) bool {
+	return slices.Contains(r.headers, http.CanonicalHeaderKey(strings.TrimSpace(name)))
+}
+
+// HeaderLine masks the value of a "Name: value" header if the header is
+// sensitive.
+func (r *Redactor) HeaderLine(line string) string {
+	name, _, found := strings.Cut(line, ":")
+	if !found || !r.SensitiveHeader(name) {
+		return line
+	}
+	return name + ": " + Mask
+}
+
+// URL masks sensitive query parameters and the password of raw. Anything
+// that does not parse as a URL is returned unchanged.
+func (r *Redactor) URL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.RawQuery == "" && parsed.User == nil) {
+		return raw
+	}
+
+	if parsed.RawQuery != "" {
+		params := strings.Split(parsed.RawQuery, "&")
+		for i, param := range params {
+			name, _, _ := strings.Cut(param, "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if slices.Contains(r.queryParams, strings.ToLower(name)) {
+				params[i] = name + "=" + Mask
+			}
+		}
+		parsed.RawQuery = strings.Join(params, "&")
+	}
+
+	return parsed.Redacted()
+}
+
+// Body masks every match of the configured body patterns.
+func (r *Redactor) Body(body string) string {
+	for _, re := range r.body {
+		body = re.ReplaceAllString(body, Mask)
+	}
+	return body
+}
+
+// Text masks URLs and body patterns in free text such as error messages.
+func (r *Redactor) Text(text string) string {
+	return r.Body(_urlPattern.ReplaceAllStringFunc(text, r.URL))
+}
+
+func canonicalHeaders(headers []string) []string {
+	canonical := make([]string, 0, len(headers))
+	for _, header := range headers {
+		canonical = append(canonical, http.CanonicalHeaderKey(strings.TrimSpace(header)))
+	}
+	return canonical
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(value))
+	}
+	return lowered
+}
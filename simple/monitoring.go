@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/Owloops/updo/aws"
 	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/logship"
 	"github.com/Owloops/updo/metrics"
 	"github.com/Owloops/updo/net"
 	"github.com/Owloops/updo/notifications"
@@ -45,11 +48,15 @@ func getErrorMessage(result net.WebsiteCheckResult) string {
 }
 
 type TargetResult struct {
-	Target   config.Target
-	Result   net.WebsiteCheckResult
-	Stats    stats.Stats
-	Sequence int
-	Region   string
+	Target    config.Target
+	TargetKey stats.TargetKey
+	Result    net.WebsiteCheckResult
+	Stats     stats.Stats
+	Sequence  int
+	Region    string
+	Anomaly   *stats.Anomaly
+	// TargetID identifies the target across reloads, see stats.TargetIDs.
+	TargetID string
 }
 
 type MonitoringOptions struct {
@@ -58,78 +65,119 @@ type MonitoringOptions struct {
 	Regions       []string
 	Profile       string
 	PrometheusURL string
+	StateFile     string
+	MetricsListen string
+	OTLPEndpoint  string
+	OTLPProtocol  string
+	Metrics       config.Metrics
+	LogOutput     string
+	LogFormat     string
+	LogFields     []string
+	Logs          config.Logs
+	// ConfigFile is reloaded when it changes or on SIGHUP, with the Only
+	// and Skip filters applied again. Runs with a Count are not reloaded.
+	ConfigFile string
+	Only       []string
+	Skip       []string
+	// DiscoveryFiles are the glob patterns of the file_sd files of
+	// ConfigFile, watched along with it. With discovery files, monitoring
+	// may start without targets and wait for them to be discovered.
+	DiscoveryFiles []string
+	// IncludeFiles are the glob patterns of the files ConfigFile includes,
+	// watched along with it.
+	IncludeFiles []string
 }
 
 func StartMultiTargetMonitoring(targets []config.Target, options MonitoringOptions) {
-	if len(targets) == 0 {
+	reloadable := options.ConfigFile != "" && options.Count == 0
+	if len(targets) == 0 && (!reloadable || len(options.DiscoveryFiles) == 0) {
 		log.Fatal("No targets provided")
 	}
 
-	keyRegistry := stats.NewTargetKeyRegistry(targets, options.Regions)
-	allKeys := keyRegistry.GetAllKeys()
+	if err := setupLogSchema(options); err != nil {
+		log.Fatalf("Invalid log settings: %v", err)
+	}
+
+	logOutput := options.LogOutput
+	if logOutput == "" {
+		logOutput = options.Logs.Output
+	}
+	output, err := utils.OpenOutput(logOutput, options.Logs.Syslog.Facility, options.Logs.Syslog.AppName)
+	if err != nil {
+		log.Fatalf("Failed to open log output: %v", err)
+	}
+	if output != nil {
+		utils.SetOutput(output)
+		log.SetFlags(0)
+		log.SetOutput(utils.NewLogWriter())
+		metrics.SetLogHandler(func(message string, err error) {
+			if err != nil {
+				utils.PrintLine(utils.SeverityWarning, fmt.Sprintf("%s: %v", message, err), nil)
+				return
+			}
+			utils.PrintLine(utils.SeverityInfo, message, nil)
+		})
+		defer func() {
+			metrics.SetLogHandler(nil)
+			log.SetOutput(os.Stderr)
+			log.SetFlags(log.LstdFlags)
+			utils.SetOutput(nil)
+			_ = output.Close()
+		}()
+	}
 
-	monitors := make(map[string]*stats.Monitor, len(allKeys))
-	sequences := make(map[string]*int, len(allKeys))
-	alertStates := make(map[string]*bool, len(allKeys))
-	webhookAlertStates := make(map[string]*bool, len(allKeys))
+	if err := metrics.Setup(metrics.SetupOptions{
+		PrometheusURL: options.PrometheusURL,
+		MetricsListen: options.MetricsListen,
+		OTLPEndpoint:  options.OTLPEndpoint,
+		OTLPProtocol:  options.OTLPProtocol,
+		Config:        options.Metrics,
+	}); err != nil {
+		log.Fatalf("Failed to start metrics export: %v", err)
+	}
+	defer metrics.StopSinks()
 
-	for _, key := range allKeys {
-		monitor, err := stats.NewMonitor()
-		if err != nil {
-			log.Fatalf("Failed to initialize stats monitor for %s: %v", key.String(), err)
+	if options.Log != "" {
+		if err := logship.Setup(options.Logs); err != nil {
+			log.Fatalf("Failed to start log shipping: %v", err)
 		}
-		keyStr := key.String()
-		monitors[keyStr] = monitor
-		var seq int
-		var alert bool
-		var webhookAlert bool
-		sequences[keyStr] = &seq
-		alertStates[keyStr] = &alert
-		webhookAlertStates[keyStr] = &webhookAlert
+		defer logship.Stop()
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	keyRegistry := stats.NewTargetKeyRegistry(targets, options.Regions)
+	incidents := stats.NewIncidentTracker()
+
+	state, err := stats.NewTargetState(keyRegistry, targets, nil)
+	if err != nil {
+		log.Fatalf("Failed to initialize stats monitor for %v", err)
+	}
 
-	prometheusURL := options.PrometheusURL
-	if prometheusURL == "" {
-		if updoURL := os.Getenv("UPDO_PROMETHEUS_RW_SERVER_URL"); updoURL != "" {
-			prometheusURL = updoURL
+	if options.StateFile != "" {
+		if _, err := stats.LoadState(options.StateFile, state.Monitors, state.AlertStates, state.WebhookAlertStates); err != nil {
+			log.Printf("Warning: could not restore state from %s: %v", options.StateFile, err)
 		}
 	}
 
-	if prometheusURL != "" {
-		metricsConfig := metrics.NewConfig()
-		metricsConfig.ServerURL = prometheusURL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		if username := os.Getenv("UPDO_PROMETHEUS_USERNAME"); username != "" {
-			metricsConfig.Username = username
-		}
-		if password := os.Getenv("UPDO_PROMETHEUS_PASSWORD"); password != "" {
-			metricsConfig.Password = password
-		}
-		if bearerToken := os.Getenv("UPDO_PROMETHEUS_BEARER_TOKEN"); bearerToken != "" {
-			metricsConfig.Headers["Authorization"] = "Bearer " + bearerToken
-		}
-		if authHeader := os.Getenv("UPDO_PROMETHEUS_AUTH_HEADER"); authHeader != "" {
-			parts := strings.SplitN(authHeader, ":", 2)
-			if len(parts) == 2 {
-				metricsConfig.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	resultsChan := make(chan TargetResult, max(len(targets), 1)*_resultsChannelMultiplier)
+	var wg sync.WaitGroup
+
+	if options.StateFile != "" {
+		// The state is saved once every monitoring goroutine has returned,
+		// so that no check is halfway through updating its monitor.
+		// Results still being sent are drained to let them finish.
+		defer func() {
+			cancel()
+			for range resultsChan {
 			}
-		}
-		if pushInterval := os.Getenv("UPDO_PROMETHEUS_PUSH_INTERVAL"); pushInterval != "" {
-			if duration, err := time.ParseDuration(pushInterval); err == nil {
-				metricsConfig.PushInterval = duration
+			if err := stats.SaveState(options.StateFile, state.Monitors, state.AlertStates, state.WebhookAlertStates); err != nil {
+				log.Printf("Failed to save state to %s: %v", options.StateFile, err)
 			}
-		}
-
-		metrics.InitRemoteWrite(metricsConfig)
-		defer metrics.StopRemoteWrite()
+		}()
 	}
 
-	resultsChan := make(chan TargetResult, len(targets)*_resultsChannelMultiplier)
-	var wg sync.WaitGroup
-
 	logMode := options.Log != ""
 
 	outputManager := NewOutputManager(targets)
@@ -137,12 +185,28 @@ func StartMultiTargetMonitoring(targets []config.Target, options MonitoringOptio
 		outputManager.PrintHeader()
 	}
 
-	for i, target := range targets {
+	runners := make(map[string]context.CancelFunc, len(targets))
+	startTarget := func(target config.Target, id string, index int) {
+		targetCtx, targetCancel := context.WithCancel(ctx)
+		runners[id] = targetCancel
+		targetState := state
 		wg.Add(1)
-		go func(t config.Target, index int) {
+		go func() {
 			defer wg.Done()
-			monitorTargetSimple(ctx, t, index, monitors, sequences, alertStates, webhookAlertStates, resultsChan, options)
-		}(target, i)
+			monitorTargetSimple(targetCtx, target, id, index, targetState.Monitors, targetState.Sequences, targetState.AlertStates, targetState.WebhookAlertStates, resultsChan, options)
+		}()
+	}
+
+	for i, target := range targets {
+		startTarget(target, keyRegistry.GetTargetID(i), i)
+	}
+
+	// Reloads may start targets at any time, even after all of them have
+	// been removed, so the results channel is kept open until monitoring
+	// ends.
+	if reloadable {
+		wg.Add(1)
+		defer wg.Done()
 	}
 
 	go func() {
@@ -150,6 +214,79 @@ func StartMultiTargetMonitoring(targets []config.Target, options MonitoringOptio
 		close(resultsChan)
 	}()
 
+	var watcher *config.Watcher
+	var reloads <-chan struct{}
+	if reloadable {
+		watcher, err = config.Watch(options.ConfigFile)
+		if err != nil {
+			log.Printf("Warning: config changes will not be reloaded: %v", err)
+		} else {
+			defer func() { _ = watcher.Close() }()
+			reloads = watcher.C
+			if err := watcher.WatchFiles(slices.Concat(options.IncludeFiles, options.DiscoveryFiles)); err != nil {
+				log.Printf("Warning: discovery file changes may not be noticed: %v", err)
+			}
+		}
+	}
+
+	// reload switches to the targets of the changed config file. Targets
+	// are matched by ID, so unchanged ones keep running wherever they
+	// moved in the list; the others are started before the old ones are
+	// stopped, so that the results channel is never closed in between.
+	reload := func() {
+		cfg, err := config.LoadConfig(options.ConfigFile)
+		if err != nil {
+			log.Printf("Config reload failed: %v", err)
+			return
+		}
+		for _, warning := range cfg.Warnings {
+			log.Printf("Warning: %v", warning)
+		}
+		if err := watcher.WatchFiles(slices.Concat(cfg.IncludePatterns, cfg.FileSDPatterns(options.ConfigFile))); err != nil {
+			log.Printf("Warning: discovery file changes may not be noticed: %v", err)
+		}
+		newTargets := cfg.FilterTargets(options.Only, options.Skip)
+		if len(newTargets) == 0 && len(cfg.FileSD) == 0 {
+			log.Printf("Config reload ignored: no targets to monitor after filtering")
+			return
+		}
+
+		newRegistry := stats.NewTargetKeyRegistry(newTargets, options.Regions)
+		newState, err := stats.NewTargetState(newRegistry, newTargets, state)
+		if err != nil {
+			log.Printf("Config reload failed: %v", err)
+			return
+		}
+
+		oldTargets := make(map[string]config.Target, len(targets))
+		for i, target := range targets {
+			oldTargets[keyRegistry.GetTargetID(i)] = target
+		}
+		oldRunners := runners
+		targets, keyRegistry, state = newTargets, newRegistry, newState
+		runners = make(map[string]context.CancelFunc, len(targets))
+
+		started, stopped := 0, 0
+		for i, target := range targets {
+			id := keyRegistry.GetTargetID(i)
+			if oldTarget, exists := oldTargets[id]; exists && reflect.DeepEqual(oldTarget, target) {
+				runners[id] = oldRunners[id]
+				delete(oldRunners, id)
+				continue
+			}
+			startTarget(target, id, i)
+			started++
+		}
+		for _, stop := range oldRunners {
+			stop()
+			stopped++
+		}
+
+		outputManager.SetTargets(targets)
+		metrics.RetainTargets(targets, options.Regions)
+		log.Printf("Reloaded %s: %d targets, %d started, %d stopped", options.ConfigFile, len(targets), started, stopped)
+	}
+
 	sigChan := make(chan os.Signal, _signalChannelBuffer)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -160,20 +297,36 @@ func StartMultiTargetMonitoring(targets []config.Target, options MonitoringOptio
 			if !ok {
 				return
 			}
+			// Stopped targets may still deliver a check that was in flight,
+			// and targets kept across a reload may have moved.
+			key, exists := keyRegistry.Lookup(result.TargetID, result.TargetKey.Region)
+			if !exists {
+				continue
+			}
+			result.TargetKey = key
 
 			totalChecks++
+			incident := incidents.Record(result.TargetKey, result.Result.IsUp, getErrorMessage(result.Result), time.Now())
 			if !logMode {
 				outputManager.PrintResult(result)
+				outputManager.PrintIncident(incident, result.Target)
 			} else {
-				utils.LogCheck(result.Result, result.Sequence, options.Log, result.Region)
+				utils.LogIncident(incident, result.Target.URL, result.Target.Labels)
+				utils.LogCheck(result.Result, result.Sequence, options.Log, result.Target.Labels, result.Region)
 				if !result.Result.IsUp {
 					errorMsg := getErrorMessage(result.Result)
-					utils.LogWarning(result.Target.URL, errorMsg, result.Region)
+					utils.LogWarning(result.Target.URL, errorMsg, result.Target.Labels, result.Region)
+				}
+				if result.Anomaly != nil {
+					utils.LogAnomaly(result.Anomaly, result.Target.URL, result.Target.Labels, result.Region)
 				}
 			}
 
-			if options.PrometheusURL != "" {
+			if metrics.Enabled() {
 				metrics.RecordCheck(result.Target, result.Result, result.Region)
+				if result.Stats.HasApdex() {
+					metrics.RecordApdex(result.Target, result.Stats.ApdexScore, result.Region)
+				}
 
 				if strings.HasPrefix(result.Target.URL, "https://") {
 					if sslExpiry := net.GetSSLCertExpiry(result.Target.URL); sslExpiry >= 0 {
@@ -183,20 +336,53 @@ func StartMultiTargetMonitoring(targets []config.Target, options MonitoringOptio
 			}
 
 			if options.Count > 0 && totalChecks >= options.Count*len(targets) {
-				outputManager.PrintFinalStatisticsWithKeys(monitors, keyRegistry, logMode)
+				outputManager.PrintFinalStatisticsWithKeys(state.Monitors, keyRegistry, incidents, logMode)
 				cancel()
 				return
 			}
 
+		case <-reloads:
+			reload()
+
 		case <-sigChan:
-			outputManager.PrintFinalStatisticsWithKeys(monitors, keyRegistry, logMode)
+			outputManager.PrintFinalStatisticsWithKeys(state.Monitors, keyRegistry, incidents, logMode)
 			cancel()
 			return
 		}
 	}
 }
 
-func monitorTargetSimple(ctx context.Context, target config.Target, targetIndex int, monitors map[string]*stats.Monitor, sequences map[string]*int, alertStates map[string]*bool, webhookAlertStates map[string]*bool, resultsChan chan<- TargetResult, options MonitoringOptions) {
+// setupLogSchema applies the [logs] field, redaction and format settings,
+// with the --log-format and --log-fields flags taking precedence.
+func setupLogSchema(options MonitoringOptions) error {
+	logs := options.Logs
+	if options.LogFormat != "" {
+		logs.Format = options.LogFormat
+	}
+	if len(options.LogFields) > 0 {
+		logs.Fields = options.LogFields
+	}
+
+	schema, err := utils.NewLogSchema(logs.Format, logs.Fields, logs.RedactHeaders, logs.RedactBody, logs.MaxBodyLength)
+	if err != nil {
+		return err
+	}
+	utils.SetLogSchema(schema)
+	return nil
+}
+
+func detectAnomaly(target config.Target, monitor *stats.Monitor, result net.WebsiteCheckResult) *stats.Anomaly {
+	anomaly := monitor.DetectAnomaly(result)
+	if anomaly != nil && anomaly.Onset && config.BoolVal(target.AnomalyAlert, false) {
+		if err := notifications.HandleAnomalyWebhook(target.WebhookURL, target.WebhookHeaders, target.Name, target.URL, anomaly.ResponseTime, anomaly.Baseline); err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+	}
+	return anomaly
+}
+
+func monitorTargetSimple(ctx context.Context, target config.Target, targetID string, targetIndex int, monitors map[string]*stats.Monitor, sequences map[string]*int, alertStates map[string]*bool, webhookAlertStates map[string]*bool, resultsChan chan<- TargetResult, options MonitoringOptions) {
+	indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 	ticker := time.NewTicker(target.GetRefreshInterval())
 	defer ticker.Stop()
 
@@ -225,17 +411,17 @@ func monitorTargetSimple(ctx context.Context, target config.Target, targetIndex
 		if len(regions) > 0 {
 			lambdaResults := aws.InvokeMultiRegion(target.URL, netConfig, regions, options.Profile)
 			for _, lambdaResult := range lambdaResults {
-				indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 				targetKey := stats.NewRegionTargetKey(indexedName, lambdaResult.Region, targetIndex)
 				keyStr := targetKey.String()
 
 				if monitor, exists := monitors[keyStr]; exists {
 					if lambdaResult.Error != nil {
-						utils.LogWarning(target.URL, fmt.Sprintf("Lambda invocation failed: %v", lambdaResult.Error), lambdaResult.Region)
+						utils.LogWarning(target.URL, fmt.Sprintf("Lambda invocation failed: %v", lambdaResult.Error), target.Labels, lambdaResult.Region)
 						continue
 					}
 
 					monitor.AddResult(lambdaResult.Result)
+					anomaly := detectAnomaly(target, monitor, lambdaResult.Result)
 					if sequence, exists := sequences[keyStr]; exists {
 						*sequence++
 					}
@@ -263,16 +449,18 @@ func monitorTargetSimple(ctx context.Context, target config.Target, targetIndex
 					}
 
 					resultsChan <- TargetResult{
-						Target:   target,
-						Result:   lambdaResult.Result,
-						Stats:    monitor.GetStats(),
-						Sequence: seq,
-						Region:   lambdaResult.Region,
+						Target:    target,
+						TargetKey: targetKey,
+						TargetID:  targetID,
+						Result:    lambdaResult.Result,
+						Stats:     monitor.GetStats(),
+						Sequence:  seq,
+						Region:    lambdaResult.Region,
+						Anomaly:   anomaly,
 					}
 				}
 			}
 		} else {
-			indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 			targetKey := stats.NewLocalTargetKey(indexedName, targetIndex)
 			keyStr := targetKey.String()
 
@@ -282,6 +470,7 @@ func monitorTargetSimple(ctx context.Context, target config.Target, targetIndex
 					log.Printf("Warning: response body from %s truncated at BodySizeLimit of %d bytes", target.URL, netConfig.BodySizeLimit)
 				}
 				monitor.AddResult(result)
+				anomaly := detectAnomaly(target, monitor, result)
 				if sequence, exists := sequences[keyStr]; exists {
 					*sequence++
 				}
@@ -309,11 +498,14 @@ func monitorTargetSimple(ctx context.Context, target config.Target, targetIndex
 				}
 
 				resultsChan <- TargetResult{
-					Target:   target,
-					Result:   result,
-					Stats:    monitor.GetStats(),
-					Sequence: seq,
-					Region:   "",
+					Target:    target,
+					TargetKey: targetKey,
+					TargetID:  targetID,
+					Result:    result,
+					Stats:     monitor.GetStats(),
+					Sequence:  seq,
+					Region:    "",
+					Anomaly:   anomaly,
 				}
 			}
 		}
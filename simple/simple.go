@@ -2,16 +2,20 @@ package simple
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 	"github.com/Owloops/updo/stats"
 	"github.com/Owloops/updo/utils"
 )
 
 type OutputManager struct {
+	out          io.Writer
 	targets      []config.Target
 	isSingle     bool
 	sslExpiry    map[string]int
@@ -21,6 +25,7 @@ type OutputManager struct {
 
 func NewOutputManager(targets []config.Target) *OutputManager {
 	return &OutputManager{
+		out:          utils.NewOutputWriter(utils.SeverityInfo),
 		targets:      targets,
 		isSingle:     len(targets) == 1,
 		sslExpiry:    make(map[string]int),
@@ -29,20 +34,36 @@ func NewOutputManager(targets []config.Target) *OutputManager {
 }
 
 func (m *OutputManager) PrintHeader() {
-	if m.isSingle {
-		fmt.Printf("UPDO %s:\n", m.targets[0].URL)
-	} else {
-		fmt.Println("UPDO monitoring:")
+	switch {
+	case len(m.targets) == 0:
+		fmt.Fprintln(m.out, "UPDO monitoring: waiting for targets to be discovered")
+	case m.isSingle:
+		fmt.Fprintf(m.out, "UPDO %s:\n", redact.URL(m.targets[0].URL))
+	default:
+		fmt.Fprintln(m.out, "UPDO monitoring:")
 		for _, target := range m.targets {
-			fmt.Printf("%s: %s\n", target.Name, target.URL)
+			fmt.Fprintf(m.out, "%s: %s\n", target.Name, redact.URL(target.URL))
 		}
 	}
 
 	m.startSSLCollection()
 }
 
+// SetTargets replaces the targets after a config reload.
+func (m *OutputManager) SetTargets(targets []config.Target) {
+	m.targets = targets
+	m.isSingle = len(targets) == 1
+	m.startSSLCollection()
+}
+
 func (m *OutputManager) startSSLCollection() {
 	for _, target := range m.targets {
+		m.sslExpiryMu.RLock()
+		collected := m.sslCollected[target.URL]
+		m.sslExpiryMu.RUnlock()
+		if collected {
+			continue
+		}
 		go func(url string) {
 			if strings.HasPrefix(url, "https://") {
 				sslDaysRemaining := net.GetSSLCertExpiry(url)
@@ -74,6 +95,10 @@ func (m *OutputManager) PrintResult(result TargetResult) {
 		statusInfo += " (assertion failed)"
 	}
 
+	if result.Anomaly != nil {
+		statusInfo += fmt.Sprintf(" (anomaly, baseline %dms)", result.Anomaly.Baseline.Milliseconds())
+	}
+
 	ipInfo := ""
 	if result.Result.ResolvedIP != "" {
 		ipInfo = fmt.Sprintf(" from %s", result.Result.ResolvedIP)
@@ -84,8 +109,9 @@ func (m *OutputManager) PrintResult(result TargetResult) {
 		regionInfo = fmt.Sprintf(" [%s]", result.Region)
 	}
 
+	var line string
 	if m.isSingle {
-		fmt.Printf("Response%s%s: seq=%d time=%dms %s uptime=%.1f%%\n",
+		line = fmt.Sprintf("Response%s%s: seq=%d time=%dms %s uptime=%.1f%%",
 			ipInfo,
 			regionInfo,
 			result.Sequence,
@@ -93,7 +119,7 @@ func (m *OutputManager) PrintResult(result TargetResult) {
 			statusInfo,
 			result.Stats.UptimePercent)
 	} else {
-		fmt.Printf("%s response%s%s: seq=%d time=%dms %s uptime=%.1f%%\n",
+		line = fmt.Sprintf("%s response%s%s: seq=%d time=%dms %s uptime=%.1f%%",
 			result.Target.Name,
 			ipInfo,
 			regionInfo,
@@ -102,6 +128,15 @@ func (m *OutputManager) PrintResult(result TargetResult) {
 			statusInfo,
 			result.Stats.UptimePercent)
 	}
+
+	severity := utils.SeverityInfo
+	switch {
+	case !result.Result.IsUp:
+		severity = utils.SeverityError
+	case result.Anomaly != nil:
+		severity = utils.SeverityWarning
+	}
+	utils.PrintLine(severity, line, utils.Record{Type: "check", URL: result.Target.URL, Region: result.Region, Labels: result.Target.Labels}.Fields())
 }
 
 func (m *OutputManager) PrintFinalStatistics(monitors map[string]*stats.Monitor, targets []config.Target, logMode bool) {
@@ -110,36 +145,46 @@ func (m *OutputManager) PrintFinalStatistics(monitors map[string]*stats.Monitor,
 	} else {
 		for _, target := range targets {
 			stats := monitors[target.Name].GetStats()
-			utils.LogMetrics(&stats, target.URL)
+			utils.LogMetrics(&stats, target.URL, target.Labels)
 		}
 	}
 }
 
-func (m *OutputManager) PrintFinalStatisticsWithKeys(monitors map[string]*stats.Monitor, keyRegistry *stats.TargetKeyRegistry, logMode bool) {
+func (m *OutputManager) PrintFinalStatisticsWithKeys(monitors map[string]*stats.Monitor, keyRegistry *stats.TargetKeyRegistry, incidents *stats.IncidentTracker, logMode bool) {
 	if !logMode {
-		m.PrintStatisticsWithKeys(monitors, keyRegistry)
+		m.PrintStatisticsWithKeys(monitors, keyRegistry, incidents)
 	} else {
-		targetMonitors := make(map[string]*stats.Monitor)
-		for _, target := range m.targets {
-			keys := keyRegistry.GetKeysForTarget(target.Name)
-			if len(keys) > 0 {
-				firstKey := keys[0]
-				if monitor, exists := monitors[firstKey.String()]; exists {
-					targetMonitors[target.Name] = monitor
+		for _, key := range keyRegistry.GetAllKeys() {
+			if key.TargetIndex < 0 || key.TargetIndex >= len(m.targets) {
+				continue
+			}
+			target := m.targets[key.TargetIndex]
+
+			if monitor, exists := monitors[key.String()]; exists {
+				region := ""
+				if !key.IsLocal {
+					region = key.Region
 				}
+				keyStats := monitor.GetStats()
+				utils.LogMetrics(&keyStats, target.URL, target.Labels, region)
 			}
 		}
 
-		for _, target := range m.targets {
-			if monitor, exists := targetMonitors[target.Name]; exists {
-				stats := monitor.GetStats()
-				utils.LogMetrics(&stats, target.URL)
+		for i, target := range m.targets {
+			globalKey := stats.NewGlobalTargetKey(fmt.Sprintf("%s#%d", target.Name, i), i)
+			regionKeys := keyRegistry.GetRegionKeys(globalKey)
+			if len(regionKeys) < 2 {
+				continue
+			}
+			if merged, err := stats.MergeKeys(monitors, regionKeys); err == nil {
+				globalStats := merged.GetStats()
+				utils.LogMetrics(&globalStats, target.URL, target.Labels, globalKey.Region)
 			}
 		}
 	}
 }
 
-func (m *OutputManager) PrintStatisticsWithKeys(monitors map[string]*stats.Monitor, keyRegistry *stats.TargetKeyRegistry) {
+func (m *OutputManager) PrintStatisticsWithKeys(monitors map[string]*stats.Monitor, keyRegistry *stats.TargetKeyRegistry, incidents *stats.IncidentTracker) {
 	if m.isSingle {
 		target := m.targets[0]
 		keys := keyRegistry.GetKeysForTarget(target.Name)
@@ -147,45 +192,22 @@ func (m *OutputManager) PrintStatisticsWithKeys(monitors map[string]*stats.Monit
 			return
 		}
 
-		var aggregatedStats stats.Stats
-		var hasStats bool
-
-		for _, key := range keys {
-			if monitor, exists := monitors[key.String()]; exists {
-				keyStats := monitor.GetStats()
-				if !hasStats {
-					aggregatedStats = keyStats
-					hasStats = true
-				} else {
-					aggregatedStats.ChecksCount += keyStats.ChecksCount
-					aggregatedStats.SuccessCount += keyStats.SuccessCount
-					if keyStats.MinResponseTime < aggregatedStats.MinResponseTime || aggregatedStats.MinResponseTime == 0 {
-						aggregatedStats.MinResponseTime = keyStats.MinResponseTime
-					}
-					if keyStats.MaxResponseTime > aggregatedStats.MaxResponseTime {
-						aggregatedStats.MaxResponseTime = keyStats.MaxResponseTime
-					}
-					if aggregatedStats.ChecksCount > 0 {
-						aggregatedStats.UptimePercent = float64(aggregatedStats.SuccessCount) / float64(aggregatedStats.ChecksCount) * 100
-					}
-				}
-			}
-		}
-
-		if hasStats {
-			fmt.Printf("\n--- %s statistics ---\n", target.URL)
+		merged, err := stats.MergeKeys(monitors, keys)
+		if err == nil && merged.ChecksCount > 0 {
+			aggregatedStats := merged.GetStats()
+			fmt.Fprintf(m.out, "\n--- %s statistics ---\n", redact.URL(target.URL))
 
 			successPercent := 0.0
 			if aggregatedStats.ChecksCount > 0 {
 				successPercent = float64(aggregatedStats.SuccessCount) / float64(aggregatedStats.ChecksCount) * 100
 			}
 
-			fmt.Printf("%d checks, %d successful (%.1f%%)\n",
+			fmt.Fprintf(m.out, "%d checks, %d successful (%.1f%%)\n",
 				aggregatedStats.ChecksCount,
 				aggregatedStats.SuccessCount,
 				successPercent)
 
-			fmt.Printf("uptime: %.1f%%\n", aggregatedStats.UptimePercent)
+			fmt.Fprintf(m.out, "uptime: %.1f%%\n", aggregatedStats.UptimePercent)
 
 			if aggregatedStats.ChecksCount > 0 {
 				var builder strings.Builder
@@ -199,60 +221,132 @@ func (m *OutputManager) PrintStatisticsWithKeys(monitors map[string]*stats.Monit
 				if aggregatedStats.ChecksCount >= 2 && aggregatedStats.P95 > 0 {
 					fmt.Fprintf(&builder, ", 95th percentile: %d ms", aggregatedStats.P95.Milliseconds())
 				}
+				if aggregatedStats.HasApdex() {
+					fmt.Fprintf(&builder, ", apdex: %.2f", aggregatedStats.ApdexScore)
+				}
 
-				fmt.Println(builder.String())
+				fmt.Fprintln(m.out, builder.String())
 			}
 
 			if sslDays := m.getSSLExpiry(target.URL); sslDays > 0 {
-				fmt.Printf("SSL certificate expires in %d days\n", sslDays)
+				fmt.Fprintf(m.out, "SSL certificate expires in %d days\n", sslDays)
 			}
+
+			m.printIncidents(incidents, keys[0].TargetName, "")
 		}
 	} else {
-		fmt.Println("\n--- statistics ---")
+		fmt.Fprintln(m.out, "\n--- statistics ---")
 		allKeys := keyRegistry.GetAllKeys()
-		for _, key := range allKeys {
+		for i, key := range allKeys {
 			if key.TargetIndex >= 0 && key.TargetIndex < len(m.targets) {
 				target := m.targets[key.TargetIndex]
 
-				fmt.Printf("\n%s (%s):\n", target.Name, target.URL)
+				fmt.Fprintf(m.out, "\n%s (%s):\n", target.Name, redact.URL(target.URL))
 
 				if monitor, exists := monitors[key.String()]; exists {
 					stats := monitor.GetStats()
 					if !key.IsLocal {
-						fmt.Printf("  Region [%s]:\n", key.Region)
+						fmt.Fprintf(m.out, "  Region [%s]:\n", key.Region)
 						m.printTargetStatsIndented(stats, target.URL)
 					} else {
 						m.printTargetStats(stats, target.URL)
 					}
 				}
+
+				isLastForTarget := i == len(allKeys)-1 || allKeys[i+1].TargetIndex != key.TargetIndex
+				if isLastForTarget && !key.IsLocal {
+					m.printGlobalStats(monitors, keyRegistry, key, target.URL)
+				}
+				if isLastForTarget {
+					m.printIncidents(incidents, key.TargetName, "  ")
+				}
 			}
 		}
 	}
 }
 
+func (m *OutputManager) printIncidents(incidents *stats.IncidentTracker, targetName string, indent string) {
+	if incidents == nil {
+		return
+	}
+	targetIncidents := incidents.Incidents(targetName)
+	if len(targetIncidents) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var downtime time.Duration
+	for _, incident := range targetIncidents {
+		downtime += incident.Duration(now)
+	}
+
+	fmt.Fprintf(m.out, "%sincidents: %d, total downtime %s\n", indent, len(targetIncidents), utils.FormatDurationMinute(downtime))
+	for _, incident := range targetIncidents {
+		fmt.Fprintf(m.out, "%s  %s\n", indent, utils.FormatIncident(incident, now))
+	}
+}
+
+// PrintIncident announces an incident opening or resolving. A nil incident
+// is ignored so callers can pass IncidentTracker.Record's result directly.
+func (m *OutputManager) PrintIncident(incident *stats.Incident, target config.Target) {
+	if incident == nil {
+		return
+	}
+
+	name := redact.URL(target.URL)
+	if !m.isSingle {
+		name = target.Name
+	}
+
+	fields := utils.Record{Type: "incident", URL: target.URL, Labels: target.Labels}.Fields()
+	if incident.IsOngoing() {
+		utils.PrintLine(utils.SeverityError, fmt.Sprintf("%s incident started: %s", name, utils.FormatIncident(*incident, time.Now())), fields)
+	} else {
+		utils.PrintLine(utils.SeverityNotice, fmt.Sprintf("%s incident resolved: %s", name, utils.FormatIncident(*incident, time.Now())), fields)
+	}
+}
+
+func (m *OutputManager) printGlobalStats(monitors map[string]*stats.Monitor, keyRegistry *stats.TargetKeyRegistry, key stats.TargetKey, url string) {
+	regionKeys := keyRegistry.GetRegionKeys(stats.NewGlobalTargetKey(key.TargetName, key.TargetIndex))
+	if len(regionKeys) < 2 {
+		return
+	}
+
+	merged, err := stats.MergeKeys(monitors, regionKeys)
+	if err != nil || merged.ChecksCount == 0 {
+		return
+	}
+
+	fmt.Fprintf(m.out, "  Global [%d regions]:\n", len(regionKeys))
+	m.printTargetStatsIndented(merged.GetStats(), url)
+}
+
 func (m *OutputManager) printTargetStats(stats stats.Stats, url string) {
 	successPercent := 0.0
 	if stats.ChecksCount > 0 {
 		successPercent = float64(stats.SuccessCount) / float64(stats.ChecksCount) * 100
 	}
 
-	fmt.Printf("  %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
+	fmt.Fprintf(m.out, "  %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
 		stats.ChecksCount, stats.SuccessCount, successPercent, stats.UptimePercent)
 
 	if stats.ChecksCount > 0 {
-		fmt.Printf("  response time min/avg/max = %d/%d/%d ms",
+		fmt.Fprintf(m.out, "  response time min/avg/max = %d/%d/%d ms",
 			stats.MinResponseTime.Milliseconds(),
 			stats.AvgResponseTime.Milliseconds(),
 			stats.MaxResponseTime.Milliseconds())
 
 		if stats.ChecksCount >= 2 && stats.P95 > 0 {
-			fmt.Printf(", 95p: %d ms", stats.P95.Milliseconds())
+			fmt.Fprintf(m.out, ", 95p: %d ms", stats.P95.Milliseconds())
+		}
+		if stats.HasApdex() {
+			fmt.Fprintf(m.out, ", apdex: %.2f", stats.ApdexScore)
 		}
-		fmt.Println()
+		fmt.Fprintln(m.out)
 	}
 
 	if sslDays := m.getSSLExpiry(url); sslDays > 0 {
-		fmt.Printf("  SSL certificate expires in %d days\n", sslDays)
+		fmt.Fprintf(m.out, "  SSL certificate expires in %d days\n", sslDays)
 	}
 }
 
@@ -262,23 +356,26 @@ func (m *OutputManager) printTargetStatsIndented(stats stats.Stats, url string)
 		successPercent = float64(stats.SuccessCount) / float64(stats.ChecksCount) * 100
 	}
 
-	fmt.Printf("    %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
+	fmt.Fprintf(m.out, "    %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
 		stats.ChecksCount, stats.SuccessCount, successPercent, stats.UptimePercent)
 
 	if stats.ChecksCount > 0 {
-		fmt.Printf("    response time min/avg/max = %d/%d/%d ms",
+		fmt.Fprintf(m.out, "    response time min/avg/max = %d/%d/%d ms",
 			stats.MinResponseTime.Milliseconds(),
 			stats.AvgResponseTime.Milliseconds(),
 			stats.MaxResponseTime.Milliseconds())
 
 		if stats.ChecksCount >= 2 && stats.P95 > 0 {
-			fmt.Printf(", 95p: %d ms", stats.P95.Milliseconds())
+			fmt.Fprintf(m.out, ", 95p: %d ms", stats.P95.Milliseconds())
 		}
-		fmt.Println()
+		if stats.HasApdex() {
+			fmt.Fprintf(m.out, ", apdex: %.2f", stats.ApdexScore)
+		}
+		fmt.Fprintln(m.out)
 	}
 
 	if sslDays := m.getSSLExpiry(url); sslDays > 0 {
-		fmt.Printf("    SSL certificate expires in %d days\n", sslDays)
+		fmt.Fprintf(m.out, "    SSL certificate expires in %d days\n", sslDays)
 	}
 }
 
@@ -288,19 +385,19 @@ func (m *OutputManager) PrintStatistics(monitors map[string]*stats.Monitor) {
 		monitor := monitors[target.Name]
 		stats := monitor.GetStats()
 
-		fmt.Printf("\n--- %s statistics ---\n", target.URL)
+		fmt.Fprintf(m.out, "\n--- %s statistics ---\n", redact.URL(target.URL))
 
 		successPercent := 0.0
 		if stats.ChecksCount > 0 {
 			successPercent = float64(stats.SuccessCount) / float64(stats.ChecksCount) * 100
 		}
 
-		fmt.Printf("%d checks, %d successful (%.1f%%)\n",
+		fmt.Fprintf(m.out, "%d checks, %d successful (%.1f%%)\n",
 			stats.ChecksCount,
 			stats.SuccessCount,
 			successPercent)
 
-		fmt.Printf("uptime: %.1f%%\n", stats.UptimePercent)
+		fmt.Fprintf(m.out, "uptime: %.1f%%\n", stats.UptimePercent)
 
 		if stats.ChecksCount > 0 {
 			var builder strings.Builder
@@ -314,43 +411,49 @@ func (m *OutputManager) PrintStatistics(monitors map[string]*stats.Monitor) {
 			if stats.ChecksCount >= 2 && stats.P95 > 0 {
 				fmt.Fprintf(&builder, ", 95th percentile: %d ms", stats.P95.Milliseconds())
 			}
+			if stats.HasApdex() {
+				fmt.Fprintf(&builder, ", apdex: %.2f", stats.ApdexScore)
+			}
 
-			fmt.Println(builder.String())
+			fmt.Fprintln(m.out, builder.String())
 		}
 
 		if sslDays := m.getSSLExpiry(target.URL); sslDays > 0 {
-			fmt.Printf("SSL certificate expires in %d days\n", sslDays)
+			fmt.Fprintf(m.out, "SSL certificate expires in %d days\n", sslDays)
 		}
 	} else {
-		fmt.Println("\n--- statistics ---")
+		fmt.Fprintln(m.out, "\n--- statistics ---")
 		for _, target := range m.targets {
 			monitor := monitors[target.Name]
 			stats := monitor.GetStats()
 
-			fmt.Printf("\n%s (%s):\n", target.Name, target.URL)
+			fmt.Fprintf(m.out, "\n%s (%s):\n", target.Name, redact.URL(target.URL))
 
 			successPercent := 0.0
 			if stats.ChecksCount > 0 {
 				successPercent = float64(stats.SuccessCount) / float64(stats.ChecksCount) * 100
 			}
 
-			fmt.Printf("  %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
+			fmt.Fprintf(m.out, "  %d checks, %d successful (%.1f%%), uptime: %.1f%%\n",
 				stats.ChecksCount, stats.SuccessCount, successPercent, stats.UptimePercent)
 
 			if stats.ChecksCount > 0 {
-				fmt.Printf("  response time min/avg/max = %d/%d/%d ms",
+				fmt.Fprintf(m.out, "  response time min/avg/max = %d/%d/%d ms",
 					stats.MinResponseTime.Milliseconds(),
 					stats.AvgResponseTime.Milliseconds(),
 					stats.MaxResponseTime.Milliseconds())
 
 				if stats.ChecksCount >= 2 && stats.P95 > 0 {
-					fmt.Printf(", 95p: %d ms", stats.P95.Milliseconds())
+					fmt.Fprintf(m.out, ", 95p: %d ms", stats.P95.Milliseconds())
+				}
+				if stats.HasApdex() {
+					fmt.Fprintf(m.out, ", apdex: %.2f", stats.ApdexScore)
 				}
-				fmt.Println()
+				fmt.Fprintln(m.out)
 			}
 
 			if sslDays := m.getSSLExpiry(target.URL); sslDays > 0 {
-				fmt.Printf("  SSL certificate expires in %d days\n", sslDays)
+				fmt.Fprintf(m.out, "  SSL certificate expires in %d days\n", sslDays)
 			}
 		}
 	}
@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"math"
+	"time"
+
+	"github.com/Owloops/updo/net"
+)
+
+const (
+	_anomalyAlpha         = 0.1
+	_anomalyWarmupSamples = 10
+	_anomalyZScore        = 3.0
+	// _anomalyMinShift keeps very stable targets from flagging jitter by
+	// requiring a response to exceed the baseline by this fraction as well.
+	_anomalyMinShift = 0.5
+	_anomalyMinStdMs = 1.0
+)
+
+// Anomaly describes a response time that deviates significantly above the
+// exponentially weighted baseline of its target key.
+type Anomaly struct {
+	Timestamp    time.Time
+	ResponseTime time.Duration
+	Baseline     time.Duration
+	StdDev       time.Duration
+	ZScore       float64
+	// Onset is set on the first anomalous check after normal ones, so
+	// callers can notify once per latency shift instead of once per check.
+	Onset bool
+}
+
+// _baselineSlots is the number of hour-of-week buckets of a seasonal
+// baseline.
+const _baselineSlots = 7 * 24
+
+// ewma is an exponentially weighted moving average and variance of
+// response times, in milliseconds.
+type ewma struct {
+	mean     float64
+	variance float64
+	samples  int
+}
+
+func (e *ewma) warm() bool {
+	return e.samples >= _anomalyWarmupSamples
+}
+
+func (e *ewma) update(x float64) {
+	e.samples++
+	alpha := math.Max(_anomalyAlpha, 1/float64(e.samples))
+	diff := x - e.mean
+	increment := alpha * diff
+	e.mean += increment
+	e.variance = (1 - alpha) * (e.variance + diff*increment)
+}
+
+// Baseline tracks successful response times both overall and per hour of
+// the week, so a service that is always slower during its nightly batch
+// window is compared with earlier nights rather than with its daytime
+// latency. Until the hour-of-week slot of a check has warmed up, the check
+// is compared with the overall average.
+type Baseline struct {
+	global   ewma
+	seasonal [_baselineSlots]ewma
+	active   bool
+}
+
+func baselineSlot(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// reference returns the average a check at now is compared with, or nil
+// during warmup.
+func (b *Baseline) reference(now time.Time) *ewma {
+	if slot := &b.seasonal[baselineSlot(now)]; slot.warm() {
+		return slot
+	}
+	if b.global.warm() {
+		return &b.global
+	}
+	return nil
+}
+
+// Observe folds responseTime into the baseline and reports whether it was
+// anomalous relative to the baseline before the update.
+func (b *Baseline) Observe(responseTime time.Duration, now time.Time) (Anomaly, bool) {
+	x := float64(responseTime) / float64(time.Millisecond)
+
+	var anomaly Anomaly
+	anomalous := false
+
+	if ref := b.reference(now); ref != nil {
+		stdDev := math.Max(math.Sqrt(ref.variance), _anomalyMinStdMs)
+		deviation := x - ref.mean
+		zScore := deviation / stdDev
+
+		if zScore > _anomalyZScore && deviation > ref.mean*_anomalyMinShift {
+			anomalous = true
+			anomaly = Anomaly{
+				Timestamp:    now,
+				ResponseTime: responseTime,
+				Baseline:     time.Duration(ref.mean * float64(time.Millisecond)),
+				StdDev:       time.Duration(stdDev * float64(time.Millisecond)),
+				ZScore:       zScore,
+				Onset:        !b.active,
+			}
+		}
+	}
+
+	b.global.update(x)
+	b.seasonal[baselineSlot(now)].update(x)
+	b.active = anomalous
+
+	return anomaly, anomalous
+}
+
+// DetectAnomaly feeds a check result into the monitor's response time
+// baseline and returns the anomaly it represents, if any. Failed checks are
+// left to the up/down alerts and never update the baseline.
+func (m *Monitor) DetectAnomaly(result net.WebsiteCheckResult) *Anomaly {
+	if !result.IsUp {
+		return nil
+	}
+
+	m.mu.Lock()
+	anomaly, found := m.baseline.Observe(result.ResponseTime, time.Now())
+	m.mu.Unlock()
+	if !found {
+		return nil
+	}
+	return &anomaly
+}
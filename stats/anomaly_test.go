@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/net"
+)
+
+func TestBaseline_Observe(t *testing.T) {
+	var baseline Baseline
+	now := time.Now()
+
+	for i := range 20 {
+		responseTime := 80*time.Millisecond + time.Duration(i%3)*time.Millisecond
+		if _, found := baseline.Observe(responseTime, now); found {
+			t.Fatalf("Unexpected anomaly for stable sample %d (%v)", i, responseTime)
+		}
+	}
+
+	anomaly, found := baseline.Observe(600*time.Millisecond, now)
+	if !found {
+		t.Fatal("Expected a latency jump to be flagged")
+	}
+	if !anomaly.Onset {
+		t.Error("First anomalous sample should be the onset")
+	}
+	if anomaly.Baseline < 75*time.Millisecond || anomaly.Baseline > 85*time.Millisecond {
+		t.Errorf("Baseline = %v, want about 80ms", anomaly.Baseline)
+	}
+
+	anomaly, found = baseline.Observe(650*time.Millisecond, now)
+	if found && anomaly.Onset {
+		t.Error("Consecutive anomalies should not repeat the onset")
+	}
+}
+
+func TestBaseline_WarmupAndMinShift(t *testing.T) {
+	var baseline Baseline
+	now := time.Now()
+
+	if _, found := baseline.Observe(10*time.Millisecond, now); found {
+		t.Error("Should not flag anomalies during warmup")
+	}
+	if _, found := baseline.Observe(900*time.Millisecond, now); found {
+		t.Error("Should not flag anomalies during warmup")
+	}
+
+	var stable Baseline
+	for range 20 {
+		stable.Observe(100*time.Millisecond, now)
+	}
+	if _, found := stable.Observe(110*time.Millisecond, now); found {
+		t.Error("Small shifts on a flat baseline should not be flagged")
+	}
+}
+
+func TestBaseline_Seasonal(t *testing.T) {
+	var baseline Baseline
+	day := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 10, 12, 2, 0, 0, 0, time.UTC)
+
+	for range 50 {
+		baseline.Observe(80*time.Millisecond, day)
+	}
+
+	if _, found := baseline.Observe(600*time.Millisecond, night); !found {
+		t.Error("Expected a cold hour-of-week slot to fall back to the overall baseline")
+	}
+	for range 20 {
+		baseline.Observe(600*time.Millisecond, night)
+	}
+
+	if _, found := baseline.Observe(610*time.Millisecond, night); found {
+		t.Error("Slow responses in an hour that is always slow should not be flagged")
+	}
+	if _, found := baseline.Observe(600*time.Millisecond, day.Add(7*24*time.Hour)); !found {
+		t.Error("Slow responses in a fast hour of the week should be flagged")
+	}
+}
+
+func TestMonitor_DetectAnomalyIgnoresFailures(t *testing.T) {
+	monitor, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+
+	for range 20 {
+		monitor.DetectAnomaly(net.WebsiteCheckResult{IsUp: true, ResponseTime: 80 * time.Millisecond})
+	}
+
+	if anomaly := monitor.DetectAnomaly(net.WebsiteCheckResult{IsUp: false, ResponseTime: 10 * time.Second}); anomaly != nil {
+		t.Error("Failed checks should not be reported as anomalies")
+	}
+	if anomaly := monitor.DetectAnomaly(net.WebsiteCheckResult{IsUp: true, ResponseTime: time.Second}); anomaly == nil {
+		t.Error("Expected anomaly after a failed check left the baseline untouched")
+	}
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// Incident is a period during which at least one check of a target was
+// failing. For multi-region targets it stays open until every region that
+// went down has recovered.
+type Incident struct {
+	// Target is the configured name of the target, without the suffix
+	// its keys use to tell targets sharing a name apart.
+	Target     string    `json:"target"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end,omitzero"`
+	FirstError string    `json:"first_error,omitempty"`
+	Regions    []string  `json:"regions,omitempty"`
+}
+
+func (i Incident) IsOngoing() bool {
+	return i.End.IsZero()
+}
+
+// Duration returns how long the incident lasted, or has lasted so far
+// at now if it is still ongoing.
+func (i Incident) Duration(now time.Time) time.Duration {
+	if i.IsOngoing() {
+		return now.Sub(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+// IncidentTracker derives incidents from the stream of check results of
+// all target keys. It is not safe for concurrent use.
+type IncidentTracker struct {
+	incidents map[string][]Incident
+	downKeys  map[string]map[string]bool
+}
+
+func NewIncidentTracker() *IncidentTracker {
+	return &IncidentTracker{
+		incidents: make(map[string][]Incident),
+		downKeys:  make(map[string]map[string]bool),
+	}
+}
+
+// Record feeds one check outcome for key into the tracker. Incidents are
+// kept per key.TargetName. It returns the affected incident when the check
+// opened or resolved one, and nil when the target's incident state did not
+// change.
+func (t *IncidentTracker) Record(key TargetKey, isUp bool, errorMsg string, at time.Time) *Incident {
+	target := key.TargetName
+	down := t.downKeys[target]
+	incidents := t.incidents[target]
+
+	var current *Incident
+	if n := len(incidents); n > 0 && incidents[n-1].IsOngoing() {
+		current = &incidents[n-1]
+	}
+
+	if isUp {
+		if !down[key.String()] {
+			return nil
+		}
+		delete(down, key.String())
+		if len(down) > 0 || current == nil {
+			return nil
+		}
+		current.End = at
+		resolved := current.clone()
+		return &resolved
+	}
+
+	if down == nil {
+		down = make(map[string]bool)
+		t.downKeys[target] = down
+	}
+	down[key.String()] = true
+
+	opened := current == nil
+	if opened {
+		t.incidents[target] = append(incidents, Incident{
+			Target:     key.GetCleanName(),
+			Start:      at,
+			FirstError: errorMsg,
+		})
+		current = &t.incidents[target][len(t.incidents[target])-1]
+	}
+
+	if !key.IsLocal && !slices.Contains(current.Regions, key.Region) {
+		current.Regions = append(current.Regions, key.Region)
+	}
+
+	if !opened {
+		return nil
+	}
+	started := current.clone()
+	return &started
+}
+
+// Incidents returns the incidents of one target, oldest first.
+func (t *IncidentTracker) Incidents(targetName string) []Incident {
+	incidents := t.incidents[targetName]
+	result := make([]Incident, len(incidents))
+	for i, incident := range incidents {
+		result[i] = incident.clone()
+	}
+	return result
+}
+
+// All returns the incidents of every target ordered by start time.
+func (t *IncidentTracker) All() []Incident {
+	var result []Incident
+	for target := range t.incidents {
+		result = append(result, t.Incidents(target)...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+func (i Incident) clone() Incident {
+	i.Regions = slices.Clone(i.Regions)
+	return i
+}
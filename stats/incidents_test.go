@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIncidentTracker_LocalTarget(t *testing.T) {
+	tracker := NewIncidentTracker()
+	key := NewLocalTargetKey("api#0", 0)
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if incident := tracker.Record(key, true, "", start); incident != nil {
+		t.Fatal("Successful check should not open an incident")
+	}
+
+	opened := tracker.Record(key, false, "Status code: 503", start.Add(time.Minute))
+	if opened == nil || !opened.IsOngoing() {
+		t.Fatalf("Expected an ongoing incident, got %+v", opened)
+	}
+	if opened.FirstError != "Status code: 503" {
+		t.Errorf("FirstError = %q, want the first failure", opened.FirstError)
+	}
+
+	if incident := tracker.Record(key, false, "Request failed", start.Add(2*time.Minute)); incident != nil {
+		t.Error("Repeated failures should not open another incident")
+	}
+
+	resolved := tracker.Record(key, true, "", start.Add(4*time.Minute))
+	if resolved == nil || resolved.IsOngoing() {
+		t.Fatalf("Expected a resolved incident, got %+v", resolved)
+	}
+	if got := resolved.Duration(time.Now()); got != 3*time.Minute {
+		t.Errorf("Duration = %v, want 3m", got)
+	}
+	if len(resolved.Regions) != 0 {
+		t.Errorf("Local incident should have no regions, got %v", resolved.Regions)
+	}
+
+	incidents := tracker.Incidents("api#0")
+	if len(incidents) != 1 || incidents[0].FirstError != "Status code: 503" {
+		t.Errorf("Incidents() = %+v, want the single recorded incident", incidents)
+	}
+}
+
+func TestIncidentTracker_MultiRegion(t *testing.T) {
+	tracker := NewIncidentTracker()
+	east := NewRegionTargetKey("api#0", "us-east-1", 0)
+	west := NewRegionTargetKey("api#0", "eu-west-1", 0)
+	start := time.Now()
+
+	if tracker.Record(east, false, "Status code: 500", start) == nil {
+		t.Fatal("First regional failure should open an incident")
+	}
+	if tracker.Record(west, false, "Request failed", start.Add(time.Second)) != nil {
+		t.Error("Second region failing should join the open incident")
+	}
+	if tracker.Record(east, true, "", start.Add(2*time.Second)) != nil {
+		t.Error("Incident should stay open while a region is still down")
+	}
+
+	resolved := tracker.Record(west, true, "", start.Add(3*time.Second))
+	if resolved == nil {
+		t.Fatal("Incident should resolve once every region recovered")
+	}
+	if len(resolved.Regions) != 2 || resolved.Regions[0] != "us-east-1" || resolved.Regions[1] != "eu-west-1" {
+		t.Errorf("Regions = %v, want [us-east-1 eu-west-1]", resolved.Regions)
+	}
+	if resolved.FirstError != "Status code: 500" {
+		t.Errorf("FirstError = %q, want the first region's error", resolved.FirstError)
+	}
+}
+
+func TestIncidentTracker_All(t *testing.T) {
+	tracker := NewIncidentTracker()
+	start := time.Now()
+
+	tracker.Record(NewLocalTargetKey("web#1", 1), false, "", start.Add(time.Minute))
+	tracker.Record(NewLocalTargetKey("api#0", 0), false, "", start)
+
+	all := tracker.All()
+	if len(all) != 2 {
+		t.Fatalf("All() returned %d incidents, want 2", len(all))
+	}
+	if all[0].Target != "api" || all[1].Target != "web" {
+		t.Errorf("All() should be ordered by start, got %s then %s", all[0].Target, all[1].Target)
+	}
+
+	all[0].Regions = append(all[0].Regions, "mutated")
+	if len(tracker.Incidents("api#0")[0].Regions) != 0 {
+		t.Error("Returned incidents should not alias tracker state")
+	}
+}
+
+func TestIncidentTracker_TargetName(t *testing.T) {
+	tracker := NewIncidentTracker()
+
+	opened := tracker.Record(NewRegionTargetKey("Cache#Store#3", "us-east-1", 3), false, "timeout", time.Now())
+	if opened == nil || opened.Target != "Cache#Store" {
+		t.Fatalf("Record() = %+v, want an incident for the configured name %q", opened, "Cache#Store")
+	}
+	if len(tracker.Incidents("Cache#Store#3")) != 1 {
+		t.Error("Incidents should stay keyed by the target key's name")
+	}
+}
@@ -0,0 +1,303 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/caio/go-tdigest/v4"
+)
+
+const _snapshotVersion = 1
+
+// MonitorState is the serializable form of a Monitor.
+type MonitorState struct {
+	ChecksCount       int           `json:"checks_count"`
+	SuccessCount      int           `json:"success_count"`
+	TotalResponseTime time.Duration `json:"total_response_time"`
+	MinResponseTime   time.Duration `json:"min_response_time"`
+	MaxResponseTime   time.Duration `json:"max_response_time"`
+	StartTime         time.Time     `json:"start_time"`
+	LastCheckTime     time.Time     `json:"last_check_time"`
+	LastIP            string        `json:"last_ip,omitempty"`
+	LastStatusCode    int           `json:"last_status_code"`
+	TotalUptime       time.Duration `json:"total_uptime"`
+	IsUp              bool          `json:"is_up"`
+	ApdexSatisfied    int           `json:"apdex_satisfied"`
+	ApdexTolerating   int           `json:"apdex_tolerating"`
+	TDigest           []byte        `json:"tdigest,omitempty"`
+	Mean              float64       `json:"mean"`
+	M2                float64       `json:"m2"`
+	BaselineMean      float64       `json:"baseline_mean"`
+	BaselineVariance  float64       `json:"baseline_variance"`
+	BaselineSamples   int           `json:"baseline_samples"`
+	// BaselineSlots holds the warmed hour-of-week baselines, keyed by
+	// weekday*24+hour.
+	BaselineSlots map[int]BaselineState `json:"baseline_slots,omitempty"`
+}
+
+// BaselineState is the serializable form of one hour-of-week baseline.
+type BaselineState struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Samples  int     `json:"samples"`
+}
+
+// Snapshot captures monitor statistics and alert states keyed by
+// TargetKey.String() so a later process can pick up where this one stopped.
+type Snapshot struct {
+	Version            int                     `json:"version"`
+	SavedAt            time.Time               `json:"saved_at"`
+	Monitors           map[string]MonitorState `json:"monitors"`
+	AlertStates        map[string]bool         `json:"alert_states,omitempty"`
+	WebhookAlertStates map[string]bool         `json:"webhook_alert_states,omitempty"`
+}
+
+func (m *Monitor) State() (MonitorState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := MonitorState{
+		ChecksCount:       m.ChecksCount,
+		SuccessCount:      m.SuccessCount,
+		TotalResponseTime: m.TotalResponseTime,
+		MinResponseTime:   m.MinResponseTime,
+		MaxResponseTime:   m.MaxResponseTime,
+		StartTime:         m.StartTime,
+		LastCheckTime:     m.LastCheckTime,
+		LastIP:            m.LastIP,
+		LastStatusCode:    m.LastStatusCode,
+		TotalUptime:       m.TotalUptime,
+		IsUp:              m.IsUp,
+		ApdexSatisfied:    m.ApdexSatisfied,
+		ApdexTolerating:   m.ApdexTolerating,
+		Mean:              m.mean,
+		M2:                m.m2,
+		BaselineMean:      m.baseline.global.mean,
+		BaselineVariance:  m.baseline.global.variance,
+		BaselineSamples:   m.baseline.global.samples,
+	}
+
+	for slot, e := range m.baseline.seasonal {
+		if e.samples == 0 {
+			continue
+		}
+		if state.BaselineSlots == nil {
+			state.BaselineSlots = make(map[int]BaselineState)
+		}
+		state.BaselineSlots[slot] = BaselineState{Mean: e.mean, Variance: e.variance, Samples: e.samples}
+	}
+
+	if m.TDigest != nil {
+		digest, err := m.TDigest.AsBytes()
+		if err != nil {
+			return MonitorState{}, fmt.Errorf("failed to encode t-digest: %w", err)
+		}
+		state.TDigest = digest
+	}
+
+	return state, nil
+}
+
+// Restore replaces the monitor's statistics with state. The start and last
+// check times are moved forward by offline, the time no process was
+// monitoring, so that gap counts neither as uptime nor as downtime.
+func (m *Monitor) Restore(state MonitorState, offline time.Duration) error {
+	td, err := tdigest.New(tdigest.Compression(_defaultCompression))
+	if err != nil {
+		return err
+	}
+	if len(state.TDigest) > 0 {
+		if err := td.FromBytes(state.TDigest); err != nil {
+			return fmt.Errorf("failed to decode t-digest: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ChecksCount = state.ChecksCount
+	m.SuccessCount = state.SuccessCount
+	m.TotalResponseTime = state.TotalResponseTime
+	m.MinResponseTime = state.MinResponseTime
+	m.MaxResponseTime = state.MaxResponseTime
+	m.StartTime = state.StartTime.Add(offline)
+	m.LastCheckTime = state.LastCheckTime.Add(offline)
+	m.LastIP = state.LastIP
+	m.LastStatusCode = state.LastStatusCode
+	m.TotalUptime = state.TotalUptime
+	m.IsUp = state.IsUp
+	m.ApdexSatisfied = state.ApdexSatisfied
+	m.ApdexTolerating = state.ApdexTolerating
+	m.TDigest = td
+	m.mean = state.Mean
+	m.m2 = state.M2
+	m.uptimeSpan = 0
+	m.baseline = Baseline{
+		global: ewma{
+			mean:     state.BaselineMean,
+			variance: state.BaselineVariance,
+			samples:  state.BaselineSamples,
+		},
+	}
+	for slot, e := range state.BaselineSlots {
+		if slot < 0 || slot >= _baselineSlots {
+			continue
+		}
+		m.baseline.seasonal[slot] = ewma{mean: e.Mean, variance: e.Variance, samples: e.Samples}
+	}
+
+	return nil
+}
+
+func NewSnapshot(monitors map[string]*Monitor, alertStates, webhookAlertStates map[string]*bool) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:            _snapshotVersion,
+		SavedAt:            time.Now(),
+		Monitors:           make(map[string]MonitorState, len(monitors)),
+		AlertStates:        derefStates(alertStates),
+		WebhookAlertStates: derefStates(webhookAlertStates),
+	}
+
+	for key, monitor := range monitors {
+		state, err := monitor.State()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		snapshot.Monitors[key] = state
+	}
+
+	return snapshot, nil
+}
+
+// Apply restores the snapshot into the given monitors and alert states.
+// Keys that are not present in the current run are ignored.
+func (s *Snapshot) Apply(monitors map[string]*Monitor, alertStates, webhookAlertStates map[string]*bool) error {
+	offline := max(time.Since(s.SavedAt), 0)
+
+	for key, state := range s.Monitors {
+		if monitor, exists := monitors[key]; exists {
+			if err := monitor.Restore(state, offline); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	applyStates(alertStates, s.AlertStates)
+	applyStates(webhookAlertStates, s.WebhookAlertStates)
+
+	return nil
+}
+
+// SaveSnapshot writes the snapshot atomically. Paths ending in .gob use
+// encoding/gob, anything else is written as JSON.
+func SaveSnapshot(path string, snapshot *Snapshot) error {
+	var buf bytes.Buffer
+	if isGobPath(path) {
+		if err := gob.NewEncoder(&buf).Encode(snapshot); err != nil {
+			return fmt.Errorf("failed to encode snapshot: %w", err)
+		}
+	} else {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(snapshot); err != nil {
+			return fmt.Errorf("failed to encode snapshot: %w", err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot. A missing file is
+// not an error: it returns a nil snapshot so a first run starts fresh.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if isGobPath(path) {
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot)
+	} else {
+		err = json.Unmarshal(data, &snapshot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	if snapshot.Version != _snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	return &snapshot, nil
+}
+
+func isGobPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gob")
+}
+
+func derefStates(states map[string]*bool) map[string]bool {
+	result := make(map[string]bool, len(states))
+	for key, state := range states {
+		if state != nil {
+			result[key] = *state
+		}
+	}
+	return result
+}
+
+func applyStates(states map[string]*bool, saved map[string]bool) {
+	for key, value := range saved {
+		if state, exists := states[key]; exists && state != nil {
+			*state = value
+		}
+	}
+}
+
+// SaveState snapshots monitors and alert states to path.
+func SaveState(path string, monitors map[string]*Monitor, alertStates, webhookAlertStates map[string]*bool) error {
+	snapshot, err := NewSnapshot(monitors, alertStates, webhookAlertStates)
+	if err != nil {
+		return err
+	}
+	return SaveSnapshot(path, snapshot)
+}
+
+// LoadState restores monitors and alert states from the snapshot at path,
+// reporting whether a snapshot was found.
+func LoadState(path string, monitors map[string]*Monitor, alertStates, webhookAlertStates map[string]*bool) (bool, error) {
+	snapshot, err := LoadSnapshot(path)
+	if err != nil || snapshot == nil {
+		return false, err
+	}
+	if err := snapshot.Apply(monitors, alertStates, webhookAlertStates); err != nil {
+		return false, err
+	}
+	return true, nil
+}
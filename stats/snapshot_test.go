@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/net"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "json", file: "state.json"},
+		{name: "gob", file: "state.gob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := NewLocalTargetKey("api", 0).String()
+
+			original, err := NewMonitor()
+			if err != nil {
+				t.Fatalf("NewMonitor failed: %v", err)
+			}
+			for i := range 20 {
+				result := net.WebsiteCheckResult{
+					IsUp:         i%5 != 0,
+					StatusCode:   200,
+					ResponseTime: time.Duration(100+i*10) * time.Millisecond,
+				}
+				original.AddResult(result)
+				original.DetectAnomaly(result)
+			}
+			alert, webhookAlert := true, false
+			path := filepath.Join(t.TempDir(), tt.file)
+
+			err = SaveState(path, map[string]*Monitor{key: original},
+				map[string]*bool{key: &alert}, map[string]*bool{key: &webhookAlert})
+			if err != nil {
+				t.Fatalf("SaveState failed: %v", err)
+			}
+
+			restored, err := NewMonitor()
+			if err != nil {
+				t.Fatalf("NewMonitor failed: %v", err)
+			}
+			restoredAlert, restoredWebhookAlert := false, true
+			found, err := LoadState(path, map[string]*Monitor{key: restored},
+				map[string]*bool{key: &restoredAlert}, map[string]*bool{key: &restoredWebhookAlert})
+			if err != nil {
+				t.Fatalf("LoadState failed: %v", err)
+			}
+			if !found {
+				t.Fatal("Expected a snapshot to be found")
+			}
+
+			want, got := original.GetStats(), restored.GetStats()
+			if got.ChecksCount != want.ChecksCount || got.SuccessCount != want.SuccessCount {
+				t.Errorf("Counts = %d/%d, want %d/%d", got.SuccessCount, got.ChecksCount, want.SuccessCount, want.ChecksCount)
+			}
+			if got.AvgResponseTime != want.AvgResponseTime {
+				t.Errorf("AvgResponseTime = %v, want %v", got.AvgResponseTime, want.AvgResponseTime)
+			}
+			if math.Abs(got.StdDev-want.StdDev) > 1e-9 {
+				t.Errorf("StdDev = %v, want %v", got.StdDev, want.StdDev)
+			}
+			if diff := got.P95 - want.P95; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("P95 = %v, want %v", got.P95, want.P95)
+			}
+			if restored.TotalUptime != original.TotalUptime || restored.IsUp != original.IsUp {
+				t.Errorf("Uptime = %v (up %v), want %v (up %v)", restored.TotalUptime, restored.IsUp, original.TotalUptime, original.IsUp)
+			}
+			if restored.baseline != original.baseline {
+				t.Error("Anomaly baseline was not restored")
+			}
+			if !restoredAlert || restoredWebhookAlert {
+				t.Errorf("Alert states = %v/%v, want true/false", restoredAlert, restoredWebhookAlert)
+			}
+		})
+	}
+}
+
+func TestLoadSnapshot_Missing(t *testing.T) {
+	snapshot, err := LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Missing snapshot should not be an error, got %v", err)
+	}
+	if snapshot != nil {
+		t.Errorf("Expected nil snapshot, got %+v", snapshot)
+	}
+}
+
+func TestLoadSnapshot_UnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadSnapshot(path); err == nil {
+		t.Error("Expected an error for an unsupported snapshot version")
+	}
+}
+
+func TestSnapshot_ApplyShiftsOfflineTime(t *testing.T) {
+	key := NewLocalTargetKey("api", 0).String()
+	start := time.Now().Add(-2 * time.Hour)
+	snapshot := &Snapshot{
+		Version: _snapshotVersion,
+		SavedAt: time.Now().Add(-time.Hour),
+		Monitors: map[string]MonitorState{
+			key: {ChecksCount: 1, SuccessCount: 1, StartTime: start, TotalUptime: time.Hour, IsUp: true},
+		},
+	}
+
+	monitor, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	if err := snapshot.Apply(map[string]*Monitor{key: monitor}, nil, nil); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if shift := monitor.StartTime.Sub(start); shift < time.Hour {
+		t.Errorf("StartTime shifted by %v, want at least the 1h offline gap", shift)
+	}
+}
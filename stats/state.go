@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"fmt"
+
+	"github.com/Owloops/updo/config"
+)
+
+// TargetState holds what is tracked per target key while monitoring. The
+// maps are never modified once built, so monitoring goroutines can read
+// them while a reload builds the next TargetState.
+type TargetState struct {
+	Monitors           map[string]*Monitor
+	Sequences          map[string]*int
+	AlertStates        map[string]*bool
+	WebhookAlertStates map[string]*bool
+
+	registry *TargetKeyRegistry
+}
+
+// NewTargetState creates the state of every key in registry. Keys of
+// targets also present in previous, which may be nil, keep their monitor,
+// sequence and alert states, so a reloaded config carries on the
+// statistics of its targets. Targets are matched by ID, see TargetIDs, so
+// their state follows them when they move in the list.
+func NewTargetState(registry *TargetKeyRegistry, targets []config.Target, previous *TargetState) (*TargetState, error) {
+	allKeys := registry.GetAllKeys()
+	state := &TargetState{
+		Monitors:           make(map[string]*Monitor, len(allKeys)),
+		Sequences:          make(map[string]*int, len(allKeys)),
+		AlertStates:        make(map[string]*bool, len(allKeys)),
+		WebhookAlertStates: make(map[string]*bool, len(allKeys)),
+		registry:           registry,
+	}
+
+	for _, key := range allKeys {
+		keyStr := key.String()
+		apdexThreshold := targets[key.TargetIndex].GetApdexThreshold()
+
+		if previous != nil {
+			if previousKey, exists := previous.registry.Lookup(registry.GetTargetID(key.TargetIndex), key.Region); exists {
+				previousStr := previousKey.String()
+				monitor := previous.Monitors[previousStr]
+				monitor.SetApdexThreshold(apdexThreshold)
+				state.Monitors[keyStr] = monitor
+				state.Sequences[keyStr] = previous.Sequences[previousStr]
+				state.AlertStates[keyStr] = previous.AlertStates[previousStr]
+				state.WebhookAlertStates[keyStr] = previous.WebhookAlertStates[previousStr]
+				continue
+			}
+		}
+
+		monitor, err := NewMonitor()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyStr, err)
+		}
+		monitor.ApdexThreshold = apdexThreshold
+		state.Monitors[keyStr] = monitor
+		state.Sequences[keyStr] = new(int)
+		state.AlertStates[keyStr] = new(bool)
+		state.WebhookAlertStates[keyStr] = new(bool)
+	}
+
+	return state, nil
+}
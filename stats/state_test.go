@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/config"
+	"github.com/Owloops/updo/net"
+)
+
+func TestNewTargetState_KeepsUnchangedKeys(t *testing.T) {
+	targets := []config.Target{{Name: "api", URL: "https://api.example.com"}, {Name: "web", URL: "https://web.example.com"}}
+	previous, err := NewTargetState(NewTargetKeyRegistry(targets, nil), targets, nil)
+	if err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+	*previous.Sequences["api#0"] = 7
+	*previous.AlertStates["api#0"] = true
+
+	reloaded := []config.Target{{Name: "api", URL: "https://api.example.com", ApdexThreshold: 0.5}, {Name: "docs", URL: "https://docs.example.com"}}
+	state, err := NewTargetState(NewTargetKeyRegistry(reloaded, nil), reloaded, previous)
+	if err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+
+	if state.Monitors["api#0"] != previous.Monitors["api#0"] || *state.Sequences["api#0"] != 7 || !*state.AlertStates["api#0"] {
+		t.Error("State of the unchanged key should be kept")
+	}
+	if got := state.Monitors["api#0"].ApdexThreshold; got != 500*time.Millisecond {
+		t.Errorf("Apdex threshold should follow the reloaded target, got %v", got)
+	}
+	if _, exists := state.Monitors["web#1"]; exists {
+		t.Error("Removed key should be dropped")
+	}
+	if monitor, exists := state.Monitors["docs#1"]; !exists || monitor == previous.Monitors["web#1"] {
+		t.Error("New key should get a fresh monitor")
+	}
+	if _, exists := previous.Monitors["docs#1"]; exists {
+		t.Error("Previous state must not be modified")
+	}
+}
+
+func TestNewTargetState_ReusedMonitorWhileChecking(t *testing.T) {
+	targets := []config.Target{{Name: "api", URL: "https://api.example.com"}}
+	previous, err := NewTargetState(NewTargetKeyRegistry(targets, nil), targets, nil)
+	if err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+	monitor := previous.Monitors["api#0"]
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			monitor.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 100 * time.Millisecond})
+		}
+	}()
+
+	reloaded := []config.Target{{Name: "api", URL: "https://api.example.com", ApdexThreshold: 0.5}}
+	if _, err := NewTargetState(NewTargetKeyRegistry(reloaded, nil), reloaded, previous); err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+	<-done
+
+	if got := monitor.GetStats().ApdexThreshold; got != 500*time.Millisecond {
+		t.Errorf("ApdexThreshold = %v, want 500ms", got)
+	}
+}
+
+func TestNewTargetState_FollowsMovedTargets(t *testing.T) {
+	targets := []config.Target{{Name: "api", URL: "https://api.example.com"}, {Name: "web", URL: "https://web.example.com"}}
+	previous, err := NewTargetState(NewTargetKeyRegistry(targets, nil), targets, nil)
+	if err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+	*previous.Sequences["web#1"] = 3
+
+	reloaded := []config.Target{targets[1], targets[0]}
+	state, err := NewTargetState(NewTargetKeyRegistry(reloaded, nil), reloaded, previous)
+	if err != nil {
+		t.Fatalf("NewTargetState failed: %v", err)
+	}
+
+	if state.Monitors["web#0"] != previous.Monitors["web#1"] || *state.Sequences["web#0"] != 3 {
+		t.Error("State should follow a target moved up the list")
+	}
+	if state.Monitors["api#1"] != previous.Monitors["api#0"] {
+		t.Error("State should follow a target moved down the list")
+	}
+}
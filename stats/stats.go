@@ -2,6 +2,7 @@ package stats
 
 import (
 	"math"
+	"sync"
 	"time"
 
 	"github.com/Owloops/updo/net"
@@ -11,9 +12,17 @@ import (
 const (
 	_defaultCompression = 100
 	_p95Quantile        = 0.95
+
+	_apdexToleratingFactor = 4
 )
 
+// Monitor accumulates the check results of one target key. Its methods
+// may be called from several goroutines, such as a target's check loop and
+// a reload or the final statistics; the exported fields must only be read
+// directly on monitors no other goroutine is updating.
 type Monitor struct {
+	mu sync.Mutex
+
 	ChecksCount       int
 	SuccessCount      int
 	TotalResponseTime time.Duration
@@ -26,10 +35,24 @@ type Monitor struct {
 	TotalUptime       time.Duration
 	IsUp              bool
 
+	// ApdexThreshold is the Apdex target time T. Responses up to T count as
+	// satisfied, up to 4T as tolerating; failed checks are always frustrated.
+	// Zero disables Apdex scoring.
+	ApdexThreshold  time.Duration
+	ApdexSatisfied  int
+	ApdexTolerating int
+
 	TDigest *tdigest.TDigest
 
 	mean float64
 	m2   float64
+
+	// uptimeSpan replaces the wall-clock time since StartTime as the uptime
+	// denominator for monitors built by Merge, which cover several
+	// independently observed spans.
+	uptimeSpan time.Duration
+
+	baseline Baseline
 }
 
 func NewMonitor() (*Monitor, error) {
@@ -46,6 +69,9 @@ func NewMonitor() (*Monitor, error) {
 }
 
 func (m *Monitor) AddResult(result net.WebsiteCheckResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.ChecksCount++
 	m.LastIP = result.ResolvedIP
 	m.LastStatusCode = result.StatusCode
@@ -69,6 +95,14 @@ func (m *Monitor) AddResult(result net.WebsiteCheckResult) {
 
 	if result.IsUp {
 		m.SuccessCount++
+		if m.ApdexThreshold > 0 {
+			switch {
+			case result.ResponseTime <= m.ApdexThreshold:
+				m.ApdexSatisfied++
+			case result.ResponseTime <= _apdexToleratingFactor*m.ApdexThreshold:
+				m.ApdexTolerating++
+			}
+		}
 	}
 
 	m.TotalResponseTime += result.ResponseTime
@@ -103,16 +137,178 @@ type Stats struct {
 	TotalDuration   time.Duration
 	LastIP          string
 	LastStatusCode  int
+	ApdexScore      float64
+	ApdexThreshold  time.Duration
 }
 
-func (m *Monitor) GetStats() Stats {
-	now := time.Now()
+// HasApdex reports whether ApdexScore carries a meaningful value.
+func (s Stats) HasApdex() bool {
+	return s.ApdexThreshold > 0 && s.ChecksCount > 0
+}
+
+func (m *Monitor) uptimeAt(now time.Time) (uptime, span time.Duration) {
+	if m.uptimeSpan > 0 {
+		return m.TotalUptime, m.uptimeSpan
+	}
 
-	currentUptime := m.TotalUptime
+	uptime = m.TotalUptime
 	if m.ChecksCount > 0 && m.IsUp {
-		currentUptime += now.Sub(m.LastCheckTime)
+		uptime += now.Sub(m.LastCheckTime)
+	}
+	return uptime, now.Sub(m.StartTime)
+}
+
+// Merge folds other into m so that m describes both check streams: counts
+// and extremes are combined, the t-digests are merged, variance is pooled
+// and uptime is weighted by the time each monitor has been observing.
+// The result is a snapshot; keep adding results to the source monitors
+// and merge again rather than calling AddResult on a merged monitor.
+func (m *Monitor) Merge(other *Monitor) error {
+	if other == nil || other == m {
+		return nil
+	}
+
+	// Never hold both locks, so that merges in opposite directions cannot
+	// deadlock.
+	other = other.snapshot()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if other.ChecksCount == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	otherUptime, otherSpan := other.uptimeAt(now)
+
+	if m.ChecksCount == 0 {
+		m.StartTime = other.StartTime
+		m.TotalUptime = otherUptime
+		m.uptimeSpan = otherSpan
+	} else {
+		uptime, span := m.uptimeAt(now)
+		m.TotalUptime = uptime + otherUptime
+		m.uptimeSpan = span + otherSpan
+		if other.StartTime.Before(m.StartTime) {
+			m.StartTime = other.StartTime
+		}
+	}
+
+	if m.TDigest != nil && other.TDigest != nil {
+		if err := m.TDigest.Merge(other.TDigest); err != nil {
+			return err
+		}
+	}
+
+	n1 := float64(m.ChecksCount)
+	n2 := float64(other.ChecksCount)
+	n := n1 + n2
+	delta := other.mean - m.mean
+	m.mean += delta * n2 / n
+	m.m2 += other.m2 + delta*delta*n1*n2/n
+
+	if other.LastCheckTime.After(m.LastCheckTime) {
+		m.LastCheckTime = other.LastCheckTime
+		m.LastIP = other.LastIP
+		m.LastStatusCode = other.LastStatusCode
+		m.IsUp = other.IsUp
+	}
+
+	if m.ApdexThreshold == 0 {
+		m.ApdexThreshold = other.ApdexThreshold
+	}
+	m.ApdexSatisfied += other.ApdexSatisfied
+	m.ApdexTolerating += other.ApdexTolerating
+
+	m.ChecksCount += other.ChecksCount
+	m.SuccessCount += other.SuccessCount
+	m.TotalResponseTime += other.TotalResponseTime
+	if other.MinResponseTime < m.MinResponseTime {
+		m.MinResponseTime = other.MinResponseTime
+	}
+	if other.MaxResponseTime > m.MaxResponseTime {
+		m.MaxResponseTime = other.MaxResponseTime
+	}
+
+	return nil
+}
+
+// snapshot returns a copy of what Merge reads from m, taken under m's lock.
+func (m *Monitor) snapshot() *Monitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := &Monitor{
+		ChecksCount:       m.ChecksCount,
+		SuccessCount:      m.SuccessCount,
+		TotalResponseTime: m.TotalResponseTime,
+		MinResponseTime:   m.MinResponseTime,
+		MaxResponseTime:   m.MaxResponseTime,
+		StartTime:         m.StartTime,
+		LastCheckTime:     m.LastCheckTime,
+		LastIP:            m.LastIP,
+		LastStatusCode:    m.LastStatusCode,
+		TotalUptime:       m.TotalUptime,
+		IsUp:              m.IsUp,
+		ApdexThreshold:    m.ApdexThreshold,
+		ApdexSatisfied:    m.ApdexSatisfied,
+		ApdexTolerating:   m.ApdexTolerating,
+		mean:              m.mean,
+		m2:                m.m2,
+		uptimeSpan:        m.uptimeSpan,
+	}
+	if m.TDigest != nil {
+		snapshot.TDigest = m.TDigest.Clone()
+	}
+	return snapshot
+}
+
+// MergeMonitors returns a new Monitor aggregating all given monitors.
+// The inputs are left untouched.
+func MergeMonitors(monitors ...*Monitor) (*Monitor, error) {
+	merged, err := NewMonitor()
+	if err != nil {
+		return nil, err
 	}
 
+	for _, monitor := range monitors {
+		if err := merged.Merge(monitor); err != nil {
+			return nil, err
+		}
+	}
+
+	return merged, nil
+}
+
+// MergeKeys aggregates the monitors registered under keys, typically the
+// per-region keys of one target. Keys without a monitor are skipped.
+func MergeKeys(monitors map[string]*Monitor, keys []TargetKey) (*Monitor, error) {
+	selected := make([]*Monitor, 0, len(keys))
+	for _, key := range keys {
+		if monitor, exists := monitors[key.String()]; exists {
+			selected = append(selected, monitor)
+		}
+	}
+	return MergeMonitors(selected...)
+}
+
+// SetApdexThreshold changes the Apdex target time T used for subsequent
+// results.
+func (m *Monitor) SetApdexThreshold(threshold time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ApdexThreshold = threshold
+}
+
+func (m *Monitor) GetStats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	currentUptime, totalMonitoredTime := m.uptimeAt(now)
+
 	stats := Stats{
 		ChecksCount:     m.ChecksCount,
 		SuccessCount:    m.SuccessCount,
@@ -121,15 +317,16 @@ func (m *Monitor) GetStats() Stats {
 		TotalDuration:   time.Since(m.StartTime),
 		LastIP:          m.LastIP,
 		LastStatusCode:  m.LastStatusCode,
+		ApdexThreshold:  m.ApdexThreshold,
 	}
 
-	totalMonitoredTime := time.Since(m.StartTime)
 	if totalMonitoredTime > 0 {
 		stats.UptimePercent = (float64(currentUptime) / float64(totalMonitoredTime)) * 100
 	}
 
 	if m.ChecksCount > 0 {
 		stats.AvgResponseTime = m.TotalResponseTime / time.Duration(m.ChecksCount)
+		stats.ApdexScore = (float64(m.ApdexSatisfied) + float64(m.ApdexTolerating)/2) / float64(m.ChecksCount)
 	}
 
 	if m.ChecksCount > 1 {
@@ -301,3 +301,235 @@ func TestMonitor_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestMonitor_Merge(t *testing.T) {
+	first := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	second := []time.Duration{50 * time.Millisecond, 400 * time.Millisecond}
+
+	a, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	b, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	combined, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+
+	for _, rt := range first {
+		a.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: rt})
+		combined.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: rt})
+	}
+	for _, rt := range second {
+		b.AddResult(net.WebsiteCheckResult{IsUp: false, ResponseTime: rt})
+		combined.AddResult(net.WebsiteCheckResult{IsUp: false, ResponseTime: rt})
+	}
+
+	merged, err := MergeMonitors(a, b)
+	if err != nil {
+		t.Fatalf("MergeMonitors failed: %v", err)
+	}
+
+	got := merged.GetStats()
+	want := combined.GetStats()
+
+	if got.ChecksCount != 5 || got.SuccessCount != 3 {
+		t.Errorf("Expected 5 checks/3 successes, got %d/%d", got.ChecksCount, got.SuccessCount)
+	}
+	if got.MinResponseTime != 50*time.Millisecond {
+		t.Errorf("Expected min=50ms, got %v", got.MinResponseTime)
+	}
+	if got.MaxResponseTime != 400*time.Millisecond {
+		t.Errorf("Expected max=400ms, got %v", got.MaxResponseTime)
+	}
+	if got.AvgResponseTime != want.AvgResponseTime {
+		t.Errorf("Expected avg=%v, got %v", want.AvgResponseTime, got.AvgResponseTime)
+	}
+	if math.Abs(got.StdDev-want.StdDev) > 1e-9 {
+		t.Errorf("Expected pooled stddev=%f, got %f", want.StdDev, got.StdDev)
+	}
+	if got.P95 == 0 {
+		t.Error("Expected P95 from merged t-digest")
+	}
+	if a.ChecksCount != 3 || b.ChecksCount != 2 {
+		t.Error("MergeMonitors should not modify its inputs")
+	}
+}
+
+func TestMonitor_MergeUptimeIsTimeWeighted(t *testing.T) {
+	now := time.Now()
+
+	up := &Monitor{
+		ChecksCount:   1,
+		SuccessCount:  1,
+		StartTime:     now.Add(-30 * time.Second),
+		LastCheckTime: now.Add(-30 * time.Second),
+		TotalUptime:   0,
+		IsUp:          true,
+	}
+	down := &Monitor{
+		ChecksCount:   1,
+		StartTime:     now.Add(-10 * time.Second),
+		LastCheckTime: now.Add(-10 * time.Second),
+		IsUp:          false,
+	}
+
+	merged, err := MergeMonitors(up, down)
+	if err != nil {
+		t.Fatalf("MergeMonitors failed: %v", err)
+	}
+
+	// 30s up out of 40s observed, not the 50% success ratio.
+	uptime := merged.GetStats().UptimePercent
+	if math.Abs(uptime-75) > 0.5 {
+		t.Errorf("Expected time-weighted uptime ~75%%, got %f", uptime)
+	}
+}
+
+func TestMonitor_MergeSkipsEmpty(t *testing.T) {
+	monitor, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	monitor.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 100 * time.Millisecond})
+
+	empty, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+
+	if err := monitor.Merge(empty); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if err := monitor.Merge(nil); err != nil {
+		t.Fatalf("Merge(nil) failed: %v", err)
+	}
+
+	if monitor.ChecksCount != 1 {
+		t.Errorf("Expected ChecksCount=1, got %d", monitor.ChecksCount)
+	}
+}
+
+func TestMonitor_MergeConcurrently(t *testing.T) {
+	a, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	b, err := NewMonitor()
+	if err != nil {
+		t.Fatalf("NewMonitor failed: %v", err)
+	}
+	a.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 100 * time.Millisecond})
+	b.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 200 * time.Millisecond})
+
+	if err := a.Merge(a); err != nil {
+		t.Fatalf("Merge with itself failed: %v", err)
+	}
+	if a.ChecksCount != 1 {
+		t.Errorf("Merging a monitor with itself should leave it unchanged, got ChecksCount=%d", a.ChecksCount)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			_ = a.Merge(b)
+		}
+	}()
+	for range 20 {
+		_ = b.Merge(a)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Merges in opposite directions deadlocked")
+	}
+}
+
+func TestMonitor_Apdex(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		results   []net.WebsiteCheckResult
+		want      float64
+		hasApdex  bool
+	}{
+		{
+			name:      "disabled",
+			threshold: 0,
+			results:   []net.WebsiteCheckResult{{IsUp: true, ResponseTime: 100 * time.Millisecond}},
+			hasApdex:  false,
+		},
+		{
+			name:      "all satisfied",
+			threshold: 500 * time.Millisecond,
+			results: []net.WebsiteCheckResult{
+				{IsUp: true, ResponseTime: 100 * time.Millisecond},
+				{IsUp: true, ResponseTime: 500 * time.Millisecond},
+			},
+			want:     1.0,
+			hasApdex: true,
+		},
+		{
+			name:      "satisfied, tolerating and frustrated",
+			threshold: 500 * time.Millisecond,
+			results: []net.WebsiteCheckResult{
+				{IsUp: true, ResponseTime: 100 * time.Millisecond},
+				{IsUp: true, ResponseTime: 1500 * time.Millisecond},
+				{IsUp: true, ResponseTime: 3 * time.Second},
+				{IsUp: false, ResponseTime: 50 * time.Millisecond},
+			},
+			want:     0.375,
+			hasApdex: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor, err := NewMonitor()
+			if err != nil {
+				t.Fatalf("NewMonitor failed: %v", err)
+			}
+			monitor.ApdexThreshold = tt.threshold
+
+			for _, result := range tt.results {
+				monitor.AddResult(result)
+			}
+
+			stats := monitor.GetStats()
+			if stats.HasApdex() != tt.hasApdex {
+				t.Fatalf("HasApdex() = %v, want %v", stats.HasApdex(), tt.hasApdex)
+			}
+			if tt.hasApdex && math.Abs(stats.ApdexScore-tt.want) > 1e-9 {
+				t.Errorf("ApdexScore = %f, want %f", stats.ApdexScore, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitor_MergeApdex(t *testing.T) {
+	a, _ := NewMonitor()
+	b, _ := NewMonitor()
+	a.ApdexThreshold = 500 * time.Millisecond
+	b.ApdexThreshold = 500 * time.Millisecond
+
+	a.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 100 * time.Millisecond})
+	b.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: time.Second})
+
+	merged, err := MergeMonitors(a, b)
+	if err != nil {
+		t.Fatalf("MergeMonitors failed: %v", err)
+	}
+
+	stats := merged.GetStats()
+	if stats.ApdexThreshold != 500*time.Millisecond {
+		t.Errorf("ApdexThreshold = %v, want 500ms", stats.ApdexThreshold)
+	}
+	if math.Abs(stats.ApdexScore-0.75) > 1e-9 {
+		t.Errorf("ApdexScore = %f, want 0.75", stats.ApdexScore)
+	}
+}
@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	_localRegion = "local"
+	_localRegion  = "local"
+	_globalRegion = "global"
 )
 
 type TargetKey struct {
@@ -33,6 +34,12 @@ func (tk TargetKey) DisplayName() string {
 	return fmt.Sprintf("%s (%s)", cleanName, tk.Region)
 }
 
+// IsGlobal reports whether the key names the cross-region aggregate of a
+// target rather than a single probe location.
+func (tk TargetKey) IsGlobal() bool {
+	return !tk.IsLocal && tk.Region == _globalRegion
+}
+
 func (tk TargetKey) GetCleanName() string {
 	if idx := strings.LastIndex(tk.TargetName, "#"); idx != -1 {
 		return tk.TargetName[:idx]
@@ -67,6 +74,17 @@ func NewRegionTargetKey(targetName, region string, targetIndex int) TargetKey {
 	}
 }
 
+// NewGlobalTargetKey returns the key under which the aggregate of all
+// regions of a target is reported.
+func NewGlobalTargetKey(targetName string, targetIndex int) TargetKey {
+	return TargetKey{
+		TargetName:  targetName,
+		Region:      _globalRegion,
+		IsLocal:     false,
+		TargetIndex: targetIndex,
+	}
+}
+
 func ParseTargetKey(keyStr string) TargetKey {
 	if strings.Contains(keyStr, "@") {
 		parts := strings.SplitN(keyStr, "@", 2)
@@ -75,6 +93,20 @@ func ParseTargetKey(keyStr string) TargetKey {
 	return NewLocalTargetKey(keyStr, -1)
 }
 
+// TargetIDs returns the identity under which each target is matched
+// across reloads: its name followed by the number of earlier targets with
+// the same name. Unlike a target's position, which its keys carry, its ID
+// does not change when other targets are added, removed or reordered.
+func TargetIDs(targets []config.Target) []string {
+	ids := make([]string, len(targets))
+	seen := make(map[string]int, len(targets))
+	for i, target := range targets {
+		ids[i] = fmt.Sprintf("%s#%d", target.Name, seen[target.Name])
+		seen[target.Name]++
+	}
+	return ids
+}
+
 func GetAllKeysForTarget(target config.Target, regions []string, index int) []TargetKey {
 	var keys []TargetKey
 
@@ -97,19 +129,29 @@ func GetAllKeysForTarget(target config.Target, regions []string, index int) []Ta
 }
 
 type TargetKeyRegistry struct {
-	allKeys    []TargetKey
-	keysByName map[string][]TargetKey
+	allKeys     []TargetKey
+	ids         []string
+	keysByName  map[string][]TargetKey
+	keysByIndex [][]TargetKey
+	keysByID    map[string]TargetKey
 }
 
 func NewTargetKeyRegistry(targets []config.Target, globalRegions []string) *TargetKeyRegistry {
 	registry := &TargetKeyRegistry{
-		keysByName: make(map[string][]TargetKey, len(targets)),
+		keysByName:  make(map[string][]TargetKey, len(targets)),
+		keysByIndex: make([][]TargetKey, len(targets)),
+		keysByID:    make(map[string]TargetKey, len(targets)),
+		ids:         TargetIDs(targets),
 	}
 
 	for i, target := range targets {
 		targetKeys := GetAllKeysForTarget(target, globalRegions, i)
 		registry.allKeys = append(registry.allKeys, targetKeys...)
 		registry.keysByName[target.Name] = targetKeys
+		registry.keysByIndex[i] = targetKeys
+		for _, key := range targetKeys {
+			registry.keysByID[registry.ids[i]+"@"+key.Region] = key
+		}
 	}
 
 	return registry
@@ -119,6 +161,19 @@ func (r *TargetKeyRegistry) GetAllKeys() []TargetKey {
 	return r.allKeys
 }
 
+// GetTargetID returns the ID of the target at index, see TargetIDs.
+func (r *TargetKeyRegistry) GetTargetID(index int) string {
+	return r.ids[index]
+}
+
+// Lookup returns the key for region of the target with the given ID. The
+// key carries the target's current position, which may differ from the
+// one a key built before a reload carries.
+func (r *TargetKeyRegistry) Lookup(id, region string) (TargetKey, bool) {
+	key, exists := r.keysByID[id+"@"+region]
+	return key, exists
+}
+
 func (r *TargetKeyRegistry) GetKeysForTarget(targetName string) []TargetKey {
 	if keys, exists := r.keysByName[targetName]; exists {
 		return keys
@@ -126,6 +181,16 @@ func (r *TargetKeyRegistry) GetKeysForTarget(targetName string) []TargetKey {
 	return nil
 }
 
+// GetRegionKeys returns the per-region keys aggregated by globalKey. Keys
+// are matched on the target index, so targets sharing a name never merge
+// each other's regions.
+func (r *TargetKeyRegistry) GetRegionKeys(globalKey TargetKey) []TargetKey {
+	if globalKey.TargetIndex < 0 || globalKey.TargetIndex >= len(r.keysByIndex) {
+		return nil
+	}
+	return r.keysByIndex[globalKey.TargetIndex]
+}
+
 func (r *TargetKeyRegistry) HasMultipleKeys() bool {
 	for _, keys := range r.keysByName {
 		if len(keys) > 1 {
@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -351,3 +352,79 @@ func TestTargetKeyWithSpecialCharacters(t *testing.T) {
 		}
 	})
 }
+
+func TestGlobalTargetKey(t *testing.T) {
+	targets := []config.Target{
+		{Name: "api", Regions: []string{"us-east-1", "eu-west-1"}},
+		{Name: "web", Regions: []string{"us-east-1"}},
+	}
+	registry := NewTargetKeyRegistry(targets, nil)
+
+	globalKey := NewGlobalTargetKey("api#0", 0)
+	if !globalKey.IsGlobal() {
+		t.Error("IsGlobal() = false, want true")
+	}
+	if got := globalKey.DisplayName(); got != "api (global)" {
+		t.Errorf("DisplayName() = %q, want %q", got, "api (global)")
+	}
+	if NewRegionTargetKey("api#0", "us-east-1", 0).IsGlobal() {
+		t.Error("IsGlobal() = true for region key, want false")
+	}
+	if NewLocalTargetKey("api#0", 0).IsGlobal() {
+		t.Error("IsGlobal() = true for local key, want false")
+	}
+
+	regionKeys := registry.GetRegionKeys(globalKey)
+	if len(regionKeys) != 2 {
+		t.Errorf("GetRegionKeys() returned %d keys, want 2", len(regionKeys))
+	}
+	if keys := registry.GetRegionKeys(NewGlobalTargetKey("web#1", 1)); len(keys) != 1 {
+		t.Errorf("GetRegionKeys() returned %d keys, want 1", len(keys))
+	}
+	if keys := registry.GetRegionKeys(NewGlobalTargetKey("web#2", 2)); keys != nil {
+		t.Errorf("GetRegionKeys() for unknown index = %v, want nil", keys)
+	}
+
+	sameName := NewTargetKeyRegistry([]config.Target{
+		{Name: "api", Regions: []string{"us-east-1", "eu-west-1"}},
+		{Name: "api", Regions: []string{"us-east-1", "eu-west-1"}},
+	}, nil)
+	for i := range 2 {
+		keys := sameName.GetRegionKeys(NewGlobalTargetKey(fmt.Sprintf("api#%d", i), i))
+		if len(keys) != 2 {
+			t.Fatalf("GetRegionKeys(%d) returned %d keys, want 2", i, len(keys))
+		}
+		for _, key := range keys {
+			if key.TargetIndex != i {
+				t.Errorf("GetRegionKeys(%d) returned key of target %d", i, key.TargetIndex)
+			}
+		}
+	}
+}
+
+func TestTargetIDs_StableAcrossInsertions(t *testing.T) {
+	before := []config.Target{{Name: "api"}, {Name: "web"}, {Name: "api"}}
+	after := []config.Target{{Name: "docs"}, {Name: "api"}, {Name: "web"}, {Name: "api"}}
+
+	if got, want := TargetIDs(before), []string{"api#0", "web#0", "api#1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TargetIDs() = %v, want %v", got, want)
+	}
+
+	registry := NewTargetKeyRegistry(after, []string{"us-east-1"})
+	for i, id := range TargetIDs(before) {
+		key, exists := registry.Lookup(id, "us-east-1")
+		if !exists {
+			t.Errorf("Lookup(%q) lost the target when another was inserted", id)
+			continue
+		}
+		if key.TargetIndex != i+1 {
+			t.Errorf("Lookup(%q).TargetIndex = %d, want %d", id, key.TargetIndex, i+1)
+		}
+		if want := fmt.Sprintf("%s#%d@us-east-1", before[i].Name, i+1); key.String() != want {
+			t.Errorf("Lookup(%q) = %q, want %q", id, key.String(), want)
+		}
+	}
+	if _, exists := registry.Lookup("api#0", "local"); exists {
+		t.Error("Lookup should not match a region the target is not checked from")
+	}
+}
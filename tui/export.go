@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Owloops/updo/exporter"
+	"github.com/Owloops/updo/redact"
+)
+
+var _unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ExportCurrentTarget writes the selected target as a curl command, and
+// its latest check as a HAR file, to the working directory. Secrets are
+// masked as they are in logs.
+func (m *Manager) ExportCurrentTarget() {
+	target := m.getCurrentTarget()
+	currentKey := m.getCurrentTargetKey()
+	if target == nil || currentKey == nil {
+		return
+	}
+
+	var latest *TargetData
+	for _, key := range m.getKeysForCurrentSelection() {
+		if data, exists := m.targetData[key.String()]; exists && (latest == nil || data.Result.LastCheckTime.After(latest.Result.LastCheckTime)) {
+			latest = &data
+		}
+	}
+	logKey := *currentKey
+	if latest != nil {
+		logKey = latest.TargetKey
+	}
+	defer func() {
+		if m.showLogs {
+			m.updateLogsWidgetForTargets(m.getKeysForCurrentSelection())
+		}
+	}()
+
+	slug := strings.Trim(_unsafeFileChars.ReplaceAllString(strings.ToLower(target.Name), "-"), "-")
+	if slug == "" {
+		slug = "target"
+	}
+	base := "updo-" + slug
+	files := []string{base + ".sh"}
+	if err := os.WriteFile(files[0], []byte(exporter.Curl(*target, redact.Current())), 0o600); err != nil {
+		m.logBuffer.AddLogEntry(LogLevelError, "Export failed", err.Error(), logKey)
+		return
+	}
+
+	if latest != nil {
+		har, err := exporter.HAR(exporter.HAREntry(latest.Result, redact.Current()))
+		if err == nil {
+			err = os.WriteFile(base+".har", har, 0o600)
+		}
+		if err != nil {
+			m.logBuffer.AddLogEntry(LogLevelError, "Export failed", err.Error(), logKey)
+			return
+		}
+		files = append(files, base+".har")
+	}
+
+	m.logBuffer.AddLogEntry(LogLevelInfo, "Exported target", "wrote "+strings.Join(files, " and "), logKey)
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Owloops/updo/utils"
+	ui "github.com/gizak/termui/v3"
+)
+
+func (m *Manager) ToggleIncidentsVisibility() {
+	m.showIncidents = !m.showIncidents
+
+	if m.showIncidents {
+		m.showLogs = false
+		m.focusOnLogs = false
+		m.detailsManager.ActiveGrid = m.detailsManager.IncidentsGrid
+		m.updateIncidentsWidget()
+	} else {
+		m.detailsManager.ActiveGrid = m.detailsManager.NormalGrid
+	}
+
+	if m.listWidget != nil {
+		m.listWidget.BorderStyle.Fg = ui.ColorGreen
+	}
+
+	m.setupGrid(m.termWidth, m.termHeight)
+}
+
+// updateIncidentsWidget lists the incidents of the selected target, newest
+// first. Region and global rows of one target share its incidents.
+func (m *Manager) updateIncidentsWidget() {
+	widget := m.detailsManager.IncidentsWidget
+	if widget == nil {
+		return
+	}
+
+	currentKey := m.getCurrentTargetKey()
+	if currentKey == nil {
+		widget.Title = _incidentsTitle
+		widget.Rows = []string{_noIncidents}
+		return
+	}
+
+	incidents := m.incidents.Incidents(currentKey.TargetName)
+	slices.Reverse(incidents)
+
+	ongoing := 0
+	now := time.Now()
+	rows := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		marker := "✔"
+		if incident.IsOngoing() {
+			marker = "✘"
+			ongoing++
+		}
+		rows = append(rows, fmt.Sprintf("%s %s", marker, utils.FormatIncident(incident, now)))
+	}
+
+	if len(rows) == 0 {
+		rows = []string{_noIncidents}
+	}
+
+	widget.Title = fmt.Sprintf("%s (%d, %d ongoing) - i:hide", _incidentsTitle, len(incidents), ongoing)
+	widget.Rows = rows
+	if widget.SelectedRow >= len(rows) {
+		widget.SelectedRow = 0
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Owloops/updo/redact"
 	"github.com/Owloops/updo/stats"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
@@ -158,8 +159,8 @@ func (lb *LogBuffer) AddLogEntry(level LogLevel, message, details string, target
 	entry := LogEntry{
 		Timestamp: time.Now(),
 		Level:     level,
-		Message:   message,
-		Details:   details,
+		Message:   redact.Text(message),
+		Details:   redact.Text(details),
 		TargetKey: targetKey,
 	}
 	lb.Add(entry)
@@ -379,6 +380,7 @@ func (m *Manager) ToggleLogsVisibility() {
 	m.showLogs = !m.showLogs
 
 	if m.showLogs {
+		m.showIncidents = false
 		m.focusOnLogs = true
 		m.detailsManager.ActiveGrid = m.detailsManager.LogsGrid
 
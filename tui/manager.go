@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"sync"
@@ -25,14 +26,16 @@ const (
 	_targetIcon            = "◉"
 	_backspaceKey          = "<Backspace>"
 	_ctrlBackspace         = "<C-8>"
-	_defaultRefresh        = 5
+	_defaultRefresh        = 5 * time.Second
 	_logBufferSize         = 1000
 	_dataChannelMultiplier = 2
 	_targetsTitle          = "Targets"
+	_noIncidents           = "No incidents recorded"
 )
 
 type Manager struct {
 	targets         []config.Target
+	regions         []string
 	keyRegistry     *stats.TargetKeyRegistry
 	targetData      map[string]TargetData
 	plotData        map[string]PlotHistory
@@ -49,14 +52,18 @@ type Manager struct {
 	termHeight      int
 	focusOnLogs     bool
 	showLogs        bool
+	incidents       *stats.IncidentTracker
+	showIncidents   bool
 
 	itemToKeyIndex          []int
+	itemToGlobalKey         map[int]stats.TargetKey
 	preserveHeaderSelection string
 }
 
 type PlotHistory struct {
 	UptimeData       []float64
 	ResponseTimeData []float64
+	AnomalyData      []bool
 }
 
 func NewManager(targets []config.Target, options Options) *Manager {
@@ -65,10 +72,12 @@ func NewManager(targets []config.Target, options Options) *Manager {
 
 	m := &Manager{
 		targets:         targets,
+		regions:         options.Regions,
 		keyRegistry:     keyRegistry,
 		targetData:      make(map[string]TargetData, len(allKeys)),
 		plotData:        make(map[string]PlotHistory, len(allKeys)),
 		logBuffer:       NewLogBuffer(_logBufferSize),
+		incidents:       stats.NewIncidentTracker(),
 		sslExpiry:       make(map[string]int, len(targets)),
 		currentKeyIndex: 0,
 		isSingle:        len(allKeys) == 1,
@@ -101,10 +110,11 @@ func (m *Manager) InitializeLayout(width, height int) {
 	m.termWidth = width
 	m.termHeight = height
 
-	allKeys := m.keyRegistry.GetAllKeys()
-	if len(allKeys) > 0 {
+	// Without targets yet, the details are set up empty so that they are
+	// ready once targets are discovered.
+	var firstTarget config.Target
+	if allKeys := m.keyRegistry.GetAllKeys(); len(allKeys) > 0 {
 		firstKey := allKeys[0]
-		var firstTarget config.Target
 		for _, target := range m.targets {
 			if target.Name == firstKey.TargetName {
 				firstTarget = target
@@ -112,11 +122,11 @@ func (m *Manager) InitializeLayout(width, height int) {
 			}
 		}
 		if m.isSingle && len(m.targets) > 0 {
-			m.detailsManager.InitializeWidgets(m.targets[0].URL, m.targets[0].GetRefreshInterval())
-		} else {
-			m.detailsManager.InitializeWidgets(firstTarget.URL, firstTarget.GetRefreshInterval())
+			firstTarget = m.targets[0]
 		}
 	}
+	m.detailsManager.InitializeWidgets(firstTarget.URL, firstTarget.GetRefreshInterval())
+	m.detailsManager.SetTarget(firstTarget)
 
 	if !m.isSingle {
 		m.initializeMultiTargetWidgets()
@@ -125,6 +135,49 @@ func (m *Manager) InitializeLayout(width, height int) {
 	m.setupGrid(width, height)
 }
 
+// logKey returns the key general log entries are attached to: the first
+// key, or the zero key while there are no targets.
+func (m *Manager) logKey() stats.TargetKey {
+	if allKeys := m.keyRegistry.GetAllKeys(); len(allKeys) > 0 {
+		return allKeys[0]
+	}
+	return stats.TargetKey{}
+}
+
+// Reload switches to the targets of a reloaded config. Check data and plot
+// history of keys that still exist are kept.
+func (m *Manager) Reload(targets []config.Target, monitors map[string]*stats.Monitor) {
+	m.targets = targets
+	m.keyRegistry = stats.NewTargetKeyRegistry(targets, m.regions)
+	allKeys := m.keyRegistry.GetAllKeys()
+
+	known := make(map[string]bool, len(allKeys))
+	for _, key := range allKeys {
+		known[key.String()] = true
+		known[stats.NewGlobalTargetKey(key.TargetName, key.TargetIndex).String()] = true
+	}
+	maps.DeleteFunc(m.targetData, func(key string, _ TargetData) bool { return !known[key] })
+	maps.DeleteFunc(m.plotData, func(key string, _ PlotHistory) bool { return !known[key] })
+
+	if m.currentKeyIndex >= len(allKeys) {
+		m.currentKeyIndex = 0
+	}
+
+	wasSingle := m.isSingle
+	m.isSingle = len(allKeys) == 1
+	switch {
+	case wasSingle != m.isSingle:
+		if !m.isSingle && m.listWidget == nil {
+			m.initializeMultiTargetWidgets()
+		}
+		m.setupGrid(m.termWidth, m.termHeight)
+	case !m.isSingle:
+		m.updateTargetList()
+	}
+
+	m.updateActiveTarget(monitors)
+}
+
 func (m *Manager) updateTargetList() {
 	if m.listWidget == nil {
 		return
@@ -148,6 +201,7 @@ func (m *Manager) updateTargetList() {
 	var items []string
 	var metadata []uw.RowMetadata
 	var itemToKeyIndex []int
+	itemToGlobalKey := make(map[int]stats.TargetKey)
 
 	keyIndex := 0
 	for _, targetName := range targetOrder {
@@ -173,22 +227,24 @@ func (m *Manager) updateTargetList() {
 		itemToKeyIndex = append(itemToKeyIndex, -1)
 
 		if !isCollapsed {
-			for _, key := range keys {
+			if len(keys) > 1 {
+				globalKey := stats.NewGlobalTargetKey(keys[0].TargetName, keys[0].TargetIndex)
+				coloredIcon := fmt.Sprintf("[%s](fg:%s)", _targetIcon, m.keyStatusColor(globalKey))
+				line := fmt.Sprintf("  %s %s", coloredIcon, globalKey.Region)
 
-				var icon, iconColor string
-
-				if data, exists := m.targetData[key.String()]; exists {
-					if data.Result.IsUp {
-						icon = _targetIcon
-						iconColor = "green"
-					} else {
-						icon = _targetIcon
-						iconColor = "red"
-					}
-				} else {
-					icon = _targetIcon
-					iconColor = "yellow"
-				}
+				itemToGlobalKey[len(items)] = globalKey
+				items = append(items, "  "+line)
+				metadata = append(metadata, uw.RowMetadata{
+					GroupID:      groupID,
+					IsHeader:     false,
+					IsSelectable: true,
+				})
+				itemToKeyIndex = append(itemToKeyIndex, -1)
+			}
+
+			for _, key := range keys {
+				icon := _targetIcon
+				iconColor := m.keyStatusColor(key)
 
 				region := "local"
 				if !key.IsLocal && key.Region != "" {
@@ -216,6 +272,7 @@ func (m *Manager) updateTargetList() {
 	}
 
 	m.itemToKeyIndex = itemToKeyIndex
+	m.itemToGlobalKey = itemToGlobalKey
 
 	m.listWidget.SetRowsWithMetadata(items, metadata)
 
@@ -270,6 +327,10 @@ func (m *Manager) getCurrentTargetKey() *stats.TargetKey {
 		originalIdx = selectedRow
 	}
 
+	if globalKey, exists := m.itemToGlobalKey[originalIdx]; exists {
+		return &globalKey
+	}
+
 	if originalIdx >= 0 && originalIdx < len(m.itemToKeyIndex) {
 		keyIdx := m.itemToKeyIndex[originalIdx]
 		if keyIdx >= 0 {
@@ -317,12 +378,59 @@ func (m *Manager) getKeysForCurrentSelection() []stats.TargetKey {
 	}
 
 	if key := m.getCurrentTargetKey(); key != nil {
+		if key.IsGlobal() {
+			return m.keyRegistry.GetRegionKeys(*key)
+		}
 		return []stats.TargetKey{*key}
 	}
 
 	return nil
 }
 
+// keyStatusColor returns the list icon color for key. A global key is
+// green when every region is up, red when every region is down and yellow
+// when regions disagree or have not reported yet.
+func (m *Manager) keyStatusColor(key stats.TargetKey) string {
+	keys := []stats.TargetKey{key}
+	if key.IsGlobal() {
+		keys = m.keyRegistry.GetRegionKeys(key)
+	}
+
+	upCount, downCount := 0, 0
+	for _, k := range keys {
+		data, exists := m.targetData[k.String()]
+		switch {
+		case !exists:
+		case data.Result.IsUp:
+			upCount++
+		default:
+			downCount++
+		}
+	}
+
+	switch {
+	case upCount == len(keys):
+		return "green"
+	case downCount == len(keys):
+		return "red"
+	default:
+		return "yellow"
+	}
+}
+
+func (m *Manager) monitorForKey(key stats.TargetKey, monitors map[string]*stats.Monitor) (*stats.Monitor, bool) {
+	if key.IsGlobal() {
+		merged, err := stats.MergeKeys(monitors, m.keyRegistry.GetRegionKeys(key))
+		if err != nil {
+			return nil, false
+		}
+		return merged, true
+	}
+
+	monitor, exists := monitors[key.String()]
+	return monitor, exists
+}
+
 func (m *Manager) getCurrentTarget() *config.Target {
 	currentKey := m.getCurrentTargetKey()
 	if currentKey == nil {
@@ -363,13 +471,12 @@ func (m *Manager) updateSelectionColors() {
 	}
 	currentKey := m.getCurrentTargetKey()
 	if currentKey != nil {
-		if data, exists := m.targetData[currentKey.String()]; exists {
-			if data.Result.IsUp {
-				m.listWidget.SelectedRowStyle.Fg = ui.ColorGreen
-			} else {
-				m.listWidget.SelectedRowStyle.Fg = ui.ColorRed
-			}
-		} else {
+		switch m.keyStatusColor(*currentKey) {
+		case "green":
+			m.listWidget.SelectedRowStyle.Fg = ui.ColorGreen
+		case "red":
+			m.listWidget.SelectedRowStyle.Fg = ui.ColorRed
+		default:
 			m.listWidget.SelectedRowStyle.Fg = ui.ColorYellow
 		}
 	} else {
@@ -393,24 +500,24 @@ func (m *Manager) updateActiveTarget(monitors map[string]*stats.Monitor) {
 
 	currentTarget := m.getCurrentTarget()
 	if currentTarget != nil && m.detailsManager.RefreshWidget != nil {
-		refreshInterval := currentTarget.RefreshInterval
+		refreshInterval := currentTarget.GetRefreshInterval()
 		if refreshInterval == 0 {
 			refreshInterval = _defaultRefresh
 		}
-		m.detailsManager.RefreshWidget.Text = fmt.Sprintf("%d seconds", refreshInterval)
+		m.detailsManager.RefreshWidget.Text = config.FormatDuration(refreshInterval)
 	}
 
 	if currentTarget != nil && m.detailsManager.URLWidget != nil {
-		m.detailsManager.URLWidget.Text = currentTarget.URL
+		m.detailsManager.SetTarget(*currentTarget)
 	}
 
 	targetKeyStr := currentKey.String()
 
 	m.restorePlotData(targetKeyStr)
 
-	if monitor, exists := monitors[targetKeyStr]; exists {
+	if monitor, exists := m.monitorForKey(*currentKey, monitors); exists {
 		freshStats := monitor.GetStats()
-		if data, exists := m.targetData[targetKeyStr]; exists {
+		if data, exists := m.latestDataForKey(*currentKey); exists {
 			m.updateCurrentTargetWidgets(data.Result, freshStats)
 		} else {
 			m.detailsManager.UpForWidget.Text = utils.FormatDurationMinute(freshStats.TotalDuration)
@@ -421,7 +528,15 @@ func (m *Manager) updateActiveTarget(monitors map[string]*stats.Monitor) {
 	}
 
 	if m.showLogs {
-		m.updateLogsWidget(*currentKey)
+		if currentKey.IsGlobal() {
+			m.updateLogsWidgetForTargets(m.keyRegistry.GetRegionKeys(*currentKey))
+		} else {
+			m.updateLogsWidget(*currentKey)
+		}
+	}
+
+	if m.showIncidents {
+		m.updateIncidentsWidget()
 	}
 
 	if !m.isSingle {
@@ -435,7 +550,12 @@ func (m *Manager) UpdateTarget(data TargetData) {
 	targetKeyStr := data.TargetKey.String()
 	m.targetData[targetKeyStr] = data
 
-	m.updatePlotDataForTarget(targetKeyStr, data.Result)
+	m.updatePlotDataForTarget(targetKeyStr, data.Result, data.Anomaly != nil)
+
+	globalKey := stats.NewGlobalTargetKey(data.TargetKey.TargetName, data.TargetKey.TargetIndex)
+	if !data.TargetKey.IsLocal && len(m.keyRegistry.GetRegionKeys(globalKey)) > 1 {
+		m.updatePlotDataForTarget(globalKey.String(), data.Result, data.Anomaly != nil)
+	}
 
 	logAdded := false
 
@@ -454,26 +574,31 @@ func (m *Manager) UpdateTarget(data TargetData) {
 		logAdded = true
 	}
 
+	if data.Anomaly != nil {
+		details := fmt.Sprintf("%dms vs baseline %dms (z=%.1f)", data.Anomaly.ResponseTime.Milliseconds(), data.Anomaly.Baseline.Milliseconds(), data.Anomaly.ZScore)
+		m.logBuffer.AddLogEntry(LogLevelWarning, "Latency anomaly", details, data.TargetKey)
+		logAdded = true
+	}
+
 	if data.Result.ResponseTruncated {
 		m.logBuffer.AddLogEntry(LogLevelWarning, "Response body truncated", "Exceeded BodySizeLimit; assertion checks may be unreliable", data.TargetKey)
 		logAdded = true
 	}
 
-	if !data.Result.IsUp && data.LambdaError == nil {
-		level := LogLevelError
-		message := "Request failed"
-
-		switch {
-		case data.Result.AssertText != "" && !data.Result.AssertionPassed && data.Result.StatusCode >= 200 && data.Result.StatusCode < 300:
-			message = fmt.Sprintf("Assertion failed (status %d)", data.Result.StatusCode)
-			level = LogLevelWarning
-		case data.Result.StatusCode > 0:
-			message = fmt.Sprintf("Status code: %d", data.Result.StatusCode)
-		case !data.TargetKey.IsLocal:
-			message = "Lambda invocation failed"
-			level = LogLevelWarning
+	if data.LambdaError == nil {
+		_, message := failureLogEntry(data)
+		if incident := m.incidents.Record(data.TargetKey, data.Result.IsUp, message, time.Now()); incident != nil {
+			if incident.IsOngoing() {
+				m.logBuffer.AddLogEntry(LogLevelError, "Incident started", utils.FormatIncident(*incident, time.Now()), data.TargetKey)
+			} else {
+				m.logBuffer.AddLogEntry(LogLevelInfo, "Incident resolved", utils.FormatIncident(*incident, time.Now()), data.TargetKey)
+			}
+			logAdded = true
 		}
+	}
 
+	if !data.Result.IsUp && data.LambdaError == nil {
+		level, message := failureLogEntry(data)
 		m.logBuffer.AddLogEntry(level, message, "", data.TargetKey)
 		logAdded = true
 	} else if data.Result.IsUp && (m.logBuffer.Size() == 0 || m.logBuffer.Size()%10 == 0) {
@@ -482,18 +607,28 @@ func (m *Manager) UpdateTarget(data TargetData) {
 	}
 
 	currentKey := m.getCurrentTargetKey()
-	if currentKey != nil && currentKey.String() == targetKeyStr {
+	isCurrentGlobal := currentKey != nil && currentKey.IsGlobal() && currentKey.String() == globalKey.String()
+	if currentKey != nil && (currentKey.String() == targetKeyStr || isCurrentGlobal) {
 		if m.isSingle && m.detailsManager.URLWidget != nil {
-			m.detailsManager.URLWidget.Text = data.Target.URL
+			m.detailsManager.SetTarget(data.Target)
+		}
+		m.restorePlotData(currentKey.String())
+		if isCurrentGlobal {
+			// Aggregated stats are recomputed from the monitors on the next
+			// RefreshStats tick; only the per-check widgets change here.
+			m.updateResultWidgets(data.Result)
+		} else {
+			m.updateCurrentTargetWidgets(data.Result, data.Stats)
 		}
-		m.restorePlotData(targetKeyStr)
-		m.updateCurrentTargetWidgets(data.Result, data.Stats)
 		if m.showLogs && logAdded {
 			keys := m.getKeysForCurrentSelection()
 			if len(keys) > 0 {
 				m.updateLogsWidgetForTargets(keys)
 			}
 		}
+		if m.showIncidents {
+			m.updateIncidentsWidget()
+		}
 		ui.Render(m.grid)
 	} else if !m.isSingle {
 		m.updateTargetList()
@@ -501,6 +636,24 @@ func (m *Manager) UpdateTarget(data TargetData) {
 	}
 }
 
+// failureLogEntry describes a failed check for the logs panel; the message
+// also serves as the first error of any incident the check opens.
+func failureLogEntry(data TargetData) (LogLevel, string) {
+	if data.Result.IsUp {
+		return LogLevelInfo, ""
+	}
+
+	switch {
+	case data.Result.AssertText != "" && !data.Result.AssertionPassed && data.Result.StatusCode >= 200 && data.Result.StatusCode < 300:
+		return LogLevelWarning, fmt.Sprintf("Assertion failed (status %d)", data.Result.StatusCode)
+	case data.Result.StatusCode > 0:
+		return LogLevelError, fmt.Sprintf("Status code: %d", data.Result.StatusCode)
+	case !data.TargetKey.IsLocal:
+		return LogLevelWarning, "Lambda invocation failed"
+	}
+	return LogLevelError, "Request failed"
+}
+
 func (m *Manager) RefreshStats(monitors map[string]*stats.Monitor) {
 	currentKey := m.getCurrentTargetKey()
 	if currentKey == nil {
@@ -509,14 +662,14 @@ func (m *Manager) RefreshStats(monitors map[string]*stats.Monitor) {
 
 	currentTarget := m.getCurrentTarget()
 	if currentTarget != nil && m.detailsManager.RefreshWidget != nil {
-		refreshInterval := currentTarget.RefreshInterval
+		refreshInterval := currentTarget.GetRefreshInterval()
 		if refreshInterval == 0 {
 			refreshInterval = _defaultRefresh
 		}
-		m.detailsManager.RefreshWidget.Text = fmt.Sprintf("%d seconds", refreshInterval)
+		m.detailsManager.RefreshWidget.Text = config.FormatDuration(refreshInterval)
 	}
 
-	if monitor, exists := monitors[currentKey.String()]; exists {
+	if monitor, exists := m.monitorForKey(*currentKey, monitors); exists {
 		freshStats := monitor.GetStats()
 
 		m.detailsManager.UptimeWidget.Text = fmt.Sprintf("%.2f%%", freshStats.UptimePercent)
@@ -537,6 +690,11 @@ func (m *Manager) RefreshStats(monitors map[string]*stats.Monitor) {
 		} else {
 			m.detailsManager.P95ResponseTimeWidget.Text = _notAvailable
 		}
+		m.detailsManager.ApdexWidget.Text = formatApdex(freshStats)
+
+		if m.showIncidents {
+			m.updateIncidentsWidget()
+		}
 
 		if !m.isSingle {
 			if !m.isSelectedRowHeader() {
@@ -566,7 +724,19 @@ func (m *Manager) updateCurrentTargetWidgets(result net.WebsiteCheckResult, stat
 	} else {
 		m.detailsManager.P95ResponseTimeWidget.Text = _notAvailable
 	}
+	m.detailsManager.ApdexWidget.Text = formatApdex(stats)
 
+	m.updateResultWidgets(result)
+}
+
+func formatApdex(s stats.Stats) string {
+	if !s.HasApdex() {
+		return _notAvailable
+	}
+	return fmt.Sprintf("%.2f (T=%s)", s.ApdexScore, s.ApdexThreshold)
+}
+
+func (m *Manager) updateResultWidgets(result net.WebsiteCheckResult) {
 	sslExpiry := m.getSSLExpiry(result.URL)
 	if sslExpiry > 0 {
 		m.detailsManager.SSLOkWidget.Text = fmt.Sprintf("%d days remaining", sslExpiry)
@@ -595,26 +765,50 @@ func (m *Manager) updateCurrentTargetWidgets(result net.WebsiteCheckResult, stat
 
 }
 
+// latestDataForKey returns the last check received for key. For a global
+// key this is the most recent check across its regions.
+func (m *Manager) latestDataForKey(key stats.TargetKey) (TargetData, bool) {
+	if !key.IsGlobal() {
+		data, exists := m.targetData[key.String()]
+		return data, exists
+	}
+
+	var latest TargetData
+	found := false
+	for _, regionKey := range m.keyRegistry.GetRegionKeys(key) {
+		data, exists := m.targetData[regionKey.String()]
+		if exists && (!found || data.Result.LastCheckTime.After(latest.Result.LastCheckTime)) {
+			latest = data
+			found = true
+		}
+	}
+	return latest, found
+}
+
 func (m *Manager) restorePlotData(targetName string) {
 	if history, exists := m.plotData[targetName]; exists {
 		m.detailsManager.UptimePlot.Data[0] = slices.Clone(history.UptimeData)
 		m.detailsManager.ResponseTimePlot.Data[0] = slices.Clone(history.ResponseTimeData)
+		m.detailsManager.ResponseTimePlot.Anomalies = slices.Clone(history.AnomalyData)
 	} else {
 		m.detailsManager.UptimePlot.Data[0] = nil
 		m.detailsManager.ResponseTimePlot.Data[0] = []float64{0.0, 0.0}
+		m.detailsManager.ResponseTimePlot.Anomalies = nil
 	}
 }
 
-func (m *Manager) updatePlotDataForTarget(targetName string, result net.WebsiteCheckResult) {
+func (m *Manager) updatePlotDataForTarget(targetName string, result net.WebsiteCheckResult, anomalous bool) {
 	history, exists := m.plotData[targetName]
 	if !exists {
 		history = PlotHistory{
 			ResponseTimeData: []float64{0.0, 0.0},
+			AnomalyData:      []bool{false, false},
 		}
 	}
 
 	history.UptimeData = append(history.UptimeData, utils.BoolToFloat64(result.IsUp))
 	history.ResponseTimeData = append(history.ResponseTimeData, result.ResponseTime.Seconds())
+	history.AnomalyData = append(history.AnomalyData, anomalous)
 
 	maxLength := m.termWidth / 2
 
@@ -626,5 +820,9 @@ func (m *Manager) updatePlotDataForTarget(targetName string, result net.WebsiteC
 		history.ResponseTimeData = history.ResponseTimeData[len(history.ResponseTimeData)-maxLength:]
 	}
 
+	if len(history.AnomalyData) > maxLength {
+		history.AnomalyData = history.AnomalyData[len(history.AnomalyData)-maxLength:]
+	}
+
 	m.plotData[targetName] = history
 }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/Owloops/updo/net"
 	"github.com/Owloops/updo/stats"
 	"github.com/Owloops/updo/utils"
+	"github.com/gizak/termui/v3/widgets"
 )
 
 func TestNewManager(t *testing.T) {
@@ -267,3 +269,118 @@ func TestManager_TraceInfoSanitization(t *testing.T) {
 		}
 	}
 }
+
+func TestManager_GlobalKeyStatusColor(t *testing.T) {
+	targets := []config.Target{
+		{Name: "api", URL: "https://api.example.com"},
+	}
+
+	manager := NewManager(targets, Options{Regions: []string{"us-east-1", "eu-west-1"}})
+	regionKeys := manager.keyRegistry.GetAllKeys()
+	globalKey := stats.NewGlobalTargetKey(regionKeys[0].TargetName, regionKeys[0].TargetIndex)
+
+	if got := manager.keyStatusColor(globalKey); got != "yellow" {
+		t.Errorf("keyStatusColor() before any check = %q, want yellow", got)
+	}
+
+	for _, key := range regionKeys {
+		manager.targetData[key.String()] = TargetData{TargetKey: key, Result: net.WebsiteCheckResult{IsUp: true}}
+	}
+	if got := manager.keyStatusColor(globalKey); got != "green" {
+		t.Errorf("keyStatusColor() with all regions up = %q, want green", got)
+	}
+
+	manager.targetData[regionKeys[1].String()] = TargetData{TargetKey: regionKeys[1], Result: net.WebsiteCheckResult{IsUp: false}}
+	if got := manager.keyStatusColor(globalKey); got != "yellow" {
+		t.Errorf("keyStatusColor() with mixed regions = %q, want yellow", got)
+	}
+
+	monitors := make(map[string]*stats.Monitor, len(regionKeys))
+	for _, key := range regionKeys {
+		monitor, err := stats.NewMonitor()
+		if err != nil {
+			t.Fatalf("NewMonitor failed: %v", err)
+		}
+		monitor.AddResult(net.WebsiteCheckResult{IsUp: true, ResponseTime: 100 * time.Millisecond})
+		monitors[key.String()] = monitor
+	}
+
+	merged, exists := manager.monitorForKey(globalKey, monitors)
+	if !exists {
+		t.Fatal("monitorForKey() found no monitor for global key")
+	}
+	if merged.ChecksCount != len(regionKeys) {
+		t.Errorf("merged ChecksCount = %d, want %d", merged.ChecksCount, len(regionKeys))
+	}
+}
+
+func TestManager_IncidentsPanel(t *testing.T) {
+	targets := []config.Target{
+		{Name: "api", URL: "https://api.example.com"},
+		{Name: "web", URL: "https://web.example.com"},
+	}
+
+	manager := NewManager(targets, Options{Regions: []string{}})
+	manager.detailsManager.IncidentsWidget = widgets.NewList()
+	key := manager.keyRegistry.GetAllKeys()[0]
+
+	manager.updateIncidentsWidget()
+	if rows := manager.detailsManager.IncidentsWidget.Rows; len(rows) != 1 || rows[0] != _noIncidents {
+		t.Errorf("Incidents rows = %q, want the empty placeholder", rows)
+	}
+
+	down := TargetData{TargetKey: key, Result: net.WebsiteCheckResult{IsUp: false, StatusCode: 503}}
+	_, message := failureLogEntry(down)
+	start := time.Now().Add(-time.Minute)
+	manager.incidents.Record(key, false, message, start)
+	manager.incidents.Record(key, true, "", start.Add(30*time.Second))
+	manager.incidents.Record(key, false, "Request failed", start.Add(45*time.Second))
+	manager.updateIncidentsWidget()
+
+	rows := manager.detailsManager.IncidentsWidget.Rows
+	if len(rows) != 2 {
+		t.Fatalf("Incidents rows = %q, want 2 rows", rows)
+	}
+	if !strings.HasPrefix(rows[0], "✘") || !strings.Contains(rows[0], "Request failed") {
+		t.Errorf("First row = %q, want the ongoing incident first", rows[0])
+	}
+	if !strings.HasPrefix(rows[1], "✔") || !strings.Contains(rows[1], "Status code: 503") {
+		t.Errorf("Second row = %q, want the resolved incident", rows[1])
+	}
+}
+
+func TestManager_ExportCurrentTarget(t *testing.T) {
+	t.Chdir(t.TempDir())
+	targets := []config.Target{
+		{Name: "My API", URL: "https://api.example.com", Headers: []string{"Authorization: Bearer abc"}},
+	}
+	manager := NewManager(targets, Options{Regions: []string{}})
+
+	manager.ExportCurrentTarget()
+	curl, err := os.ReadFile("updo-my-api.sh")
+	if err != nil {
+		t.Fatalf("Expected the curl command to be written: %v", err)
+	}
+	if !strings.Contains(string(curl), "curl https://api.example.com") || strings.Contains(string(curl), "abc") {
+		t.Errorf("Unexpected curl command:\n%s", curl)
+	}
+	if _, err := os.Stat("updo-my-api.har"); !os.IsNotExist(err) {
+		t.Error("Expected no HAR file before the first check")
+	}
+
+	key := manager.keyRegistry.GetAllKeys()[0]
+	manager.targetData[key.String()] = TargetData{
+		Target:    targets[0],
+		TargetKey: key,
+		Result:    net.WebsiteCheckResult{URL: targets[0].URL, IsUp: true, StatusCode: 200, LastCheckTime: time.Now()},
+	}
+	manager.ExportCurrentTarget()
+	if _, err := os.Stat("updo-my-api.har"); err != nil {
+		t.Errorf("Expected the HAR file to be written: %v", err)
+	}
+
+	entries := manager.logBuffer.GetEntriesForTarget(key)
+	if last := entries[len(entries)-1]; last.Message != "Exported target" || last.Details != "wrote updo-my-api.sh and updo-my-api.har" {
+		t.Errorf("Unexpected log entry %+v", last)
+	}
+}
@@ -3,9 +3,14 @@ package tui
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Owloops/updo/aws"
@@ -25,6 +30,9 @@ type TargetData struct {
 	WebhookError error
 	LambdaError  error
 	AlertError   error
+	Anomaly      *stats.Anomaly
+	// TargetID identifies the target across reloads, see stats.TargetIDs.
+	TargetID string
 }
 
 type Options struct {
@@ -33,88 +41,106 @@ type Options struct {
 	Regions       []string
 	Profile       string
 	PrometheusURL string
+	StateFile     string
+	MetricsListen string
+	OTLPEndpoint  string
+	OTLPProtocol  string
+	Metrics       config.Metrics
+	// ConfigFile is reloaded when it changes or on SIGHUP, with the Only
+	// and Skip filters applied again. Runs with a Count are not reloaded.
+	ConfigFile string
+	Only       []string
+	Skip       []string
+	// DiscoveryFiles are the glob patterns of the file_sd files of
+	// ConfigFile, watched along with it. With discovery files, monitoring
+	// may start without targets and wait for them to be discovered.
+	DiscoveryFiles []string
+	// IncludeFiles are the glob patterns of the files ConfigFile includes,
+	// watched along with it.
+	IncludeFiles []string
 }
 
 func StartMonitoring(targets []config.Target, options Options) {
-	if len(targets) == 0 {
+	reloadable := options.ConfigFile != "" && options.Count == 0
+	if len(targets) == 0 && (!reloadable || len(options.DiscoveryFiles) == 0) {
 		panic("No targets provided")
 	}
 
-	if err := ui.Init(); err != nil {
-		panic(err)
-	}
-	defer ui.Close()
-
-	prometheusURL := options.PrometheusURL
-	if prometheusURL == "" {
-		if updoURL := os.Getenv("UPDO_PROMETHEUS_RW_SERVER_URL"); updoURL != "" {
-			prometheusURL = updoURL
-		}
+	if err := metrics.Setup(metrics.SetupOptions{
+		PrometheusURL: options.PrometheusURL,
+		MetricsListen: options.MetricsListen,
+		OTLPEndpoint:  options.OTLPEndpoint,
+		OTLPProtocol:  options.OTLPProtocol,
+		Config:        options.Metrics,
+	}); err != nil {
+		log.Fatalf("Failed to start metrics export: %v", err)
 	}
-
-	if prometheusURL != "" {
-		metricsConfig := metrics.NewConfig()
-		metricsConfig.ServerURL = prometheusURL
-
-		if username := os.Getenv("UPDO_PROMETHEUS_USERNAME"); username != "" {
-			metricsConfig.Username = username
-		}
-		if password := os.Getenv("UPDO_PROMETHEUS_PASSWORD"); password != "" {
-			metricsConfig.Password = password
-		}
-		if bearerToken := os.Getenv("UPDO_PROMETHEUS_BEARER_TOKEN"); bearerToken != "" {
-			metricsConfig.Headers["Authorization"] = "Bearer " + bearerToken
-		}
-		if authHeader := os.Getenv("UPDO_PROMETHEUS_AUTH_HEADER"); authHeader != "" {
-			parts := strings.SplitN(authHeader, ":", 2)
-			if len(parts) == 2 {
-				metricsConfig.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
-			}
-		}
-		if pushInterval := os.Getenv("UPDO_PROMETHEUS_PUSH_INTERVAL"); pushInterval != "" {
-			if duration, err := time.ParseDuration(pushInterval); err == nil {
-				metricsConfig.PushInterval = duration
-			}
+	defer metrics.StopSinks()
+
+	// Registered before ui.Close so the message is printed once the
+	// terminal has been restored.
+	var saveStateErr error
+	defer func() {
+		if saveStateErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save state to %s: %v\n", options.StateFile, saveStateErr)
 		}
+	}()
 
-		metrics.InitRemoteWrite(metricsConfig)
-		defer metrics.StopRemoteWrite()
+	if err := ui.Init(); err != nil {
+		panic(err)
 	}
+	defer ui.Close()
 
 	keyRegistry := stats.NewTargetKeyRegistry(targets, options.Regions)
-	allKeys := keyRegistry.GetAllKeys()
-
-	monitors := make(map[string]*stats.Monitor, len(allKeys))
-	sequences := make(map[string]*int, len(allKeys))
-	alertStates := make(map[string]*bool, len(allKeys))
-	webhookAlertStates := make(map[string]*bool, len(allKeys))
+	state, err := stats.NewTargetState(keyRegistry, targets, nil)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize stats monitor for %v", err))
+	}
 
-	for _, key := range allKeys {
-		monitor, err := stats.NewMonitor()
-		if err != nil {
-			panic(fmt.Sprintf("Failed to initialize stats monitor for %s: %v", key.String(), err))
-		}
-		monitors[key.String()] = monitor
-		seq := 0
-		alert := false
-		webhookAlert := false
-		sequences[key.String()] = &seq
-		alertStates[key.String()] = &alert
-		webhookAlertStates[key.String()] = &webhookAlert
+	var loadStateErr error
+	if options.StateFile != "" {
+		_, loadStateErr = stats.LoadState(options.StateFile, state.Monitors, state.AlertStates, state.WebhookAlertStates)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	dataChannel := make(chan TargetData, len(targets)*_dataChannelMultiplier)
+	dataChannel := make(chan TargetData, max(len(targets), 1)*_dataChannelMultiplier)
 	var wg sync.WaitGroup
 
-	for i, target := range targets {
+	if options.StateFile != "" {
+		// The state is saved once every monitoring goroutine has returned,
+		// so that no check is halfway through updating its monitor.
+		// Data still being sent is drained to let them finish.
+		defer func() {
+			cancel()
+			for range dataChannel {
+			}
+			saveStateErr = stats.SaveState(options.StateFile, state.Monitors, state.AlertStates, state.WebhookAlertStates)
+		}()
+	}
+
+	runners := make(map[string]context.CancelFunc, len(targets))
+	startTarget := func(target config.Target, id string, index int) {
+		targetCtx, targetCancel := context.WithCancel(ctx)
+		runners[id] = targetCancel
+		targetState := state
 		wg.Add(1)
-		go func(t config.Target, index int) {
+		go func() {
 			defer wg.Done()
-			monitorTargetTUI(ctx, t, index, monitors, sequences, alertStates, webhookAlertStates, dataChannel, options)
-		}(target, i)
+			monitorTargetTUI(targetCtx, target, id, index, targetState.Monitors, targetState.Sequences, targetState.AlertStates, targetState.WebhookAlertStates, dataChannel, options)
+		}()
+	}
+
+	for i, target := range targets {
+		startTarget(target, keyRegistry.GetTargetID(i), i)
+	}
+
+	// Reloads may start targets at any time, even after all of them have
+	// been removed, so the data channel is kept open until monitoring ends.
+	if reloadable {
+		wg.Add(1)
+		defer wg.Done()
 	}
 
 	go func() {
@@ -126,6 +152,102 @@ func StartMonitoring(targets []config.Target, options Options) {
 	width, height := ui.TerminalDimensions()
 	manager.InitializeLayout(width, height)
 
+	if loadStateErr != nil {
+		manager.logBuffer.AddLogEntry(LogLevelWarning, "State restore failed", loadStateErr.Error(), manager.logKey())
+	}
+
+	// Reset before ui.Close so messages from stopping the sinks are printed
+	// to the restored terminal.
+	sinkLogKey := manager.logKey()
+	metrics.SetLogHandler(func(message string, err error) {
+		if err != nil {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, message, err.Error(), sinkLogKey)
+			return
+		}
+		manager.logBuffer.AddLogEntry(LogLevelInfo, message, "", sinkLogKey)
+	})
+	defer metrics.SetLogHandler(nil)
+
+	var watcher *config.Watcher
+	var reloads <-chan struct{}
+	if reloadable {
+		watcher, err = config.Watch(options.ConfigFile)
+		if err != nil {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Config changes will not be reloaded", err.Error(), manager.logKey())
+		} else {
+			defer func() { _ = watcher.Close() }()
+			reloads = watcher.C
+			if err := watcher.WatchFiles(slices.Concat(options.IncludeFiles, options.DiscoveryFiles)); err != nil {
+				manager.logBuffer.AddLogEntry(LogLevelWarning, "Discovery file changes may not be noticed", err.Error(), manager.logKey())
+			}
+		}
+	}
+
+	// reload switches to the targets of the changed config file. Targets
+	// are matched by ID, so unchanged ones keep running wherever they
+	// moved in the list; the others are started before the old ones are
+	// stopped, so that the data channel is never closed in between.
+	reload := func() {
+		logKey := manager.logKey()
+		cfg, err := config.LoadConfig(options.ConfigFile)
+		if err != nil {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Config reload failed", err.Error(), logKey)
+			return
+		}
+		for _, warning := range cfg.Warnings {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Discovery file skipped", warning.Error(), logKey)
+		}
+		if err := watcher.WatchFiles(slices.Concat(cfg.IncludePatterns, cfg.FileSDPatterns(options.ConfigFile))); err != nil {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Discovery file changes may not be noticed", err.Error(), logKey)
+		}
+		newTargets := cfg.FilterTargets(options.Only, options.Skip)
+		if len(newTargets) == 0 && len(cfg.FileSD) == 0 {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Config reload ignored", "No targets to monitor after filtering", logKey)
+			return
+		}
+
+		newRegistry := stats.NewTargetKeyRegistry(newTargets, options.Regions)
+		newState, err := stats.NewTargetState(newRegistry, newTargets, state)
+		if err != nil {
+			manager.logBuffer.AddLogEntry(LogLevelWarning, "Config reload failed", err.Error(), logKey)
+			return
+		}
+
+		oldTargets := make(map[string]config.Target, len(targets))
+		for i, target := range targets {
+			oldTargets[keyRegistry.GetTargetID(i)] = target
+		}
+		oldRunners := runners
+		targets, keyRegistry, state = newTargets, newRegistry, newState
+		runners = make(map[string]context.CancelFunc, len(targets))
+
+		started, stopped := 0, 0
+		for i, target := range targets {
+			id := keyRegistry.GetTargetID(i)
+			if oldTarget, exists := oldTargets[id]; exists && reflect.DeepEqual(oldTarget, target) {
+				runners[id] = oldRunners[id]
+				delete(oldRunners, id)
+				continue
+			}
+			startTarget(target, id, i)
+			started++
+		}
+		for _, stop := range oldRunners {
+			stop()
+			stopped++
+		}
+
+		manager.Reload(targets, state.Monitors)
+		metrics.RetainTargets(targets, options.Regions)
+		details := fmt.Sprintf("%d targets, %d started, %d stopped", len(targets), started, stopped)
+		manager.logBuffer.AddLogEntry(LogLevelInfo, "Config reloaded", details, manager.logKey())
+		ui.Render(manager.grid)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
 	uiRefreshTicker := time.NewTicker(1 * time.Second)
 	defer uiRefreshTicker.Stop()
 
@@ -158,7 +280,7 @@ func StartMonitoring(targets []config.Target, options Options) {
 						manager.NavigateLogs(1)
 						ui.Render(manager.grid)
 					} else {
-						manager.NavigateTargetKeys(1, monitors)
+						manager.NavigateTargetKeys(1, state.Monitors)
 					}
 				}
 			case "<Up>":
@@ -167,7 +289,7 @@ func StartMonitoring(targets []config.Target, options Options) {
 						manager.NavigateLogs(-1)
 						ui.Render(manager.grid)
 					} else {
-						manager.NavigateTargetKeys(-1, monitors)
+						manager.NavigateTargetKeys(-1, state.Monitors)
 					}
 				}
 			case "<Enter>":
@@ -198,8 +320,22 @@ func StartMonitoring(targets []config.Target, options Options) {
 					manager.ToggleLogsVisibility()
 				}
 				ui.Render(manager.grid)
+			case "i":
+				if manager.listWidget != nil && manager.listWidget.IsSearchMode() {
+					manager.listWidget.UpdateSearch("i")
+				} else {
+					manager.ToggleIncidentsVisibility()
+				}
+				ui.Render(manager.grid)
+			case "e":
+				if manager.listWidget != nil && manager.listWidget.IsSearchMode() {
+					manager.listWidget.UpdateSearch("e")
+				} else {
+					manager.ExportCurrentTarget()
+				}
+				ui.Render(manager.grid)
 			case "/":
-				if len(targets) > 1 && manager.listWidget != nil {
+				if len(manager.targets) > 1 && manager.listWidget != nil {
 					manager.listWidget.ToggleSearch()
 					if manager.listWidget.IsSearchMode() && manager.listWidget.OnSearchChange != nil {
 						indices := manager.listWidget.GetFilteredIndices()
@@ -237,14 +373,24 @@ func StartMonitoring(targets []config.Target, options Options) {
 			if !ok {
 				return
 			}
+			// Stopped targets may still deliver a check that was in flight,
+			// and targets kept across a reload may have moved.
+			key, exists := keyRegistry.Lookup(data.TargetID, data.TargetKey.Region)
+			if !exists {
+				continue
+			}
+			data.TargetKey = key
 			manager.UpdateTarget(data)
 
-			if options.PrometheusURL != "" {
+			if metrics.Enabled() {
 				region := ""
 				if !data.TargetKey.IsLocal {
 					region = data.TargetKey.Region
 				}
 				metrics.RecordCheck(data.Target, data.Result, region)
+				if data.Stats.HasApdex() {
+					metrics.RecordApdex(data.Target, data.Stats.ApdexScore, region)
+				}
 
 				if strings.HasPrefix(data.Target.URL, "https://") {
 					go func(target config.Target) {
@@ -255,13 +401,31 @@ func StartMonitoring(targets []config.Target, options Options) {
 				}
 			}
 
+		case <-reloads:
+			reload()
+
+		case <-sigChan:
+			cancel()
+			return
+
 		case <-uiRefreshTicker.C:
-			manager.RefreshStats(monitors)
+			manager.RefreshStats(state.Monitors)
 		}
 	}
 }
 
-func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int, monitors map[string]*stats.Monitor, sequences map[string]*int, alertStates map[string]*bool, webhookAlertStates map[string]*bool, dataChannel chan<- TargetData, options Options) {
+// detectAnomaly returns the anomaly flagged for result, if any, along with
+// the error from the anomaly webhook so it can surface in the logs panel.
+func detectAnomaly(target config.Target, monitor *stats.Monitor, result net.WebsiteCheckResult) (*stats.Anomaly, error) {
+	anomaly := monitor.DetectAnomaly(result)
+	if anomaly == nil || !anomaly.Onset || !config.BoolVal(target.AnomalyAlert, false) {
+		return anomaly, nil
+	}
+	return anomaly, notifications.HandleAnomalyWebhook(target.WebhookURL, target.WebhookHeaders, target.Name, target.URL, anomaly.ResponseTime, anomaly.Baseline)
+}
+
+func monitorTargetTUI(ctx context.Context, target config.Target, targetID string, targetIndex int, monitors map[string]*stats.Monitor, sequences map[string]*int, alertStates map[string]*bool, webhookAlertStates map[string]*bool, dataChannel chan<- TargetData, options Options) {
+	indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 	ticker := time.NewTicker(target.GetRefreshInterval())
 	defer ticker.Stop()
 
@@ -298,24 +462,24 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 						LastCheckTime: time.Now(),
 					}
 
-					indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 					targetKey := stats.NewRegionTargetKey(indexedName, lambdaResult.Region, targetIndex)
 					dataChannel <- TargetData{
 						Target:      target,
 						Result:      errorResult,
 						Stats:       stats.Stats{},
 						TargetKey:   targetKey,
+						TargetID:    targetID,
 						LambdaError: lambdaResult.Error,
 					}
 					continue
 				}
 
-				indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 				targetKey := stats.NewRegionTargetKey(indexedName, lambdaResult.Region, targetIndex)
 				targetKeyStr := targetKey.String()
 
 				if monitor, exists := monitors[targetKeyStr]; exists {
 					monitor.AddResult(lambdaResult.Result)
+					anomaly, anomalyErr := detectAnomaly(target, monitor, lambdaResult.Result)
 					if sequence, exists := sequences[targetKeyStr]; exists {
 						*sequence++
 					}
@@ -328,6 +492,7 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 									Result:     lambdaResult.Result,
 									Stats:      monitor.GetStats(),
 									TargetKey:  targetKey,
+									TargetID:   targetID,
 									AlertError: err,
 								}
 							}
@@ -353,6 +518,7 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 									Result:       lambdaResult.Result,
 									Stats:        stats.Stats{},
 									TargetKey:    targetKey,
+									TargetID:     targetID,
 									WebhookError: err,
 								}
 							}
@@ -361,21 +527,24 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 
 					stats := monitor.GetStats()
 					dataChannel <- TargetData{
-						Target:    target,
-						Result:    lambdaResult.Result,
-						Stats:     stats,
-						TargetKey: targetKey,
+						Target:       target,
+						Result:       lambdaResult.Result,
+						Stats:        stats,
+						TargetKey:    targetKey,
+						TargetID:     targetID,
+						Anomaly:      anomaly,
+						WebhookError: anomalyErr,
 					}
 				}
 			}
 		} else {
 			result := net.CheckWebsite(target.URL, netConfig)
-			indexedName := fmt.Sprintf("%s#%d", target.Name, targetIndex)
 			targetKey := stats.NewLocalTargetKey(indexedName, targetIndex)
 			targetKeyStr := targetKey.String()
 
 			if monitor, exists := monitors[targetKeyStr]; exists {
 				monitor.AddResult(result)
+				anomaly, anomalyErr := detectAnomaly(target, monitor, result)
 				if sequence, exists := sequences[targetKeyStr]; exists {
 					*sequence++
 				}
@@ -389,6 +558,7 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 								Result:     result,
 								Stats:      stats,
 								TargetKey:  targetKey,
+								TargetID:   targetID,
 								AlertError: err,
 							}
 						}
@@ -417,6 +587,7 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 								Result:       result,
 								Stats:        stats.Stats{},
 								TargetKey:    targetKey,
+								TargetID:     targetID,
 								WebhookError: err,
 							}
 						}
@@ -425,10 +596,13 @@ func monitorTargetTUI(ctx context.Context, target config.Target, targetIndex int
 
 				stats := monitor.GetStats()
 				dataChannel <- TargetData{
-					Target:    target,
-					Result:    result,
-					Stats:     stats,
-					TargetKey: targetKey,
+					Target:       target,
+					Result:       result,
+					Stats:        stats,
+					TargetKey:    targetKey,
+					TargetID:     targetID,
+					Anomaly:      anomaly,
+					WebhookError: anomalyErr,
 				}
 			}
 		}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Owloops/updo/config"
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 	"github.com/Owloops/updo/utils"
 	uw "github.com/Owloops/updo/widgets"
 
@@ -12,7 +14,11 @@ import (
 	"github.com/gizak/termui/v3/widgets"
 )
 
-const _recentLogsTitle = "Recent Logs"
+const (
+	_urlTitle        = "Monitoring URL"
+	_recentLogsTitle = "Recent Logs"
+	_incidentsTitle  = "Incidents"
+)
 
 type DetailsManager struct {
 	QuitWidget            *widgets.Paragraph
@@ -22,16 +28,19 @@ type DetailsManager struct {
 	MinResponseTimeWidget *widgets.Paragraph
 	MaxResponseTimeWidget *widgets.Paragraph
 	P95ResponseTimeWidget *widgets.Paragraph
+	ApdexWidget           *widgets.Paragraph
 	SSLOkWidget           *widgets.Paragraph
 	UptimePlot            *widgets.Plot
-	ResponseTimePlot      *widgets.Plot
+	ResponseTimePlot      *uw.AnomalyPlot
 	URLWidget             *widgets.Paragraph
 	RefreshWidget         *widgets.Paragraph
 	AssertionWidget       *widgets.Paragraph
 	TimingBreakdownWidget *uw.TimingBreakdown
 	LogsWidget            *widgets.Tree
+	IncidentsWidget       *widgets.List
 	NormalGrid            *ui.Grid
 	LogsGrid              *ui.Grid
+	IncidentsGrid         *ui.Grid
 	ActiveGrid            *ui.Grid
 }
 
@@ -42,7 +51,7 @@ func NewDetailsManager() *DetailsManager {
 func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Duration) {
 	m.QuitWidget = widgets.NewParagraph()
 	m.QuitWidget.Title = "Information"
-	m.QuitWidget.Text = "q:quit l:logs ↑↓:nav"
+	m.QuitWidget.Text = "q:quit l:logs i:incidents ↑↓:nav"
 	m.QuitWidget.BorderStyle.Fg = ui.ColorClear
 
 	m.UptimeWidget = widgets.NewParagraph()
@@ -75,6 +84,11 @@ func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Dura
 	m.P95ResponseTimeWidget.Text = _notAvailable
 	m.P95ResponseTimeWidget.BorderStyle.Fg = ui.ColorCyan
 
+	m.ApdexWidget = widgets.NewParagraph()
+	m.ApdexWidget.Title = "Apdex"
+	m.ApdexWidget.Text = _notAvailable
+	m.ApdexWidget.BorderStyle.Fg = ui.ColorCyan
+
 	m.SSLOkWidget = widgets.NewParagraph()
 	m.SSLOkWidget.Title = "SSL Certificate"
 	m.SSLOkWidget.Text = _notAvailable
@@ -88,7 +102,7 @@ func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Dura
 	m.UptimePlot.Data[0] = nil
 	m.UptimePlot.LineColors[0] = ui.ColorCyan
 
-	m.ResponseTimePlot = widgets.NewPlot()
+	m.ResponseTimePlot = uw.NewAnomalyPlot()
 	m.ResponseTimePlot.Title = "Response Time History"
 	m.ResponseTimePlot.Marker = widgets.MarkerBraille
 	m.ResponseTimePlot.BorderStyle.Fg = ui.ColorCyan
@@ -97,13 +111,13 @@ func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Dura
 	m.ResponseTimePlot.LineColors[0] = ui.ColorCyan
 
 	m.URLWidget = widgets.NewParagraph()
-	m.URLWidget.Title = "Monitoring URL"
-	m.URLWidget.Text = url
+	m.URLWidget.Title = _urlTitle
+	m.URLWidget.Text = redact.URL(url)
 	m.URLWidget.BorderStyle.Fg = ui.ColorBlue
 
 	m.RefreshWidget = widgets.NewParagraph()
 	m.RefreshWidget.Title = "Refresh Interval"
-	m.RefreshWidget.Text = fmt.Sprintf("%v seconds", refreshInterval.Seconds())
+	m.RefreshWidget.Text = config.FormatDuration(refreshInterval)
 	m.RefreshWidget.BorderStyle.Fg = ui.ColorBlue
 
 	m.AssertionWidget = widgets.NewParagraph()
@@ -123,6 +137,14 @@ func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Dura
 	m.LogsWidget.TextStyle.Fg = ui.ColorWhite
 	m.LogsWidget.SelectedRowStyle = ui.NewStyle(ui.ColorBlack, ui.ColorMagenta, ui.ModifierBold)
 
+	m.IncidentsWidget = widgets.NewList()
+	m.IncidentsWidget.Title = _incidentsTitle
+	m.IncidentsWidget.BorderStyle.Fg = ui.ColorRed
+	m.IncidentsWidget.TitleStyle.Fg = ui.ColorWhite
+	m.IncidentsWidget.TitleStyle.Modifier = ui.ModifierBold
+	m.IncidentsWidget.TextStyle.Fg = ui.ColorWhite
+	m.IncidentsWidget.Rows = []string{_noIncidents}
+
 	termWidth, termHeight := ui.TerminalDimensions()
 
 	m.NormalGrid = ui.NewGrid()
@@ -131,11 +153,24 @@ func (m *DetailsManager) InitializeWidgets(url string, refreshInterval time.Dura
 
 	m.LogsGrid = ui.NewGrid()
 	m.LogsGrid.SetRect(0, 0, termWidth, termHeight)
-	m.setupLogsGrid()
+	m.setupPanelGrid(m.LogsGrid, m.LogsWidget)
+
+	m.IncidentsGrid = ui.NewGrid()
+	m.IncidentsGrid.SetRect(0, 0, termWidth, termHeight)
+	m.setupPanelGrid(m.IncidentsGrid, m.IncidentsWidget)
 
 	m.ActiveGrid = m.NormalGrid
 }
 
+// SetTarget shows the URL of target and the config file it comes from.
+func (m *DetailsManager) SetTarget(target config.Target) {
+	m.URLWidget.Text = redact.URL(target.URL)
+	m.URLWidget.Title = _urlTitle
+	if target.Source != "" {
+		m.URLWidget.Title = fmt.Sprintf("%s (%s)", _urlTitle, target.Source)
+	}
+}
+
 func (m *DetailsManager) setupNormalGrid() {
 	m.NormalGrid.Set(
 		ui.NewRow(1.0/7,
@@ -156,13 +191,14 @@ func (m *DetailsManager) setupNormalGrid() {
 			),
 			ui.NewCol(2.0/5,
 				ui.NewRow(0.5,
-					ui.NewCol(1.0/2,
-						ui.NewRow(0.5, m.MinResponseTimeWidget),
-						ui.NewRow(0.5, m.AvgResponseTimeWidget),
+					ui.NewRow(0.5,
+						ui.NewCol(1.0/3, m.MinResponseTimeWidget),
+						ui.NewCol(1.0/3, m.AvgResponseTimeWidget),
+						ui.NewCol(1.0/3, m.MaxResponseTimeWidget),
 					),
-					ui.NewCol(1.0/2,
-						ui.NewRow(0.5, m.MaxResponseTimeWidget),
-						ui.NewRow(0.5, m.P95ResponseTimeWidget),
+					ui.NewRow(0.5,
+						ui.NewCol(1.0/2, m.P95ResponseTimeWidget),
+						ui.NewCol(1.0/2, m.ApdexWidget),
 					),
 				),
 				ui.NewRow(0.5, m.TimingBreakdownWidget),
@@ -171,8 +207,9 @@ func (m *DetailsManager) setupNormalGrid() {
 	)
 }
 
-func (m *DetailsManager) setupLogsGrid() {
-	m.LogsGrid.Set(
+// setupPanelGrid lays out the details view with panel docked below it.
+func (m *DetailsManager) setupPanelGrid(grid *ui.Grid, panel ui.Drawable) {
+	grid.Set(
 		ui.NewRow(0.1,
 			ui.NewCol(1.0/4, m.URLWidget),
 			ui.NewCol(1.0/4, m.RefreshWidget),
@@ -191,19 +228,20 @@ func (m *DetailsManager) setupLogsGrid() {
 			),
 			ui.NewCol(2.0/5,
 				ui.NewRow(0.5,
-					ui.NewCol(1.0/2,
-						ui.NewRow(0.5, m.MinResponseTimeWidget),
-						ui.NewRow(0.5, m.AvgResponseTimeWidget),
+					ui.NewRow(0.5,
+						ui.NewCol(1.0/3, m.MinResponseTimeWidget),
+						ui.NewCol(1.0/3, m.AvgResponseTimeWidget),
+						ui.NewCol(1.0/3, m.MaxResponseTimeWidget),
 					),
-					ui.NewCol(1.0/2,
-						ui.NewRow(0.5, m.MaxResponseTimeWidget),
-						ui.NewRow(0.5, m.P95ResponseTimeWidget),
+					ui.NewRow(0.5,
+						ui.NewCol(1.0/2, m.P95ResponseTimeWidget),
+						ui.NewCol(1.0/2, m.ApdexWidget),
 					),
 				),
 				ui.NewRow(0.5, m.TimingBreakdownWidget),
 			),
 		),
-		ui.NewRow(0.3, m.LogsWidget),
+		ui.NewRow(0.3, panel),
 	)
 }
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Owloops/updo/net"
+	uw "github.com/Owloops/updo/widgets"
 	"github.com/gizak/termui/v3/widgets"
 )
 
@@ -18,7 +19,7 @@ func TestDetailsManager_Creation(t *testing.T) {
 func TestDetailsManager_UpdatePlotsData(t *testing.T) {
 	dm := NewDetailsManager()
 	dm.UptimePlot = &widgets.Plot{Data: [][]float64{{}}}
-	dm.ResponseTimePlot = &widgets.Plot{Data: [][]float64{{}}}
+	dm.ResponseTimePlot = &uw.AnomalyPlot{Plot: widgets.Plot{Data: [][]float64{{}}}}
 
 	tests := []struct {
 		name   string
@@ -78,7 +79,7 @@ func TestDetailsManager_UpdatePlotsData(t *testing.T) {
 func TestDetailsManager_DataTruncation(t *testing.T) {
 	dm := NewDetailsManager()
 	dm.UptimePlot = &widgets.Plot{Data: [][]float64{{}}}
-	dm.ResponseTimePlot = &widgets.Plot{Data: [][]float64{{}}}
+	dm.ResponseTimePlot = &uw.AnomalyPlot{Plot: widgets.Plot{Data: [][]float64{{}}}}
 
 	width := 20
 	maxLength := width / 2
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	stdnet "net"
+	"strings"
+	"sync"
+)
+
+const (
+	_journalSocket = "/run/systemd/journal/socket"
+	// _journalMaxMessage keeps entries within a single datagram. Longer
+	// messages, such as checks with large bodies, are truncated.
+	_journalMaxMessage  = 128 << 10
+	_journalFieldPrefix = "UPDO_"
+)
+
+// journaldOutput writes entries over the native journal protocol, with
+// the line fields as UPDO_* fields.
+type journaldOutput struct {
+	identifier string
+
+	mu   sync.Mutex
+	conn *stdnet.UnixConn
+}
+
+func NewJournaldOutput(identifier string) (LineOutput, error) {
+	conn, err := stdnet.DialUnix("unixgram", nil, &stdnet.UnixAddr{Name: _journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journaldOutput{identifier: identifier, conn: conn}, nil
+}
+
+func (j *journaldOutput) WriteLine(severity Severity, line string, fields map[string]string) error {
+	entry := encodeJournalEntry(j.identifier, severity, line, fields)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err := j.conn.Write(entry)
+	return err
+}
+
+func (j *journaldOutput) Close() error {
+	return j.conn.Close()
+}
+
+func encodeJournalEntry(identifier string, severity Severity, line string, fields map[string]string) []byte {
+	if len(line) > _journalMaxMessage {
+		line = line[:_journalMaxMessage] + "..."
+	}
+
+	var entry bytes.Buffer
+	writeJournalField(&entry, "MESSAGE", line)
+	writeJournalField(&entry, "PRIORITY", fmt.Sprint(int(severity)))
+	writeJournalField(&entry, "SYSLOG_IDENTIFIER", identifier)
+	for name, value := range fields {
+		writeJournalField(&entry, journalFieldName(name), value)
+	}
+	return entry.Bytes()
+}
+
+// writeJournalField uses KEY=value, or the length prefixed form for values
+// spanning several lines.
+func writeJournalField(entry *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(entry, "%s=%s\n", name, value)
+		return
+	}
+
+	entry.WriteString(name)
+	entry.WriteByte('\n')
+	_ = binary.Write(entry, binary.LittleEndian, uint64(len(value)))
+	entry.WriteString(value)
+	entry.WriteByte('\n')
+}
+
+// journalFieldName maps a field to UPDO_NAME. Journal field names only
+// allow upper case letters, digits and underscores.
+func journalFieldName(name string) string {
+	return _journalFieldPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -1,14 +1,14 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/Owloops/updo/net"
+	"github.com/Owloops/updo/redact"
 	"github.com/Owloops/updo/stats"
 )
 
@@ -17,40 +17,108 @@ const (
 	_logLevelWarning = "warning"
 )
 
-func encodeAndPrint(data interface{}, writer io.Writer) {
-	var buf strings.Builder
-	encoder := json.NewEncoder(&buf)
-	encoder.SetEscapeHTML(false)
+// Record is a printed log record along with the fields log shippers
+// group it by.
+type Record struct {
+	Type      string
+	Timestamp time.Time
+	URL       string
+	Region    string
+	Labels    map[string]string
+	Severity  Severity
+	// Line is the JSON encoded record without the trailing newline.
+	Line []byte
+}
+
+var (
+	_recordHookMu sync.RWMutex
+	_recordHook   func(Record)
+)
+
+// SetRecordHook passes every record printed by the Log* functions to hook
+// as well. A nil hook disables it.
+func SetRecordHook(hook func(Record)) {
+	_recordHookMu.Lock()
+	defer _recordHookMu.Unlock()
+	_recordHook = hook
+}
 
-	if err := encoder.Encode(data); err != nil {
+func encodeAndPrint(data interface{}, record Record, writer io.Writer) {
+	jsonLine, line, err := currentSchema().encode(data)
+	if err != nil {
 		return
 	}
-	_, _ = fmt.Fprint(writer, buf.String())
+
+	if output := currentOutput(); output != nil {
+		if err := output.WriteLine(record.Severity, line, record.Fields()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write log output: %v\n", err)
+		}
+	} else {
+		_, _ = fmt.Fprintln(writer, line)
+	}
+
+	_recordHookMu.RLock()
+	hook := _recordHook
+	_recordHookMu.RUnlock()
+
+	if hook != nil {
+		record.Line = []byte(jsonLine)
+		hook(record)
+	}
 }
 
 type MetricsData struct {
-	Type           string    `json:"type"`
-	Timestamp      time.Time `json:"timestamp"`
-	URL            string    `json:"url"`
-	Region         string    `json:"region,omitempty"`
-	Uptime         float64   `json:"uptime"`
-	AvgResponseMS  int64     `json:"avg_response_time_ms"`
-	MinResponseMS  int64     `json:"min_response_time_ms"`
-	MaxResponseMS  int64     `json:"max_response_time_ms"`
-	P95ResponseMS  int64     `json:"p95_response_time_ms,omitempty"`
-	ChecksCount    int       `json:"checks_count"`
-	SuccessCount   int       `json:"success_count"`
-	SuccessPercent float64   `json:"success_percent"`
+	Type           string            `json:"type"`
+	Timestamp      time.Time         `json:"timestamp"`
+	URL            string            `json:"url"`
+	Region         string            `json:"region,omitempty"`
+	Uptime         float64           `json:"uptime"`
+	AvgResponseMS  int64             `json:"avg_response_time_ms"`
+	MinResponseMS  int64             `json:"min_response_time_ms"`
+	MaxResponseMS  int64             `json:"max_response_time_ms"`
+	P95ResponseMS  int64             `json:"p95_response_time_ms,omitempty"`
+	ApdexScore     *float64          `json:"apdex_score,omitempty"`
+	ChecksCount    int               `json:"checks_count"`
+	SuccessCount   int               `json:"success_count"`
+	SuccessPercent float64           `json:"success_percent"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+type AnomalyData struct {
+	Type           string            `json:"type"`
+	Timestamp      time.Time         `json:"timestamp"`
+	URL            string            `json:"url"`
+	Region         string            `json:"region,omitempty"`
+	ResponseTimeMS int64             `json:"response_time_ms"`
+	BaselineMS     int64             `json:"baseline_response_time_ms"`
+	StdDevMS       int64             `json:"stddev_ms"`
+	ZScore         float64           `json:"z_score"`
+	Onset          bool              `json:"onset"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+type IncidentData struct {
+	Type       string            `json:"type"`
+	Timestamp  time.Time         `json:"timestamp"`
+	URL        string            `json:"url"`
+	Status     string            `json:"status"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end,omitzero"`
+	DurationMS int64             `json:"duration_ms"`
+	FirstError string            `json:"first_error,omitempty"`
+	Regions    []string          `json:"regions,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
 }
 
 type ErrorData struct {
-	Type      string    `json:"type"`
-	Timestamp time.Time `json:"timestamp"`
-	URL       string    `json:"url"`
-	Region    string    `json:"region,omitempty"`
-	Level     string    `json:"level"`
-	Message   string    `json:"message"`
-	Error     string    `json:"error,omitempty"`
+	Type      string            `json:"type"`
+	Timestamp time.Time         `json:"timestamp"`
+	URL       string            `json:"url"`
+	Region    string            `json:"region,omitempty"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	Error     string            `json:"error,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
 }
 
 type CheckData struct {
@@ -70,9 +138,10 @@ type CheckData struct {
 	ResponseBody    string              `json:"response_body,omitempty"`
 	AssertionPassed bool                `json:"assertion_passed,omitempty"`
 	AssertionText   string              `json:"assertion_text,omitempty"`
+	Labels          map[string]string   `json:"labels,omitempty"`
 }
 
-func LogMetrics(stats *stats.Stats, url string, region ...string) {
+func LogMetrics(stats *stats.Stats, url string, labels map[string]string, region ...string) {
 	if stats == nil {
 		return
 	}
@@ -80,7 +149,7 @@ func LogMetrics(stats *stats.Stats, url string, region ...string) {
 	data := MetricsData{
 		Type:           "metrics",
 		Timestamp:      time.Now(),
-		URL:            url,
+		URL:            redact.URL(url),
 		Uptime:         stats.UptimePercent,
 		AvgResponseMS:  stats.AvgResponseTime.Milliseconds(),
 		MinResponseMS:  stats.MinResponseTime.Milliseconds(),
@@ -88,6 +157,7 @@ func LogMetrics(stats *stats.Stats, url string, region ...string) {
 		ChecksCount:    stats.ChecksCount,
 		SuccessCount:   stats.SuccessCount,
 		SuccessPercent: 0,
+		Labels:         labels,
 	}
 
 	if len(region) > 0 && region[0] != "" {
@@ -102,14 +172,18 @@ func LogMetrics(stats *stats.Stats, url string, region ...string) {
 		data.P95ResponseMS = stats.P95.Milliseconds()
 	}
 
-	encodeAndPrint(data, os.Stdout)
+	if stats.HasApdex() {
+		data.ApdexScore = &stats.ApdexScore
+	}
+
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Region: data.Region, Labels: data.Labels, Severity: SeverityInfo}, os.Stdout)
 }
 
-func LogCheck(result net.WebsiteCheckResult, seq int, jsonFormat string, region ...string) {
+func LogCheck(result net.WebsiteCheckResult, seq int, jsonFormat string, labels map[string]string, region ...string) {
 	data := CheckData{
 		Type:            "check",
 		Timestamp:       result.LastCheckTime,
-		URL:             result.URL,
+		URL:             redact.URL(result.URL),
 		ResolvedIP:      result.ResolvedIP,
 		StatusCode:      result.StatusCode,
 		ResponseTimeMS:  result.ResponseTime.Milliseconds(),
@@ -118,26 +192,86 @@ func LogCheck(result net.WebsiteCheckResult, seq int, jsonFormat string, region
 		SequenceNum:     seq,
 		AssertionPassed: result.AssertionPassed,
 		AssertionText:   result.AssertText,
-		RequestHeaders:  result.RequestHeaders,
-		ResponseHeaders: result.ResponseHeaders,
-		RequestBody:     result.RequestBody,
-		ResponseBody:    result.ResponseBody,
+		RequestHeaders:  redact.Headers(result.RequestHeaders),
+		ResponseHeaders: redact.Headers(result.ResponseHeaders),
+		RequestBody:     redact.Body(result.RequestBody),
+		ResponseBody:    redact.Body(result.ResponseBody),
+		Labels:          labels,
+	}
+
+	if len(region) > 0 && region[0] != "" {
+		data.Region = region[0]
+	}
+
+	currentSchema().redactCheck(&data)
+
+	severity := SeverityInfo
+	if !result.IsUp {
+		severity = SeverityError
+	}
+
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Region: data.Region, Labels: data.Labels, Severity: severity}, os.Stdout)
+}
+
+func LogAnomaly(anomaly *stats.Anomaly, url string, labels map[string]string, region ...string) {
+	if anomaly == nil {
+		return
+	}
+
+	data := AnomalyData{
+		Type:           "anomaly",
+		Timestamp:      anomaly.Timestamp,
+		URL:            redact.URL(url),
+		ResponseTimeMS: anomaly.ResponseTime.Milliseconds(),
+		BaselineMS:     anomaly.Baseline.Milliseconds(),
+		StdDevMS:       anomaly.StdDev.Milliseconds(),
+		ZScore:         anomaly.ZScore,
+		Onset:          anomaly.Onset,
+		Labels:         labels,
 	}
 
 	if len(region) > 0 && region[0] != "" {
 		data.Region = region[0]
 	}
 
-	encodeAndPrint(data, os.Stdout)
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Region: data.Region, Labels: data.Labels, Severity: SeverityWarning}, os.Stdout)
+}
+
+func LogIncident(incident *stats.Incident, url string, labels map[string]string) {
+	if incident == nil {
+		return
+	}
+
+	now := time.Now()
+	data := IncidentData{
+		Type:       "incident",
+		Timestamp:  now,
+		URL:        redact.URL(url),
+		Status:     "resolved",
+		Start:      incident.Start,
+		End:        incident.End,
+		DurationMS: incident.Duration(now).Milliseconds(),
+		FirstError: redact.Text(incident.FirstError),
+		Regions:    incident.Regions,
+		Labels:     labels,
+	}
+
+	severity := SeverityNotice
+	if incident.IsOngoing() {
+		data.Status = "ongoing"
+		severity = SeverityError
+	}
+
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Labels: data.Labels, Severity: severity}, os.Stdout)
 }
 
 func LogError(url string, msg string, err error, region ...string) {
 	data := ErrorData{
 		Type:      _logLevelError,
 		Timestamp: time.Now(),
-		URL:       url,
+		URL:       redact.URL(url),
 		Level:     _logLevelError,
-		Message:   msg,
+		Message:   redact.Text(msg),
 	}
 
 	if len(region) > 0 && region[0] != "" {
@@ -145,24 +279,25 @@ func LogError(url string, msg string, err error, region ...string) {
 	}
 
 	if err != nil {
-		data.Error = err.Error()
+		data.Error = redact.Text(err.Error())
 	}
 
-	encodeAndPrint(data, os.Stderr)
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Region: data.Region, Labels: data.Labels, Severity: SeverityError}, os.Stderr)
 }
 
-func LogWarning(url string, msg string, region ...string) {
+func LogWarning(url string, msg string, labels map[string]string, region ...string) {
 	data := ErrorData{
 		Type:      _logLevelWarning,
 		Timestamp: time.Now(),
-		URL:       url,
+		URL:       redact.URL(url),
 		Level:     _logLevelWarning,
-		Message:   msg,
+		Message:   redact.Text(msg),
+		Labels:    labels,
 	}
 
 	if len(region) > 0 && region[0] != "" {
 		data.Region = region[0]
 	}
 
-	encodeAndPrint(data, os.Stderr)
+	encodeAndPrint(data, Record{Type: data.Type, Timestamp: data.Timestamp, URL: data.URL, Region: data.Region, Labels: data.Labels, Severity: SeverityWarning}, os.Stderr)
 }
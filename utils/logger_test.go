@@ -31,7 +31,7 @@ func TestLogMetrics(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.stats == nil {
-				LogMetrics(tt.stats, tt.url, tt.region...)
+				LogMetrics(tt.stats, tt.url, nil, tt.region...)
 				return
 			}
 
@@ -42,7 +42,7 @@ func TestLogMetrics(t *testing.T) {
 			}
 			os.Stdout = w
 
-			LogMetrics(tt.stats, tt.url, tt.region...)
+			LogMetrics(tt.stats, tt.url, nil, tt.region...)
 
 			err = w.Close()
 			if err != nil {
@@ -97,11 +97,15 @@ func TestLogCheck(t *testing.T) {
 		Method:     "GET",
 	}
 
+	var shipped []Record
+	SetRecordHook(func(record Record) { shipped = append(shipped, record) })
+	defer SetRecordHook(nil)
+
 	oldStdout := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	LogCheck(result, 1, "json", "us-east-1")
+	LogCheck(result, 1, "json", map[string]string{"team": "payments"}, "us-east-1")
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -124,6 +128,16 @@ func TestLogCheck(t *testing.T) {
 	if parsed["region"] != "us-east-1" {
 		t.Errorf("Expected region=us-east-1, got %v", parsed["region"])
 	}
+	if labels, ok := parsed["labels"].(map[string]interface{}); !ok || labels["team"] != "payments" {
+		t.Errorf("Expected labels.team=payments, got %v", parsed["labels"])
+	}
+
+	if len(shipped) != 1 {
+		t.Fatalf("Expected the record to be passed to the hook once, got %d", len(shipped))
+	}
+	if got := shipped[0]; got.Type != "check" || got.Region != "us-east-1" || got.Labels["team"] != "payments" || string(got.Line)+"\n" != output {
+		t.Errorf("Unexpected hooked record %+v", got)
+	}
 }
 
 func TestLogError(t *testing.T) {
@@ -173,7 +187,7 @@ func TestLogWarning(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stderr = w
 
-	LogWarning("https://example.com", "test warning", "us-east-1")
+	LogWarning("https://example.com", "test warning", nil, "us-east-1")
 
 	_ = w.Close()
 	os.Stderr = oldStderr
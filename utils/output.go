@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Owloops/updo/redact"
+)
+
+// Severity is a syslog severity level as defined by RFC 5424, which
+// journald uses as its PRIORITY as well.
+type Severity int
+
+const (
+	SeverityError   Severity = 3
+	SeverityWarning Severity = 4
+	SeverityNotice  Severity = 5
+	SeverityInfo    Severity = 6
+)
+
+const (
+	OutputStdout   = "stdout"
+	OutputSyslog   = "syslog"
+	OutputJournald = "journald"
+)
+
+// LineOutput receives complete output lines in place of stdout and stderr,
+// along with fields describing them such as the target URL.
+type LineOutput interface {
+	WriteLine(severity Severity, line string, fields map[string]string) error
+	Close() error
+}
+
+var (
+	_outputMu sync.RWMutex
+	_output   LineOutput
+)
+
+// SetOutput sends everything printed through this package to output. A nil
+// output restores stdout and stderr.
+func SetOutput(output LineOutput) {
+	_outputMu.Lock()
+	defer _outputMu.Unlock()
+	_output = output
+}
+
+func currentOutput() LineOutput {
+	_outputMu.RLock()
+	defer _outputMu.RUnlock()
+	return _output
+}
+
+// OpenOutput connects to the output named by spec: "journald", "syslog"
+// for the local syslog socket, or a syslog address such as udp://host:514,
+// tcp://host:601 or unix:///dev/log. It returns nil for stdout.
+func OpenOutput(spec, facility, appName string) (LineOutput, error) {
+	if appName == "" {
+		appName = "updo"
+	}
+
+	switch spec {
+	case "", OutputStdout:
+		return nil, nil
+	case OutputJournald:
+		return NewJournaldOutput(appName)
+	case OutputSyslog:
+		return NewSyslogOutput("", facility, appName)
+	default:
+		if !strings.Contains(spec, "://") {
+			return nil, fmt.Errorf("unknown log output %q, expected %s, %s, %s or a syslog address", spec, OutputStdout, OutputSyslog, OutputJournald)
+		}
+		return NewSyslogOutput(spec, facility, appName)
+	}
+}
+
+// PrintLine writes a line of text output with severity and fields, or
+// prints it to stdout if no output is set.
+func PrintLine(severity Severity, line string, fields map[string]string) {
+	output := currentOutput()
+	if output == nil {
+		fmt.Println(line)
+		return
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if err := output.WriteLine(severity, line, fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write log output: %v\n", err)
+	}
+}
+
+// Fields describes the record's target for system loggers.
+func (r Record) Fields() map[string]string {
+	fields := maps.Clone(r.Labels)
+	if fields == nil {
+		fields = make(map[string]string, 3)
+	}
+	fields["type"] = r.Type
+	if r.URL != "" {
+		fields["url"] = redact.URL(r.URL)
+	}
+	if r.Region != "" {
+		fields["region"] = r.Region
+	}
+	return fields
+}
+
+// lineWriter splits writes into lines and passes them to PrintLine, so
+// code printing with fmt.Fprintf can write to the configured output.
+type lineWriter struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	severity func(line string) Severity
+}
+
+// NewOutputWriter returns a writer printing each complete line with
+// severity.
+func NewOutputWriter(severity Severity) io.Writer {
+	return &lineWriter{severity: func(string) Severity { return severity }}
+}
+
+// NewLogWriter returns a writer for the standard library logger. Lines
+// tagged [ERROR] are errors, anything else a warning.
+func NewLogWriter() io.Writer {
+	return &lineWriter{severity: func(line string) Severity {
+		if strings.Contains(line, "[ERROR]") {
+			return SeverityError
+		}
+		return SeverityWarning
+	}}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		index := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+		line := string(w.buf.Next(index + 1))
+		line = strings.TrimSuffix(line, "\n")
+		PrintLine(w.severity(line), line, nil)
+	}
+}
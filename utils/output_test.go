@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	stdnet "net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type capturedLine struct {
+	severity Severity
+	line     string
+	fields   map[string]string
+}
+
+type captureOutput struct {
+	mu    sync.Mutex
+	lines []capturedLine
+}
+
+func (c *captureOutput) WriteLine(severity Severity, line string, fields map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, capturedLine{severity, line, fields})
+	return nil
+}
+
+func (c *captureOutput) Close() error { return nil }
+
+func TestSyslogFormat(t *testing.T) {
+	output := &syslogOutput{facility: 3, appName: "updo", hostname: "host"}
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	fields := map[string]string{"type": "check", "url": "https://example.com", "team": `a"b]`}
+
+	got := output.format(SeverityError, timestamp, "down", fields)
+	want := fmt.Sprintf(`<27>1 2024-01-02T03:04:05.000006Z host updo %d check [updo@32473 team="a\"b\]" type="check" url="https://example.com"] down`, os.Getpid())
+	if got != want {
+		t.Errorf("format() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := output.format(SeverityInfo, timestamp, "up", nil); !strings.HasSuffix(got, " - - up") {
+		t.Errorf("Expected nil values for MSGID and SD, got %s", got)
+	}
+}
+
+func TestSyslogOutput_UDP(t *testing.T) {
+	conn, err := stdnet.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP not available: %v", err)
+	}
+	defer conn.Close()
+
+	output, err := OpenOutput("udp://"+conn.LocalAddr().String(), "local0", "")
+	if err != nil {
+		t.Fatalf("OpenOutput failed: %v", err)
+	}
+	defer output.Close()
+
+	if err := output.WriteLine(SeverityWarning, "slow", map[string]string{"type": "anomaly"}); err != nil {
+		t.Fatalf("WriteLine failed: %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if !regexp.MustCompile(`^<132>1 \S+ \S+ updo \d+ anomaly \[updo@32473 type="anomaly"\] slow$`).Match(buf[:n]) {
+		t.Errorf("Unexpected datagram %q", buf[:n])
+	}
+}
+
+func TestSyslogOutput_TCPFraming(t *testing.T) {
+	listener, err := stdnet.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("TCP not available: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for range 2 {
+			var length int
+			if _, err := fmt.Fscanf(reader, "%d ", &length); err != nil {
+				return
+			}
+			message := make([]byte, length)
+			if _, err := reader.Read(message); err != nil {
+				return
+			}
+			received <- string(message)
+		}
+	}()
+
+	output, err := OpenOutput("tcp://"+listener.Addr().String(), "", "")
+	if err != nil {
+		t.Fatalf("OpenOutput failed: %v", err)
+	}
+	defer output.Close()
+
+	for _, line := range []string{"first", "second line"} {
+		if err := output.WriteLine(SeverityInfo, line, nil); err != nil {
+			t.Fatalf("WriteLine failed: %v", err)
+		}
+	}
+	for _, want := range []string{"first", "second line"} {
+		select {
+		case message := <-received:
+			if !strings.HasPrefix(message, "<30>1 ") || !strings.HasSuffix(message, " - - "+want) {
+				t.Errorf("Unexpected message %q", message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+}
+
+func TestOpenOutput_Invalid(t *testing.T) {
+	for _, spec := range []string{"file.log", "http://localhost:514"} {
+		if _, err := OpenOutput(spec, "", ""); err == nil {
+			t.Errorf("OpenOutput(%q) should fail", spec)
+		}
+	}
+	if _, err := OpenOutput("syslog", "local9", ""); err == nil {
+		t.Error("Unknown facility should fail")
+	}
+	if output, err := OpenOutput(OutputStdout, "", ""); output != nil || err != nil {
+		t.Errorf("stdout should not open an output, got %v, %v", output, err)
+	}
+}
+
+func TestEncodeJournalEntry(t *testing.T) {
+	entry := encodeJournalEntry("updo", SeverityError, "line one\nline two", map[string]string{"url": "https://example.com", "team-name": "ops"})
+
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	_ = binary.Write(&want, binary.LittleEndian, uint64(len("line one\nline two")))
+	want.WriteString("line one\nline two\n")
+	want.WriteString("PRIORITY=3\nSYSLOG_IDENTIFIER=updo\n")
+	if !bytes.HasPrefix(entry, want.Bytes()) {
+		t.Errorf("Unexpected entry header %q", entry)
+	}
+	for _, field := range []string{"UPDO_URL=https://example.com\n", "UPDO_TEAM_NAME=ops\n"} {
+		if !bytes.Contains(entry, []byte(field)) {
+			t.Errorf("Expected %q in entry %q", field, entry)
+		}
+	}
+}
+
+func TestOutputRouting(t *testing.T) {
+	capture := &captureOutput{}
+	SetOutput(capture)
+	defer SetOutput(nil)
+
+	writer := NewOutputWriter(SeverityInfo)
+	fmt.Fprintf(writer, "\n--- statistics ---\n")
+	fmt.Fprintf(writer, "response time %d ms", 10)
+	fmt.Fprintln(writer, ", apdex: 1.00")
+
+	logWriter := NewLogWriter()
+	fmt.Fprintln(logWriter, "[ERROR] webhook failed")
+
+	LogWarning("https://example.com", "Request failed", map[string]string{"team": "ops"}, "eu-west-1")
+
+	want := []capturedLine{
+		{SeverityInfo, "--- statistics ---", nil},
+		{SeverityInfo, "response time 10 ms, apdex: 1.00", nil},
+		{SeverityError, "[ERROR] webhook failed", nil},
+	}
+	if len(capture.lines) != len(want)+1 {
+		t.Fatalf("Expected %d lines, got %+v", len(want)+1, capture.lines)
+	}
+	for i, line := range want {
+		if capture.lines[i].severity != line.severity || capture.lines[i].line != line.line {
+			t.Errorf("Line %d = %+v, want %+v", i, capture.lines[i], line)
+		}
+	}
+
+	record := capture.lines[len(want)]
+	if record.severity != SeverityWarning || !strings.HasPrefix(record.line, `{"type":"warning"`) {
+		t.Errorf("Unexpected warning record %+v", record)
+	}
+	if record.fields["team"] != "ops" || record.fields["region"] != "eu-west-1" || record.fields["type"] != "warning" {
+		t.Errorf("Unexpected warning fields %v", record.fields)
+	}
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Owloops/updo/redact"
+)
+
+const (
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+)
+
+const _truncatedSuffix = "...(truncated)"
+
+// _alwaysLoggedFields identify a record and cannot be excluded.
+var _alwaysLoggedFields = []string{"type", "timestamp"}
+
+// LogSchema controls which fields the Log* functions write and how. The
+// zero value writes every field as JSON. Its redaction applies on top of
+// the redact package, for values that only need masking in logs.
+type LogSchema struct {
+	Format string
+	// include and exclude hold field names, at most one of them is set.
+	include       []string
+	exclude       []string
+	redactor      *redact.Redactor
+	maxBodyLength int
+}
+
+var (
+	_schemaMu sync.RWMutex
+	_schema   LogSchema
+)
+
+// NewLogSchema validates the options of a LogSchema. fields lists the
+// fields to include, or the ones to leave out when prefixed with "-".
+// redactBody holds regular expressions whose matches are masked in request
+// and response bodies, which are cut to maxBodyLength bytes if positive.
+func NewLogSchema(format string, fields, redactHeaders, redactBody []string, maxBodyLength int) (LogSchema, error) {
+	schema := LogSchema{
+		Format:        format,
+		maxBodyLength: maxBodyLength,
+	}
+
+	switch format {
+	case "":
+		schema.Format = LogFormatJSON
+	case LogFormatJSON, LogFormatLogfmt:
+	default:
+		return LogSchema{}, fmt.Errorf("unknown log format %q, expected %s or %s", format, LogFormatJSON, LogFormatLogfmt)
+	}
+
+	known := knownLogFields()
+	for _, field := range fields {
+		name, excluded := strings.CutPrefix(strings.TrimSpace(field), "-")
+		if !slices.Contains(known, name) {
+			return LogSchema{}, fmt.Errorf("unknown log field %q", name)
+		}
+		if excluded {
+			schema.exclude = append(schema.exclude, name)
+		} else {
+			schema.include = append(schema.include, name)
+		}
+	}
+	if len(schema.include) > 0 && len(schema.exclude) > 0 {
+		return LogSchema{}, fmt.Errorf("log fields must either all be included or all be excluded with -")
+	}
+
+	if len(redactHeaders) > 0 || len(redactBody) > 0 {
+		redactor, err := redact.New(redactHeaders, nil, redactBody, true)
+		if err != nil {
+			return LogSchema{}, err
+		}
+		schema.redactor = redactor
+	}
+
+	return schema, nil
+}
+
+// SetLogSchema applies schema to every record written afterwards.
+func SetLogSchema(schema LogSchema) {
+	_schemaMu.Lock()
+	defer _schemaMu.Unlock()
+	_schema = schema
+}
+
+func currentSchema() LogSchema {
+	_schemaMu.RLock()
+	defer _schemaMu.RUnlock()
+	return _schema
+}
+
+// knownLogFields lists the JSON names of every record field.
+func knownLogFields() []string {
+	var fields []string
+	for _, record := range []any{MetricsData{}, AnomalyData{}, IncidentData{}, ErrorData{}, CheckData{}} {
+		recordType := reflect.TypeOf(record)
+		for i := range recordType.NumField() {
+			name, _, _ := strings.Cut(recordType.Field(i).Tag.Get("json"), ",")
+			if !slices.Contains(fields, name) {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
+
+func (s LogSchema) keep(field string) bool {
+	switch {
+	case slices.Contains(_alwaysLoggedFields, field):
+		return true
+	case len(s.include) > 0:
+		return slices.Contains(s.include, field)
+	default:
+		return !slices.Contains(s.exclude, field)
+	}
+}
+
+// redactCheck masks sensitive headers and body contents and truncates the
+// bodies of a check record.
+func (s LogSchema) redactCheck(data *CheckData) {
+	if s.redactor != nil {
+		data.RequestHeaders = s.redactor.Headers(data.RequestHeaders)
+		data.ResponseHeaders = s.redactor.Headers(data.ResponseHeaders)
+		data.RequestBody = s.redactor.Body(data.RequestBody)
+		data.ResponseBody = s.redactor.Body(data.ResponseBody)
+	}
+	data.RequestBody = s.truncateBody(data.RequestBody)
+	data.ResponseBody = s.truncateBody(data.ResponseBody)
+}
+
+func (s LogSchema) truncateBody(body string) string {
+	if s.maxBodyLength > 0 && len(body) > s.maxBodyLength {
+		end := s.maxBodyLength
+		for end > 0 && !utf8.RuneStart(body[end]) {
+			end--
+		}
+		body = body[:end] + _truncatedSuffix
+	}
+	return body
+}
+
+type logField struct {
+	name  string
+	value json.RawMessage
+}
+
+// encode renders data as a JSON object, which log shippers receive, and as
+// the line to print in the configured format.
+func (s LogSchema) encode(data any) (jsonLine, line string, err error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return "", "", err
+	}
+
+	fields, err := decodeFields(buf.Bytes())
+	if err != nil {
+		return "", "", err
+	}
+	fields = slices.DeleteFunc(fields, func(field logField) bool { return !s.keep(field.name) })
+
+	var object strings.Builder
+	object.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			object.WriteByte(',')
+		}
+		object.WriteString(strconv.Quote(field.name))
+		object.WriteByte(':')
+		object.Write(field.value)
+	}
+	object.WriteByte('}')
+
+	if s.Format == LogFormatLogfmt {
+		return object.String(), encodeLogfmt(fields), nil
+	}
+	return object.String(), object.String(), nil
+}
+
+// decodeFields splits a JSON object into its fields, keeping their order.
+func decodeFields(data []byte) ([]logField, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var fields []logField
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, logField{name: token.(string), value: value})
+	}
+	return fields, nil
+}
+
+// encodeLogfmt writes key=value pairs. Objects are flattened into dotted
+// keys such as labels.team and arrays joined with commas.
+func encodeLogfmt(fields []logField) string {
+	var pairs []string
+	for _, field := range fields {
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(field.value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			continue
+		}
+		pairs = appendLogfmt(pairs, field.name, value)
+	}
+	return strings.Join(pairs, " ")
+}
+
+func appendLogfmt(pairs []string, key string, value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return pairs
+	case map[string]any:
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			pairs = appendLogfmt(pairs, key+"."+name, v[name])
+		}
+		return pairs
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return append(pairs, key+"="+logfmtValue(strings.Join(values, ",")))
+	case string:
+		return append(pairs, key+"="+logfmtValue(v))
+	default:
+		return append(pairs, fmt.Sprintf("%s=%v", key, v))
+	}
+}
+
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\\t\r\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Owloops/updo/redact"
+)
+
+func testCheckData() CheckData {
+	return CheckData{
+		Type:            "check",
+		Timestamp:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		URL:             "https://example.com",
+		StatusCode:      200,
+		Success:         true,
+		Method:          "POST",
+		RequestHeaders:  map[string][]string{"authorization": {"Bearer secret"}, "Content-Type": {"application/json"}},
+		ResponseHeaders: map[string][]string{"Set-Cookie": {"session=abc"}},
+		RequestBody:     `{"password":"hunter2","user":"bob"}`,
+		ResponseBody:    "hello wörld",
+		Labels:          map[string]string{"team": "ops"},
+	}
+}
+
+func TestLogSchema_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		present []string
+		absent  []string
+	}{
+		{"all fields", nil, []string{"type", "request_body", "status_code"}, nil},
+		{"include", []string{"status_code", "url"}, []string{"type", "timestamp", "status_code", "url"}, []string{"request_body", "method"}},
+		{"exclude", []string{"-request_body", "-response_headers"}, []string{"type", "status_code", "response_body"}, []string{"request_body", "response_headers"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewLogSchema("", tt.fields, nil, nil, 0)
+			if err != nil {
+				t.Fatalf("NewLogSchema failed: %v", err)
+			}
+
+			jsonLine, line, err := schema.encode(testCheckData())
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if jsonLine != line {
+				t.Errorf("JSON format should print the JSON line")
+			}
+
+			var parsed map[string]any
+			if err := json.Unmarshal([]byte(jsonLine), &parsed); err != nil {
+				t.Fatalf("Invalid JSON %s: %v", jsonLine, err)
+			}
+			for _, field := range tt.present {
+				if _, ok := parsed[field]; !ok {
+					t.Errorf("Expected field %q in %s", field, jsonLine)
+				}
+			}
+			for _, field := range tt.absent {
+				if _, ok := parsed[field]; ok {
+					t.Errorf("Field %q should be left out of %s", field, jsonLine)
+				}
+			}
+			if !strings.HasPrefix(jsonLine, `{"type":"check","timestamp":`) {
+				t.Errorf("Field order should be kept, got %s", jsonLine)
+			}
+		})
+	}
+}
+
+func TestNewLogSchema_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		fields     []string
+		redactBody []string
+	}{
+		{"unknown format", "xml", nil, nil},
+		{"unknown field", "", []string{"-password"}, nil},
+		{"mixed include and exclude", "", []string{"url", "-request_body"}, nil},
+		{"invalid pattern", "", nil, []string{"("}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLogSchema(tt.format, tt.fields, nil, tt.redactBody, 0); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestLogSchema_Redaction(t *testing.T) {
+	schema, err := NewLogSchema("", nil, []string{"Authorization", "Set-Cookie"}, []string{`"password":"[^"]*"`}, 8)
+	if err != nil {
+		t.Fatalf("NewLogSchema failed: %v", err)
+	}
+
+	data := testCheckData()
+	original := data.RequestHeaders["authorization"][0]
+	schema.redactCheck(&data)
+
+	if got := data.RequestHeaders["authorization"]; len(got) != 1 || got[0] != redact.Mask {
+		t.Errorf("Authorization should be redacted regardless of case, got %v", got)
+	}
+	if got := data.ResponseHeaders["Set-Cookie"]; got[0] != redact.Mask {
+		t.Errorf("Set-Cookie should be redacted, got %v", got)
+	}
+	if got := data.RequestHeaders["Content-Type"]; got[0] != "application/json" {
+		t.Errorf("Content-Type should be kept, got %v", got)
+	}
+	if testCheckData().RequestHeaders["authorization"][0] != original {
+		t.Error("Redaction must not modify the result headers")
+	}
+	if data.RequestBody != "{[REDACT"+_truncatedSuffix {
+		t.Errorf("Unexpected request body %q", data.RequestBody)
+	}
+	if data.ResponseBody != "hello w"+_truncatedSuffix {
+		t.Errorf("Truncation should not split characters, got %q", data.ResponseBody)
+	}
+}
+
+func TestLogSchema_Logfmt(t *testing.T) {
+	schema, err := NewLogSchema(LogFormatLogfmt, []string{"url", "status_code", "success", "response_body", "request_headers", "labels"}, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("NewLogSchema failed: %v", err)
+	}
+
+	jsonLine, line, err := schema.encode(testCheckData())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	want := `type=check timestamp=2024-01-02T03:04:05Z url=https://example.com status_code=200 success=true ` +
+		`request_headers.Content-Type=application/json request_headers.authorization="Bearer secret" ` +
+		`response_body="hello wörld" labels.team=ops`
+	if line != want {
+		t.Errorf("logfmt line =\n%s\nwant\n%s", line, want)
+	}
+	if !json.Valid([]byte(jsonLine)) {
+		t.Errorf("Shippers should still receive JSON, got %s", jsonLine)
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"fmt"
+	"maps"
+	stdnet "net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// _syslogSDID names the structured data element. 32473 is the private
+	// enterprise number RFC 5424 reserves for examples.
+	_syslogSDID        = "updo@32473"
+	_syslogDialTimeout = 5 * time.Second
+	_syslogMaxParam    = 32
+)
+
+var _syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var _syslogLocalSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+var _syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogOutput sends RFC 5424 messages with the line fields as structured
+// data. Stream connections use octet counting framing (RFC 6587).
+type syslogOutput struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+
+	mu   sync.Mutex
+	conn stdnet.Conn
+}
+
+// NewSyslogOutput connects to a syslog daemon at address, given as
+// udp://host:port, tcp://host:port or unix:///path. An empty address uses
+// the local syslog socket. The facility defaults to daemon.
+func NewSyslogOutput(address, facility, appName string) (LineOutput, error) {
+	if facility == "" {
+		facility = "daemon"
+	}
+	code, ok := _syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+
+	hostname, _ := os.Hostname()
+	output := &syslogOutput{
+		facility: code,
+		appName:  appName,
+		hostname: hostname,
+	}
+
+	if address == "" {
+		for _, socket := range _syslogLocalSockets {
+			output.network, output.address = "unixgram", socket
+			if err := output.connect(); err == nil {
+				return output, nil
+			}
+		}
+		return nil, fmt.Errorf("no local syslog socket found")
+	}
+
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %w", address, err)
+	}
+	switch parsed.Scheme {
+	case "udp", "tcp":
+		output.network, output.address = parsed.Scheme, parsed.Host
+	case "unix":
+		output.network, output.address = "unixgram", parsed.Path
+	default:
+		return nil, fmt.Errorf("unsupported syslog address %q, expected udp://, tcp:// or unix://", address)
+	}
+
+	if err := output.connect(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (s *syslogOutput) connect() error {
+	conn, err := stdnet.DialTimeout(s.network, s.address, _syslogDialTimeout)
+	if err != nil && s.network == "unixgram" {
+		// Some daemons only listen on a stream socket.
+		s.network = "unix"
+		conn, err = stdnet.DialTimeout(s.network, s.address, _syslogDialTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog at %s: %w", s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *syslogOutput) WriteLine(severity Severity, line string, fields map[string]string) error {
+	message := s.format(severity, time.Now(), line, fields)
+	if s.network == "tcp" || s.network == "unix" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if _, err := s.conn.Write([]byte(message)); err == nil {
+			return nil
+		}
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+
+	// The daemon may have restarted, reconnect once before giving up.
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write([]byte(message))
+	return err
+}
+
+func (s *syslogOutput) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format renders <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG,
+// using the record type as MSGID.
+func (s *syslogOutput) format(severity Severity, timestamp time.Time, line string, fields map[string]string) string {
+	msgID := fields["type"]
+	if msgID == "" {
+		msgID = "-"
+	}
+	hostname := s.hostname
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+int(severity),
+		timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		hostname,
+		s.appName,
+		os.Getpid(),
+		msgID,
+		structuredData(fields),
+		line)
+}
+
+func structuredData(fields map[string]string) string {
+	if len(fields) == 0 {
+		return "-"
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + _syslogSDID)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if name == "" || len(name) > _syslogMaxParam || strings.ContainsAny(name, `= ]"`) {
+			continue
+		}
+		fmt.Fprintf(&sd, ` %s="%s"`, name, _syslogParamEscaper.Replace(fields[name]))
+	}
+	sd.WriteString("]")
+	return sd.String()
+}
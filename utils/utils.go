@@ -2,9 +2,15 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/Owloops/updo/redact"
+	"github.com/Owloops/updo/stats"
 )
 
+const _incidentTimeFormat = "2006-01-02 15:04:05"
+
 func BoolToFloat64(b bool) float64 {
 	if b {
 		return 1.0
@@ -27,3 +33,24 @@ func SanitizeDuration(d time.Duration) time.Duration {
 	}
 	return d
 }
+
+// FormatIncident renders an incident as a single human readable line,
+// with the secrets of its error masked.
+func FormatIncident(incident stats.Incident, now time.Time) string {
+	var builder strings.Builder
+	builder.WriteString(incident.Start.Format(_incidentTimeFormat))
+	if incident.IsOngoing() {
+		builder.WriteString(" - ongoing")
+	} else {
+		builder.WriteString(" - ")
+		builder.WriteString(incident.End.Format(_incidentTimeFormat))
+	}
+	fmt.Fprintf(&builder, " (%s)", FormatDurationMinute(incident.Duration(now)))
+	if len(incident.Regions) > 0 {
+		fmt.Fprintf(&builder, " [%s]", strings.Join(incident.Regions, ", "))
+	}
+	if incident.FirstError != "" {
+		fmt.Fprintf(&builder, ": %s", redact.Text(incident.FirstError))
+	}
+	return builder.String()
+}
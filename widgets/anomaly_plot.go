@@ -0,0 +1,67 @@
+package widgets
+
+import (
+	"image"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+const (
+	// Mirror the axis layout of termui's Plot so markers land on its points.
+	_plotYAxisLabelsWidth  = 4
+	_plotXAxisLabelsHeight = 1
+
+	_anomalyMarker = '●'
+)
+
+// AnomalyPlot is a Plot that highlights flagged points of its first series.
+type AnomalyPlot struct {
+	widgets.Plot
+	Anomalies    []bool
+	AnomalyColor ui.Color
+}
+
+func NewAnomalyPlot() *AnomalyPlot {
+	return &AnomalyPlot{
+		Plot:         *widgets.NewPlot(),
+		AnomalyColor: ui.ColorRed,
+	}
+}
+
+func (ap *AnomalyPlot) Draw(buf *ui.Buffer) {
+	ap.Plot.Draw(buf)
+
+	if len(ap.Data) == 0 || len(ap.Anomalies) == 0 {
+		return
+	}
+
+	maxVal := ap.MaxVal
+	if maxVal == 0 {
+		maxVal, _ = ui.GetMaxFloat64From2dSlice(ap.Data)
+	}
+	if maxVal <= 0 {
+		return
+	}
+
+	drawArea := ap.Inner
+	if ap.ShowAxes {
+		drawArea = image.Rect(
+			ap.Inner.Min.X+_plotYAxisLabelsWidth+1, ap.Inner.Min.Y,
+			ap.Inner.Max.X, ap.Inner.Max.Y-_plotXAxisLabelsHeight-1,
+		)
+	}
+
+	style := ui.NewStyle(ap.AnomalyColor)
+	series := ap.Data[0]
+	for i, anomalous := range ap.Anomalies {
+		if !anomalous || i >= len(series) {
+			continue
+		}
+		height := int((series[i] / maxVal) * float64(drawArea.Dy()-1))
+		point := image.Pt(drawArea.Min.X+i*ap.HorizontalScale, drawArea.Max.Y-1-height)
+		if point.In(drawArea) {
+			buf.SetCell(ui.NewCell(_anomalyMarker, style), point)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package widgets
+
+import (
+	"image"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+func TestAnomalyPlot_Draw(t *testing.T) {
+	plot := NewAnomalyPlot()
+	plot.Data = [][]float64{{0, 0, 0.1, 0.1, 0.5, 0.1}}
+	plot.Anomalies = []bool{false, false, false, false, true, false}
+	plot.SetRect(0, 0, 40, 12)
+
+	buf := ui.NewBuffer(plot.GetRect())
+	plot.Draw(buf)
+
+	markers := 0
+	for point, cell := range buf.CellMap {
+		if cell.Rune == _anomalyMarker {
+			markers++
+			if !point.In(image.Rect(0, 0, 40, 12)) {
+				t.Errorf("Marker drawn outside plot at %v", point)
+			}
+		}
+	}
+
+	if markers != 1 {
+		t.Errorf("Expected 1 anomaly marker, got %d", markers)
+	}
+}